@@ -0,0 +1,52 @@
+// auditverify проверяет хеш-цепочку журнала аудита и завершается с кодом 1,
+// если хотя бы одна запись изменена, удалена или вставлена в обход приложения
+package main
+
+import (
+	"avito-shop/internal/audit"
+	"avito-shop/internal/logger"
+	"avito-shop/internal/repository"
+	"avito-shop/internal/service"
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	dsn := flag.String("dsn", os.Getenv("PG_DSN"), "строка подключения к Postgres, по умолчанию из PG_DSN")
+	flag.Parse()
+
+	if *dsn == "" {
+		fmt.Fprintln(os.Stderr, "auditverify: -dsn or PG_DSN is required")
+		os.Exit(2)
+	}
+
+	db, err := sql.Open("postgres", *dsn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "auditverify: open database: %v\n", err)
+		os.Exit(2)
+	}
+	defer db.Close()
+
+	logs := logger.NewLogger()
+	verifier := service.NewAuditService(repository.NewAuditRepo(db, logs), logs)
+
+	checked, err := verifier.Verify(context.Background())
+
+	var chainErr *audit.ChainError
+	switch {
+	case errors.As(err, &chainErr):
+		fmt.Printf("audit log is broken after %d valid entries: %v\n", checked, chainErr)
+		os.Exit(1)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "auditverify: %v\n", err)
+		os.Exit(2)
+	}
+
+	fmt.Printf("audit log is intact: %d entries verified\n", checked)
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"avito-shop/internal/i18n"
+	"avito-shop/internal/model"
+	"context"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=ServiceAdminInterface
+type ServiceAdminInterface interface {
+	IsAdmin(ctx context.Context, userID int) (bool, error)
+	HasRole(ctx context.Context, userID int, role string) (bool, error)
+	MintCoins(ctx context.Context, actorID int, dto model.CoinOperationRequestDTO) (int, error)
+	BurnCoins(ctx context.Context, actorID int, dto model.CoinOperationRequestDTO) (int, error)
+	AddGroupMember(ctx context.Context, actorID int, group string, username string) error
+	RemoveGroupMember(ctx context.Context, actorID int, group string, username string) error
+}
+
+func (r *Api) MintCoins(c *gin.Context) {
+	r.coinOperation(c, r.admin.MintCoins)
+}
+
+func (r *Api) BurnCoins(c *gin.Context) {
+	r.coinOperation(c, r.admin.BurnCoins)
+}
+
+func (r *Api) coinOperation(c *gin.Context, apply func(context.Context, int, model.CoinOperationRequestDTO) (int, error)) {
+	actorID, err := getUserId(c)
+	if err != nil {
+		r.log(c).Error("unidentified user")
+		r.respondError(c, errUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	var input model.CoinOperationRequestDTO
+
+	err = c.ShouldBindJSON(&input)
+	if err != nil {
+		r.log(c).Error("error bind json", zap.Error(err))
+		r.respondError(c, errInvalidInput)
+		return
+	}
+
+	affected, err := apply(ctx, actorID, input)
+	if err != nil {
+		r.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.CoinOperationResponseDTO{Affected: affected})
+}
+
+func (r *Api) AddGroupMember(c *gin.Context) {
+	actorID, err := getUserId(c)
+	if err != nil {
+		r.log(c).Error("unidentified user")
+		r.respondError(c, errUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	var input model.GroupMemberRequestDTO
+
+	err = c.ShouldBindJSON(&input)
+	if err != nil {
+		r.log(c).Error("error bind json", zap.Error(err))
+		r.respondError(c, errInvalidInput)
+		return
+	}
+
+	err = r.admin.AddGroupMember(ctx, actorID, c.Param("group"), input.Username)
+	if err != nil {
+		r.respondError(c, err)
+		return
+	}
+
+	r.respondMessage(c, i18n.MsgGroupMemberAdded)
+}
+
+func (r *Api) RemoveGroupMember(c *gin.Context) {
+	actorID, err := getUserId(c)
+	if err != nil {
+		r.log(c).Error("unidentified user")
+		r.respondError(c, errUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	err = r.admin.RemoveGroupMember(ctx, actorID, c.Param("group"), c.Param("username"))
+	if err != nil {
+		r.respondError(c, err)
+		return
+	}
+
+	r.respondMessage(c, i18n.MsgGroupMemberRemoved)
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"avito-shop/internal/domain"
+	"avito-shop/internal/model"
+	"context"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=ServiceAPIKeyInterface
+type ServiceAPIKeyInterface interface {
+	CreateKey(ctx context.Context, actorID int, dto model.APIKeyRequestDTO) (model.APIKeyDTO, error)
+	ListKeys(ctx context.Context) ([]model.APIKeyDTO, error)
+	RevokeKey(ctx context.Context, actorID int, id int) error
+	Authenticate(ctx context.Context, plain string) (domain.APIKey, error)
+}
+
+func (r *Api) CreateAPIKey(c *gin.Context) {
+	actorID, err := getUserId(c)
+	if err != nil {
+		r.log(c).Error("unidentified user")
+		r.respondError(c, errUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	var input model.APIKeyRequestDTO
+
+	err = c.ShouldBindJSON(&input)
+	if err != nil {
+		r.log(c).Error("error bind json", zap.Error(err))
+		r.respondError(c, errInvalidInput)
+		return
+	}
+
+	key, err := r.apiKeys.CreateKey(ctx, actorID, input)
+	if err != nil {
+		r.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+func (r *Api) ListAPIKeys(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	keys, err := r.apiKeys.ListKeys(ctx)
+	if err != nil {
+		r.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func (r *Api) RevokeAPIKey(c *gin.Context) {
+	actorID, err := getUserId(c)
+	if err != nil {
+		r.log(c).Error("unidentified user")
+		r.respondError(c, errUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		r.respondError(c, errInvalidInput)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := r.apiKeys.RevokeKey(ctx, actorID, id); err != nil {
+		r.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
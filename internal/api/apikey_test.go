@@ -0,0 +1,99 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"avito-shop/internal/api/mocks"
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	"avito-shop/internal/model"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUserIdentity_APIKey(t *testing.T) {
+	mockKeys := mocks.NewServiceAPIKeyInterface(t)
+	mockKeys.On("Authenticate", mock.Anything, "shk_dashboard").
+		Return(domain.APIKey{ID: 4, UserID: 1, Scopes: []string{domain.ScopeInfoRead}}, nil).Maybe()
+	mockKeys.On("Authenticate", mock.Anything, "shk_revoked").Return(domain.APIKey{}, erorrs.ErrAPIKeyInvalid).Maybe()
+
+	mockUser := mocks.NewServiceUserInterface(t)
+	mockUser.On("GetUserInfo", mock.Anything, 1).Return(model.InfoResponseDTO{}, nil).Maybe()
+
+	router := NewApi(nopLogger{}, nil, mockUser, nil, nil, nil, mockKeys, nil, newTestLocalizer(t), nil, nil).InitRoutes(nil)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		key            string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "scope granted", method: http.MethodGet, path: "/api/info", key: "shk_dashboard", expectedStatus: http.StatusOK},
+		{name: "scope missing", method: http.MethodPost, path: "/api/buy/cup", key: "shk_dashboard", expectedStatus: http.StatusForbidden, expectedCode: CodeInsufficientScope},
+		{name: "admin scope missing", method: http.MethodGet, path: "/api/admin/apikeys", key: "shk_dashboard", expectedStatus: http.StatusForbidden, expectedCode: CodeInsufficientScope},
+		{name: "revoked key", method: http.MethodGet, path: "/api/info", key: "shk_revoked", expectedStatus: http.StatusUnauthorized, expectedCode: CodeAPIKeyInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set(apiKeyHeader, tt.key)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedCode != "" {
+				var response model.ErrorResponseDTO
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedCode, response.Code)
+			}
+		})
+	}
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	mockKeys := mocks.NewServiceAPIKeyInterface(t)
+	mockKeys.On("CreateKey", mock.Anything, 1, model.APIKeyRequestDTO{
+		Username: "ci-bot", Name: "merged PRs", Scopes: []string{domain.ScopeCoinsSend},
+	}).Return(model.APIKeyDTO{ID: 7, Username: "ci-bot", Key: "shk_secret", Scopes: []string{domain.ScopeCoinsSend}}, nil)
+	mockKeys.On("CreateKey", mock.Anything, 1, model.APIKeyRequestDTO{
+		Username: "ci-bot", Name: "mint", Scopes: []string{"coins:mint"},
+	}).Return(model.APIKeyDTO{}, erorrs.ErrUnknownScope)
+
+	api := NewApi(nopLogger{}, nil, nil, nil, nil, nil, mockKeys, nil, newTestLocalizer(t), nil, nil)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "created", body: `{"username":"ci-bot","name":"merged PRs","scopes":["coins:send"]}`, expectedStatus: http.StatusCreated},
+		{name: "unknown scope", body: `{"username":"ci-bot","name":"mint","scopes":["coins:mint"]}`, expectedStatus: http.StatusBadRequest},
+		{name: "no scopes", body: `{"username":"ci-bot","name":"empty","scopes":[]}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/apikeys", func(c *gin.Context) { c.Set(userCtx, 1) }, api.CreateAPIKey)
+
+			req := httptest.NewRequest(http.MethodPost, "/apikeys", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+		})
+	}
+}
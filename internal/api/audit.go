@@ -0,0 +1,37 @@
+package api
+
+import (
+	"avito-shop/internal/model"
+	"context"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=ServiceAuditInterface
+type ServiceAuditInterface interface {
+	Query(ctx context.Context, dto model.AuditQueryDTO) ([]model.AuditEntryDTO, error)
+}
+
+func (r *Api) GetAuditLog(c *gin.Context) {
+	var input model.AuditQueryDTO
+
+	err := c.ShouldBindQuery(&input)
+	if err != nil {
+		r.log(c).Error("error bind query", zap.Error(err))
+		r.respondError(c, errInvalidInput)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	entries, err := r.audit.Query(ctx, input)
+	if err != nil {
+		r.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,78 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"avito-shop/internal/api/mocks"
+	"avito-shop/internal/audit"
+	"avito-shop/internal/domain"
+	"avito-shop/internal/model"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGetAuditLog(t *testing.T) {
+	mockAdmin := mocks.NewServiceAdminInterface(t)
+	mockAdmin.On("HasRole", mock.Anything, 1, domain.RoleAuditor).Return(true, nil).Maybe()
+	mockAdmin.On("HasRole", mock.Anything, 2, domain.RoleAuditor).Return(false, nil).Maybe()
+
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	mockAudit := mocks.NewServiceAuditInterface(t)
+	mockAudit.On("Query", mock.Anything, model.AuditQueryDTO{
+		ActorID: 5, Action: domain.AuditLoginFailed, From: from, Limit: 10,
+	}).Return([]model.AuditEntryDTO{{ID: 3, ActorID: 5, Action: domain.AuditLoginFailed, Hash: "abc"}}, nil).Maybe()
+
+	api := NewApi(nopLogger{}, nil, nil, mockAdmin, nil, mockAudit, nil, nil, newTestLocalizer(t), nil, nil)
+
+	tests := []struct {
+		name           string
+		userID         int
+		query          string
+		expectedStatus int
+	}{
+		{name: "auditor", userID: 1, query: "?actorId=5&action=auth.login_failed&from=2026-10-01T00:00:00Z&limit=10", expectedStatus: http.StatusOK},
+		{name: "not an auditor", userID: 2, query: "?actorId=5", expectedStatus: http.StatusForbidden},
+		{name: "limit too large", userID: 1, query: "?limit=5000", expectedStatus: http.StatusBadRequest},
+		{name: "malformed time", userID: 1, query: "?from=yesterday", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/api/audit", func(c *gin.Context) { c.Set(userCtx, tt.userID) }, api.AuditorIdentity, api.GetAuditLog)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/audit"+tt.query, nil))
+
+			require.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedStatus == http.StatusOK {
+				var entries []model.AuditEntryDTO
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+				assert.Equal(t, int64(3), entries[0].ID)
+			}
+		})
+	}
+}
+
+func TestRequestID_SetsAuditInfo(t *testing.T) {
+	var info audit.RequestInfo
+	router := newTestRouter(&Api{logger: nopLogger{}}, func(c *gin.Context) {
+		info = audit.RequestInfoFromContext(c.Request.Context())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set(requestIDHeader, "req-9")
+	req.Header.Set("User-Agent", "curl/8")
+	req.RemoteAddr = "10.0.0.7:5123"
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, audit.RequestInfo{IP: "10.0.0.7", UserAgent: "curl/8", RequestID: "req-9"}, info)
+}
@@ -14,7 +14,14 @@ import (
 //go:generate go run github.com/vektra/mockery/v2@latest --name=ServiceAuthInterface
 type ServiceAuthInterface interface {
 	Authorization(ctx context.Context, dto model.AuthRequestDTO) (string, error)
-	GenerateJwtToken(userId int) (string, error)
+	GenerateJwtToken(userId int, version int, scopes []string) (string, error)
+	CheckSession(ctx context.Context, userID int, version int) error
+	ChangePassword(ctx context.Context, userID int, scopes []string, dto model.PasswordChangeRequestDTO) (string, error)
+	IssueResetToken(ctx context.Context, actorID int, username string) (model.PasswordResetTokenDTO, error)
+	ResetPassword(ctx context.Context, dto model.PasswordResetRequestDTO) error
+	EnrollMFA(ctx context.Context, userID int) (model.MFAEnrollmentDTO, error)
+	ConfirmMFA(ctx context.Context, userID int, dto model.MFAConfirmRequestDTO) (model.MFARecoveryCodesDTO, error)
+	VerifyMFA(ctx context.Context, dto model.MFAVerifyRequestDTO) (string, error)
 }
 
 func (r *Api) Auth(c *gin.Context) {
@@ -25,27 +32,24 @@ func (r *Api) Auth(c *gin.Context) {
 
 	err := c.ShouldBindJSON(&input)
 	if err != nil {
-		r.logger.Error("invalid request body", zap.Error(err))
-		c.JSON(http.StatusBadRequest, model.ErrorResponseDTO{Error: "неправильное данные для ввода"})
+		r.log(c).Error("invalid request body", zap.Error(err))
+		r.respondError(c, errInvalidInput)
 		return
 	}
 
 	if input.Username == "" || input.Password == "" {
-		c.JSON(http.StatusBadRequest, model.ErrorResponseDTO{Error: "поля имя и пароль обязательны к заполнению"})
+		r.respondError(c, errMissingCredentials)
 		return
 	}
 
 	token, err := r.auth.Authorization(ctx, input)
 	if err != nil {
-		switch {
-		case errors.Is(err, erorrs.ErrNotFound):
-			c.JSON(http.StatusUnauthorized, model.ErrorResponseDTO{Error: "неправильны данные для входа"})
-		case errors.Is(err, erorrs.ErrUserExist):
-			c.JSON(http.StatusBadRequest, model.ErrorResponseDTO{Error: "пользователь уже существует"})
-		default:
-			r.logger.Error("Authentication error", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, model.ErrorResponseDTO{Error: "внутренняя ошибка сервера"})
+		// Authorization регистрирует неизвестного пользователя, поэтому ErrUserExist
+		// здесь означает, что пароль не подошел к существующему имени
+		if errors.Is(err, erorrs.ErrNotFound) || errors.Is(err, erorrs.ErrUserExist) {
+			err = errInvalidCredentials
 		}
+		r.respondError(c, err)
 		return
 	}
 
@@ -0,0 +1,219 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"avito-shop/internal/api/mocks"
+	"avito-shop/internal/api/openapi"
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	"avito-shop/internal/logger"
+	"avito-shop/internal/model"
+	"avito-shop/internal/ratelimit"
+	"avito-shop/internal/service"
+	"bytes"
+	"context"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// nopLogger нужен контрактному тесту, чтобы прогонять запросы через все middleware InitRoutes
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...zap.Field)         {}
+func (nopLogger) Error(string, ...zap.Field)        {}
+func (l nopLogger) With(...zap.Field) logger.Logger { return l }
+
+func loadSpec(t *testing.T) *openapi3.T {
+	t.Helper()
+
+	doc, err := openapi3.NewLoader().LoadFromData(openapi.Spec)
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(context.Background()))
+
+	return doc
+}
+
+func TestOpenAPI_ResponsesMatchSpec(t *testing.T) {
+	doc := loadSpec(t)
+	specRouter, err := legacy.NewRouter(doc)
+	require.NoError(t, err)
+
+	mockAuth := mocks.NewServiceAuthInterface(t)
+	mockAuth.On("Authorization", mock.Anything, model.AuthRequestDTO{Username: "user", Password: "pass"}).Return("token", nil).Maybe()
+	mockAuth.On("Authorization", mock.Anything, model.AuthRequestDTO{Username: "user", Password: "wrong"}).Return("", erorrs.ErrUserExist).Maybe()
+	mockAuth.On("Authorization", mock.Anything, model.AuthRequestDTO{Username: "locked", Password: "pass"}).
+		Return("", &ratelimit.RetryAfterError{RetryAfter: time.Minute}).Maybe()
+	mockAuth.On("Authorization", mock.Anything, model.AuthRequestDTO{Username: "mfa", Password: "pass"}).
+		Return("", &service.MFARequiredError{Token: "challenge", ExpiresAt: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)}).Maybe()
+	mockAuth.On("VerifyMFA", mock.Anything, model.MFAVerifyRequestDTO{MFAToken: "challenge", Code: "123456"}).Return("token", nil).Maybe()
+	mockAuth.On("VerifyMFA", mock.Anything, model.MFAVerifyRequestDTO{MFAToken: "challenge", Code: "000000"}).Return("", erorrs.ErrMFACodeInvalid).Maybe()
+	mockAuth.On("VerifyMFA", mock.Anything, model.MFAVerifyRequestDTO{MFAToken: "expired", Code: "123456"}).Return("", erorrs.ErrMFAChallengeInvalid).Maybe()
+	mockAuth.On("EnrollMFA", mock.Anything, 1).Return(model.MFAEnrollmentDTO{
+		Secret:     "GEZDGNBVGY3TQOJQ",
+		OTPAuthURI: "otpauth://totp/avito-shop:user?secret=GEZDGNBVGY3TQOJQ",
+	}, nil).Maybe()
+	mockAuth.On("ConfirmMFA", mock.Anything, 1, model.MFAConfirmRequestDTO{Code: "123456"}).
+		Return(model.MFARecoveryCodesDTO{RecoveryCodes: []string{"abcd-ef01-2345-6789"}}, nil).Maybe()
+	mockAuth.On("ConfirmMFA", mock.Anything, 1, model.MFAConfirmRequestDTO{Code: "000000"}).Return(model.MFARecoveryCodesDTO{}, erorrs.ErrMFACodeInvalid).Maybe()
+	mockAuth.On("CheckSession", mock.Anything, 1, 0).Return(nil).Maybe()
+	mockAuth.On("ChangePassword", mock.Anything, 1, domain.Scopes, model.PasswordChangeRequestDTO{CurrentPassword: "pass", NewPassword: "secret42"}).
+		Return("token", nil).Maybe()
+	mockAuth.On("ChangePassword", mock.Anything, 1, domain.Scopes, model.PasswordChangeRequestDTO{CurrentPassword: "wrong", NewPassword: "secret42"}).
+		Return("", erorrs.ErrWrongPassword).Maybe()
+	mockAuth.On("ResetPassword", mock.Anything, model.PasswordResetRequestDTO{Token: "stale", NewPassword: "secret42"}).
+		Return(erorrs.ErrResetTokenInvalid).Maybe()
+
+	mockUser := mocks.NewServiceUserInterface(t)
+	mockUser.On("GetUserInfo", mock.Anything, 1).Return(model.InfoResponseDTO{
+		Coins:     domain.NewMoney(990),
+		Inventory: []model.ItemDTO{{Type: "cup", Quantity: 1}},
+		Wallets:   []model.WalletDTO{{Currency: domain.DefaultCurrency, Balance: domain.NewMoney(990)}},
+		CoinHistory: model.CoinHistoryDTO{
+			Sent:       []model.TransactionHistoryDTO{{ToUser: "friend", Amount: domain.NewMoney(10), Fee: 50, Currency: domain.DefaultCurrency}},
+			Operations: []model.OperationHistoryDTO{{Type: domain.OperationWelcome, Amount: domain.NewMoney(1000), Currency: domain.DefaultCurrency, Reason: "welcome grant"}},
+		},
+		ExpiringSoon: []model.ExpiringCoinsDTO{{Amount: domain.NewMoney(990), Currency: domain.DefaultCurrency, ExpiresAt: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)}},
+	}, nil).Maybe()
+	mockUser.On("SendCoinToUser", mock.Anything, 1, "friend", domain.NewMoney(10), "").Return(nil).Maybe()
+	mockUser.On("SendCoinToUser", mock.Anything, 1, "friend", domain.NewMoney(5000), "").Return(erorrs.ErrInsufficientFunds).Maybe()
+	mockUser.On("SendCoinToUser", mock.Anything, 1, "ghost", domain.NewMoney(10), "").Return(erorrs.ErrNotFound).Maybe()
+	mockUser.On("QuoteTransfer", mock.Anything, domain.Money(1050), "").Return(model.TransferQuoteDTO{
+		Amount: 1050, Fee: 11, Total: 1061, Currency: domain.DefaultCurrency,
+	}, nil).Maybe()
+	mockUser.On("QuoteTransfer", mock.Anything, domain.Money(1050), "doge").Return(model.TransferQuoteDTO{}, erorrs.ErrCurrencyNotFound).Maybe()
+	mockUser.On("BuyItem", mock.Anything, 1, model.BuyItemRequestDTO{Item: "cup"}).Return(nil).Maybe()
+	mockUser.On("BuyItem", mock.Anything, 1, model.BuyItemRequestDTO{Item: "yacht"}).Return(erorrs.ErrItemNotFound).Maybe()
+
+	token, err := service.NewAuthService(nil, nil, nil, nopLogger{}, 0, domain.ExpiryPolicy{}, domain.PasswordPolicy{}).GenerateJwtToken(1, 0, domain.Scopes)
+	require.NoError(t, err)
+
+	limiter := ratelimit.NewRouteLimiter(ratelimit.Budget{}, map[string]ratelimit.Budget{
+		"POST /api/buy/:item": {Rate: 0.01, Burst: 3},
+	})
+	readOnlyToken, err := service.NewAuthService(nil, nil, nil, nopLogger{}, 0, domain.ExpiryPolicy{}, domain.PasswordPolicy{}).GenerateJwtToken(1, 0, []string{domain.ScopeInfoRead})
+	require.NoError(t, err)
+
+	router := NewApi(nopLogger{}, mockAuth, mockUser, nil, nil, nil, nil, nil, newTestLocalizer(t), nil, limiter).InitRoutes(nil)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		auth           bool
+		token          string
+		expectedStatus int
+	}{
+		{name: "auth", method: http.MethodPost, path: "/api/auth", body: `{"username":"user","password":"pass"}`, expectedStatus: http.StatusOK},
+		{name: "auth wrong password", method: http.MethodPost, path: "/api/auth", body: `{"username":"user","password":"wrong"}`, expectedStatus: http.StatusUnauthorized},
+		{name: "auth locked out", method: http.MethodPost, path: "/api/auth", body: `{"username":"locked","password":"pass"}`, expectedStatus: http.StatusTooManyRequests},
+		{name: "auth empty password", method: http.MethodPost, path: "/api/auth", body: `{"username":"user","password":""}`, expectedStatus: http.StatusBadRequest},
+		{name: "auth mfa required", method: http.MethodPost, path: "/api/auth", body: `{"username":"mfa","password":"pass"}`, expectedStatus: http.StatusUnauthorized},
+		{name: "verify mfa", method: http.MethodPost, path: "/api/auth/mfa", body: `{"mfaToken":"challenge","code":"123456"}`, expectedStatus: http.StatusOK},
+		{name: "verify mfa wrong code", method: http.MethodPost, path: "/api/auth/mfa", body: `{"mfaToken":"challenge","code":"000000"}`, expectedStatus: http.StatusBadRequest},
+		{name: "verify mfa expired challenge", method: http.MethodPost, path: "/api/auth/mfa", body: `{"mfaToken":"expired","code":"123456"}`, expectedStatus: http.StatusUnauthorized},
+		{name: "password reset invalid token", method: http.MethodPost, path: "/api/auth/password-reset", body: `{"token":"stale","newPassword":"secret42"}`, expectedStatus: http.StatusBadRequest},
+		{name: "info", method: http.MethodGet, path: "/api/info", auth: true, expectedStatus: http.StatusOK},
+		{name: "info without token", method: http.MethodGet, path: "/api/info", expectedStatus: http.StatusUnauthorized},
+		{name: "send coin", method: http.MethodPost, path: "/api/sendCoin", body: `{"toUser":"friend","amount":"10"}`, auth: true, expectedStatus: http.StatusOK},
+		{name: "send coin insufficient funds", method: http.MethodPost, path: "/api/sendCoin", body: `{"toUser":"friend","amount":5000}`, auth: true, expectedStatus: http.StatusBadRequest},
+		{name: "send coin unknown user", method: http.MethodPost, path: "/api/sendCoin", body: `{"toUser":"ghost","amount":"10.00"}`, auth: true, expectedStatus: http.StatusNotFound},
+		{name: "quote", method: http.MethodGet, path: "/api/sendCoin/quote?amount=10.50", auth: true, expectedStatus: http.StatusOK},
+		{name: "quote unknown currency", method: http.MethodGet, path: "/api/sendCoin/quote?amount=10.50&currency=doge", auth: true, expectedStatus: http.StatusBadRequest},
+		{name: "buy with read-only token", method: http.MethodPost, path: "/api/buy/cup", token: readOnlyToken, expectedStatus: http.StatusForbidden},
+		{name: "buy", method: http.MethodPost, path: "/api/buy/cup", auth: true, expectedStatus: http.StatusOK},
+		{name: "buy unknown item", method: http.MethodPost, path: "/api/buy/yacht", auth: true, expectedStatus: http.StatusNotFound},
+		{name: "buy rate limited", method: http.MethodPost, path: "/api/buy/cup", auth: true, expectedStatus: http.StatusTooManyRequests},
+		{name: "change password", method: http.MethodPost, path: "/api/me/password", body: `{"currentPassword":"pass","newPassword":"secret42"}`, auth: true, expectedStatus: http.StatusOK},
+		{name: "enroll mfa", method: http.MethodPost, path: "/api/me/mfa", auth: true, expectedStatus: http.StatusOK},
+		{name: "confirm mfa", method: http.MethodPost, path: "/api/me/mfa/confirm", body: `{"code":"123456"}`, auth: true, expectedStatus: http.StatusOK},
+		{name: "confirm mfa wrong code", method: http.MethodPost, path: "/api/me/mfa/confirm", body: `{"code":"000000"}`, auth: true, expectedStatus: http.StatusBadRequest},
+		{name: "change password wrong current", method: http.MethodPost, path: "/api/me/password", body: `{"currentPassword":"wrong","newPassword":"secret42"}`, auth: true, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if tt.auth {
+				req.Header.Set(authHeader, "Bearer "+token)
+			}
+			if tt.token != "" {
+				req.Header.Set(authHeader, "Bearer "+tt.token)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			require.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+
+			route, pathParams, err := specRouter.FindRoute(req)
+			require.NoError(t, err)
+
+			requestInput := &openapi3filter.RequestValidationInput{
+				Request:    req,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc},
+			}
+			req.Body = io.NopCloser(strings.NewReader(tt.body))
+			if tt.expectedStatus < http.StatusBadRequest {
+				assert.NoError(t, openapi3filter.ValidateRequest(context.Background(), requestInput))
+			}
+
+			err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: requestInput,
+				Status:                 w.Code,
+				Header:                 w.Header(),
+				Body:                   io.NopCloser(bytes.NewReader(w.Body.Bytes())),
+				Options:                &openapi3filter.Options{IncludeResponseStatus: true},
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestOpenAPI_DocumentedRoutesExist(t *testing.T) {
+	doc := loadSpec(t)
+
+	router := NewApi(nopLogger{}, nil, nil, nil, nil, nil, nil, nil, newTestLocalizer(t), nil, nil).InitRoutes(nil)
+	registered := make(map[string]bool)
+	for _, route := range router.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+
+	pathParam := regexp.MustCompile(`\{(\w+)\}`)
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			ginPath := pathParam.ReplaceAllString(path, ":$1")
+			assert.True(t, registered[method+" "+ginPath], "%s %s is documented but not routed", method, path)
+		}
+	}
+}
+
+func TestOpenAPI_Served(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := NewApi(nopLogger{}, nil, nil, nil, nil, nil, nil, nil, newTestLocalizer(t), nil, nil).InitRoutes(nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, string(openapi.Spec), w.Body.String())
+}
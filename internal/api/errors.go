@@ -0,0 +1,172 @@
+package api
+
+import (
+	"avito-shop/internal/erorrs"
+	"avito-shop/internal/i18n"
+	"avito-shop/internal/model"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Коды ошибок API. Коды стабильны, клиенты могут на них опираться, в отличие от текста сообщения
+const (
+	CodeInvalidInput       = "INVALID_INPUT"
+	CodeInvalidCredentials = "INVALID_CREDENTIALS"
+	CodeUnauthorized       = "UNAUTHORIZED"
+	CodeTokenInvalid       = "TOKEN_INVALID"
+	CodeForbidden          = "FORBIDDEN"
+	CodeInsufficientScope  = "INSUFFICIENT_SCOPE"
+	CodeUserNotFound       = "USER_NOT_FOUND"
+	CodeItemNotFound       = "ITEM_NOT_FOUND"
+	CodeGroupNotFound      = "GROUP_NOT_FOUND"
+	CodeNotGroupMember     = "NOT_GROUP_MEMBER"
+	CodeCurrencyNotFound   = "CURRENCY_NOT_FOUND"
+	CodeInsufficientFunds  = "INSUFFICIENT_FUNDS"
+	CodeSelfTransfer       = "SELF_TRANSFER"
+	CodeInvalidTarget      = "INVALID_TARGET"
+	CodeWebhookNotFound    = "WEBHOOK_NOT_FOUND"
+	CodeDeliveryNotFound   = "DELIVERY_NOT_FOUND"
+	CodeUnknownEventType   = "UNKNOWN_EVENT_TYPE"
+	CodeTooManyAttempts    = "TOO_MANY_ATTEMPTS"
+	CodeRateLimited        = "RATE_LIMITED"
+	CodeAPIKeyInvalid      = "API_KEY_INVALID"
+	CodeAPIKeyNotFound     = "API_KEY_NOT_FOUND"
+	CodeUnknownScope       = "UNKNOWN_SCOPE"
+	CodeWeakPassword       = "WEAK_PASSWORD"
+	CodeWrongPassword      = "WRONG_PASSWORD"
+	CodeResetTokenInvalid  = "RESET_TOKEN_INVALID"
+	CodeSessionRevoked     = "SESSION_REVOKED"
+	CodeMFARequired        = "MFA_REQUIRED"
+	CodeMFAChallenge       = "MFA_CHALLENGE_INVALID"
+	CodeMFACodeInvalid     = "MFA_CODE_INVALID"
+	CodeMFAAlreadyEnabled  = "MFA_ALREADY_ENABLED"
+	CodeMFANotEnrolled     = "MFA_NOT_ENROLLED"
+	CodeInternal           = "INTERNAL_ERROR"
+)
+
+// Ошибки уровня API, для которых нет соответствия в erorrs
+var (
+	errInvalidInput       = errors.New("invalid input")
+	errMissingCredentials = errors.New("username and password are required")
+	errInvalidCredentials = errors.New("invalid credentials")
+	errUnauthorized       = errors.New("unauthorized")
+	errTokenInvalid       = errors.New("invalid token")
+	errInsufficientScope  = errors.New("insufficient scope")
+)
+
+// apiError - ответ на ошибку: HTTP-статус, код и ключ сообщения для пользователя
+type apiError struct {
+	status  int
+	code    string
+	message i18n.Key
+}
+
+var internalError = apiError{http.StatusInternalServerError, CodeInternal, i18n.MsgInternal}
+
+// errorCatalog сопоставляет ошибки с ответами. Проверяется по порядку через errors.Is
+var errorCatalog = []struct {
+	err      error
+	response apiError
+}{
+	{errInvalidInput, apiError{http.StatusBadRequest, CodeInvalidInput, i18n.MsgInvalidInput}},
+	{errMissingCredentials, apiError{http.StatusBadRequest, CodeInvalidInput, i18n.MsgMissingCredentials}},
+	{errInvalidCredentials, apiError{http.StatusUnauthorized, CodeInvalidCredentials, i18n.MsgInvalidCredentials}},
+	{errUnauthorized, apiError{http.StatusUnauthorized, CodeUnauthorized, i18n.MsgUnauthorized}},
+	{errTokenInvalid, apiError{http.StatusUnauthorized, CodeTokenInvalid, i18n.MsgTokenInvalid}},
+	{erorrs.ErrForbidden, apiError{http.StatusForbidden, CodeForbidden, i18n.MsgForbidden}},
+	{errInsufficientScope, apiError{http.StatusForbidden, CodeInsufficientScope, i18n.MsgInsufficientScope}},
+	{erorrs.ErrReservedName, apiError{http.StatusBadRequest, CodeInvalidInput, i18n.MsgReservedName}},
+	{erorrs.ErrNotFound, apiError{http.StatusNotFound, CodeUserNotFound, i18n.MsgUserNotFound}},
+	{erorrs.ErrItemNotFound, apiError{http.StatusNotFound, CodeItemNotFound, i18n.MsgItemNotFound}},
+	{erorrs.ErrGroupNotFound, apiError{http.StatusNotFound, CodeGroupNotFound, i18n.MsgGroupNotFound}},
+	{erorrs.ErrNotGroupMember, apiError{http.StatusNotFound, CodeNotGroupMember, i18n.MsgNotGroupMember}},
+	{erorrs.ErrCurrencyNotFound, apiError{http.StatusBadRequest, CodeCurrencyNotFound, i18n.MsgCurrencyNotFound}},
+	{erorrs.ErrInsufficientFunds, apiError{http.StatusBadRequest, CodeInsufficientFunds, i18n.MsgInsufficientFunds}},
+	{erorrs.ErrSelfTransfer, apiError{http.StatusBadRequest, CodeSelfTransfer, i18n.MsgSelfTransfer}},
+	{erorrs.ErrInvalidTarget, apiError{http.StatusBadRequest, CodeInvalidTarget, i18n.MsgInvalidTarget}},
+	{erorrs.ErrWebhookNotFound, apiError{http.StatusNotFound, CodeWebhookNotFound, i18n.MsgWebhookNotFound}},
+	{erorrs.ErrDeliveryNotFound, apiError{http.StatusNotFound, CodeDeliveryNotFound, i18n.MsgDeliveryNotFound}},
+	{erorrs.ErrUnknownEventType, apiError{http.StatusBadRequest, CodeUnknownEventType, i18n.MsgUnknownEventType}},
+	{erorrs.ErrTooManyAttempts, apiError{http.StatusTooManyRequests, CodeTooManyAttempts, i18n.MsgTooManyAttempts}},
+	{erorrs.ErrRateLimited, apiError{http.StatusTooManyRequests, CodeRateLimited, i18n.MsgRateLimited}},
+	{erorrs.ErrAPIKeyInvalid, apiError{http.StatusUnauthorized, CodeAPIKeyInvalid, i18n.MsgAPIKeyInvalid}},
+	{erorrs.ErrAPIKeyNotFound, apiError{http.StatusNotFound, CodeAPIKeyNotFound, i18n.MsgAPIKeyNotFound}},
+	{erorrs.ErrUnknownScope, apiError{http.StatusBadRequest, CodeUnknownScope, i18n.MsgUnknownScope}},
+	{erorrs.ErrWeakPassword, apiError{http.StatusBadRequest, CodeWeakPassword, i18n.MsgWeakPassword}},
+	{erorrs.ErrWrongPassword, apiError{http.StatusBadRequest, CodeWrongPassword, i18n.MsgWrongPassword}},
+	{erorrs.ErrResetTokenInvalid, apiError{http.StatusBadRequest, CodeResetTokenInvalid, i18n.MsgResetTokenInvalid}},
+	{erorrs.ErrSessionRevoked, apiError{http.StatusUnauthorized, CodeSessionRevoked, i18n.MsgSessionRevoked}},
+	{erorrs.ErrMFARequired, apiError{http.StatusUnauthorized, CodeMFARequired, i18n.MsgMFARequired}},
+	{erorrs.ErrMFAChallengeInvalid, apiError{http.StatusUnauthorized, CodeMFAChallenge, i18n.MsgMFAChallenge}},
+	{erorrs.ErrMFACodeInvalid, apiError{http.StatusBadRequest, CodeMFACodeInvalid, i18n.MsgMFACodeInvalid}},
+	{erorrs.ErrMFAAlreadyEnabled, apiError{http.StatusBadRequest, CodeMFAAlreadyEnabled, i18n.MsgMFAAlreadyEnabled}},
+	{erorrs.ErrMFANotEnrolled, apiError{http.StatusBadRequest, CodeMFANotEnrolled, i18n.MsgMFANotEnrolled}},
+}
+
+func lookupError(err error) apiError {
+	for _, entry := range errorCatalog {
+		if errors.Is(err, entry.err) {
+			return entry.response
+		}
+	}
+
+	return internalError
+}
+
+// retryAfterError - отказ, после которого клиенту сообщается, когда повторить запрос
+type retryAfterError interface {
+	RetryAfterSeconds() int
+}
+
+// mfaChallengeError - пароль верный, вход нужно подтвердить вторым фактором по выданному токену
+type mfaChallengeError interface {
+	MFAChallenge() (string, time.Time)
+}
+
+// respondError - единственный способ ответить клиенту ошибкой. Неизвестные ошибки
+// превращаются в 500 и пишутся в лог, их текст клиенту не отдается
+func (r *Api) respondError(c *gin.Context, err error) {
+	response := lookupError(err)
+	if response.status == http.StatusInternalServerError {
+		r.log(c).Error("request failed", zap.Error(err))
+	}
+
+	var retryErr retryAfterError
+	if errors.As(err, &retryErr) {
+		c.Header("Retry-After", strconv.Itoa(retryErr.RetryAfterSeconds()))
+	}
+
+	var challengeErr mfaChallengeError
+	if errors.As(err, &challengeErr) {
+		token, expiresAt := challengeErr.MFAChallenge()
+		c.AbortWithStatusJSON(response.status, model.MFAChallengeResponseDTO{
+			Error:        r.translate(c, response.message),
+			Code:         response.code,
+			MFAToken:     token,
+			MFAExpiresAt: expiresAt,
+		})
+		return
+	}
+
+	c.AbortWithStatusJSON(response.status, model.ErrorResponseDTO{
+		Error: r.translate(c, response.message),
+		Code:  response.code,
+	})
+}
+
+// respondMessage отвечает успешным статусом с сообщением на языке клиента
+func (r *Api) respondMessage(c *gin.Context, key i18n.Key) {
+	c.JSON(http.StatusOK, r.translate(c, key))
+}
+
+// translate переводит сообщение на язык из Accept-Language и указывает выбранный язык в ответе
+func (r *Api) translate(c *gin.Context, key i18n.Key) string {
+	lang := r.i18n.Language(c.GetHeader("Accept-Language"))
+	c.Header("Content-Language", lang.String())
+
+	return r.i18n.Translate(lang, key)
+}
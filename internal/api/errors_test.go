@@ -0,0 +1,252 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"avito-shop/internal/api/mocks"
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	"avito-shop/internal/i18n"
+	mocks2 "avito-shop/internal/logger/mocks"
+	"avito-shop/internal/model"
+	"avito-shop/internal/ratelimit"
+	"avito-shop/internal/service"
+	servicemocks "avito-shop/internal/service/mocks"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLookupError(t *testing.T) {
+	tests := []struct {
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{err: erorrs.ErrInsufficientFunds, expectedStatus: http.StatusBadRequest, expectedCode: CodeInsufficientFunds},
+		{err: fmt.Errorf("send coins: %w", erorrs.ErrNotFound), expectedStatus: http.StatusNotFound, expectedCode: CodeUserNotFound},
+		{err: erorrs.ErrItemNotFound, expectedStatus: http.StatusNotFound, expectedCode: CodeItemNotFound},
+		{err: errTokenInvalid, expectedStatus: http.StatusUnauthorized, expectedCode: CodeTokenInvalid},
+		{err: erorrs.ErrForbidden, expectedStatus: http.StatusForbidden, expectedCode: CodeForbidden},
+		{err: &ratelimit.RetryAfterError{RetryAfter: time.Second}, expectedStatus: http.StatusTooManyRequests, expectedCode: CodeTooManyAttempts},
+		{err: &ratelimit.RateLimitError{RetryAfter: time.Second}, expectedStatus: http.StatusTooManyRequests, expectedCode: CodeRateLimited},
+		{err: erorrs.ErrSessionRevoked, expectedStatus: http.StatusUnauthorized, expectedCode: CodeSessionRevoked},
+		{err: erorrs.ErrWeakPassword, expectedStatus: http.StatusBadRequest, expectedCode: CodeWeakPassword},
+		{err: &service.MFARequiredError{Token: "challenge"}, expectedStatus: http.StatusUnauthorized, expectedCode: CodeMFARequired},
+		{err: erorrs.ErrMFACodeInvalid, expectedStatus: http.StatusBadRequest, expectedCode: CodeMFACodeInvalid},
+		{err: errors.New("connection reset"), expectedStatus: http.StatusInternalServerError, expectedCode: CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expectedCode, func(t *testing.T) {
+			response := lookupError(tt.err)
+
+			assert.Equal(t, tt.expectedStatus, response.status)
+			assert.Equal(t, tt.expectedCode, response.code)
+			assert.NotEmpty(t, response.message)
+		})
+	}
+}
+
+func TestUserIdentity_MalformedToken(t *testing.T) {
+	mockLogger := mocks2.NewLogger(t)
+	mockLogger.On("Info", mock.Anything, mock.Anything).Maybe()
+	mockLogger.On("Info", mock.Anything).Maybe()
+
+	api := NewApi(mockLogger, nil, nil, nil, nil, nil, nil, nil, newTestLocalizer(t), nil, nil)
+	router := gin.New()
+	router.GET("/api/info", api.UserIdentity, api.GetUserInfo)
+
+	for _, header := range []string{"Bearer", "Token abc", "Bearer not.a.jwt"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/info", nil)
+		req.Header.Set(authHeader, header)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code, header)
+		assert.Equal(t, CodeTokenInvalid, decodeError(t, w).Code, header)
+	}
+}
+
+func TestAuth_LockedOut(t *testing.T) {
+	mockLogger := mocks2.NewLogger(t)
+	mockAuth := mocks.NewServiceAuthInterface(t)
+	mockAuth.On("Authorization", mock.Anything, model.AuthRequestDTO{Username: "victim", Password: "guess"}).
+		Return("", &ratelimit.RetryAfterError{RetryAfter: 90*time.Second + time.Millisecond})
+
+	api := NewApi(mockLogger, mockAuth, nil, nil, nil, nil, nil, nil, newTestLocalizer(t), nil, nil)
+	router := gin.New()
+	router.POST("/api/auth", api.Auth)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/auth", strings.NewReader(`{"username":"victim","password":"guess"}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "91", w.Header().Get("Retry-After"))
+	assert.Equal(t, CodeTooManyAttempts, decodeError(t, w).Code)
+}
+
+func TestAuth_SpoofedForwardedFor(t *testing.T) {
+	// каждая попытка приходит с другим X-Forwarded-For, но с одного адреса
+	attempt := func(router http.Handler, i int) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"username":"victim%d","password":"guess"}`, i)
+		req := httptest.NewRequest(http.MethodPost, "/api/auth", strings.NewReader(body))
+		req.RemoteAddr = "192.0.2.1:40000"
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d", i))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	newRouter := func(t *testing.T, trustedProxies []string) http.Handler {
+		repo := servicemocks.NewRepoAuthInterface(t)
+		repo.On("GetUser", mock.Anything, mock.Anything, mock.Anything).Return(domain.User{}, nil)
+		repo.On("CreateUser", mock.Anything, mock.Anything, mock.Anything).Return(0, erorrs.ErrUserExist)
+		recorder := servicemocks.NewAuditRecorderInterface(t)
+		recorder.On("Record", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+		guard := ratelimit.NewGuard(ratelimit.NewMemoryStore(), ratelimit.LockoutPolicy{},
+			ratelimit.LockoutPolicy{MaxFailures: 2, Window: time.Minute, BaseLockout: time.Minute, MaxLockout: time.Minute})
+		auth := service.NewAuthService(repo, recorder, guard, nopLogger{}, 0, domain.ExpiryPolicy{}, domain.PasswordPolicy{})
+
+		return NewApi(nopLogger{}, auth, nil, nil, nil, nil, nil, nil, newTestLocalizer(t), nil, nil).InitRoutes(trustedProxies)
+	}
+
+	t.Run("untrusted proxy", func(t *testing.T) {
+		router := newRouter(t, nil)
+
+		assert.Equal(t, http.StatusUnauthorized, attempt(router, 1).Code)
+		assert.Equal(t, http.StatusUnauthorized, attempt(router, 2).Code)
+		assert.Equal(t, http.StatusTooManyRequests, attempt(router, 3).Code)
+	})
+
+	t.Run("trusted proxy", func(t *testing.T) {
+		router := newRouter(t, []string{"192.0.2.1"})
+
+		for i := 1; i <= 3; i++ {
+			assert.Equal(t, http.StatusUnauthorized, attempt(router, i).Code)
+		}
+	})
+}
+
+func TestAuth_MFARequired(t *testing.T) {
+	mockLogger := mocks2.NewLogger(t)
+	mockAuth := mocks.NewServiceAuthInterface(t)
+	expiresAt := time.Date(2027, 1, 1, 0, 5, 0, 0, time.UTC)
+	mockAuth.On("Authorization", mock.Anything, model.AuthRequestDTO{Username: "alice", Password: "pass"}).
+		Return("", &service.MFARequiredError{Token: "challenge", ExpiresAt: expiresAt})
+
+	api := NewApi(mockLogger, mockAuth, nil, nil, nil, nil, nil, nil, newTestLocalizer(t), nil, nil)
+	router := gin.New()
+	router.POST("/api/auth", api.Auth)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/auth", strings.NewReader(`{"username":"alice","password":"pass"}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Header().Get("Authorization"))
+
+	var body model.MFAChallengeResponseDTO
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, CodeMFARequired, body.Code)
+	assert.Equal(t, "challenge", body.MFAToken)
+	assert.True(t, expiresAt.Equal(body.MFAExpiresAt))
+}
+
+func TestSendCoin_UnknownRecipient(t *testing.T) {
+	mockLogger := mocks2.NewLogger(t)
+	mockUser := mocks.NewServiceUserInterface(t)
+	mockUser.On("SendCoinToUser", mock.Anything, 1, "ghost", domain.NewMoney(10), "").Return(erorrs.ErrNotFound)
+
+	token, err := service.NewAuthService(nil, nil, nil, mockLogger, 0, domain.ExpiryPolicy{}, domain.PasswordPolicy{}).GenerateJwtToken(1, 0, domain.Scopes)
+	require.NoError(t, err)
+
+	mockLogger.On("With", mock.Anything).Return(mockLogger)
+
+	api := NewApi(mockLogger, newSessionChecker(t), mockUser, nil, nil, nil, nil, nil, newTestLocalizer(t), nil, nil)
+	router := gin.New()
+	router.POST("/api/sendCoin", api.UserIdentity, api.SendCoin)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/sendCoin", strings.NewReader(`{"toUser":"ghost","amount":10}`))
+	req.Header.Set(authHeader, "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	body := decodeError(t, w)
+	assert.Equal(t, CodeUserNotFound, body.Code)
+	assert.Equal(t, "пользователь не найден", body.Error)
+}
+
+func TestSendCoin_LocalizedMessages(t *testing.T) {
+	mockLogger := mocks2.NewLogger(t)
+	mockLogger.On("With", mock.Anything).Return(mockLogger)
+	mockUser := mocks.NewServiceUserInterface(t)
+	mockUser.On("SendCoinToUser", mock.Anything, 1, "poor", domain.NewMoney(10), "").Return(erorrs.ErrInsufficientFunds)
+	mockUser.On("SendCoinToUser", mock.Anything, 1, "friend", domain.NewMoney(10), "").Return(nil)
+
+	token, err := service.NewAuthService(nil, nil, nil, mockLogger, 0, domain.ExpiryPolicy{}, domain.PasswordPolicy{}).GenerateJwtToken(1, 0, domain.Scopes)
+	require.NoError(t, err)
+
+	api := NewApi(mockLogger, newSessionChecker(t), mockUser, nil, nil, nil, nil, nil, newTestLocalizer(t), nil, nil)
+	router := gin.New()
+	router.POST("/api/sendCoin", api.UserIdentity, api.SendCoin)
+
+	tests := []struct {
+		name             string
+		toUser           string
+		acceptLanguage   string
+		expectedStatus   int
+		expectedBody     string
+		expectedLanguage string
+	}{
+		{name: "error in english", toUser: "poor", acceptLanguage: "en-US,en;q=0.9", expectedStatus: http.StatusBadRequest,
+			expectedBody: `{"error":"insufficient funds","code":"INSUFFICIENT_FUNDS"}`, expectedLanguage: "en"},
+		{name: "error in default language", toUser: "poor", expectedStatus: http.StatusBadRequest,
+			expectedBody: `{"error":"недостаточно средств","code":"INSUFFICIENT_FUNDS"}`, expectedLanguage: "ru"},
+		{name: "success in english", toUser: "friend", acceptLanguage: "en", expectedStatus: http.StatusOK,
+			expectedBody: `"coins sent successfully"`, expectedLanguage: "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/sendCoin", strings.NewReader(`{"toUser":"`+tt.toUser+`","amount":10}`))
+			req.Header.Set(authHeader, "Bearer "+token)
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			assert.Equal(t, tt.expectedLanguage, w.Header().Get("Content-Language"))
+		})
+	}
+}
+
+func newTestLocalizer(t *testing.T) *i18n.Localizer {
+	t.Helper()
+
+	localizer, err := i18n.NewLocalizer("ru")
+	require.NoError(t, err)
+	return localizer
+}
+
+func decodeError(t *testing.T, w *httptest.ResponseRecorder) model.ErrorResponseDTO {
+	t.Helper()
+
+	var body model.ErrorResponseDTO
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return body
+}
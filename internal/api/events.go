@@ -0,0 +1,63 @@
+package api
+
+import (
+	"avito-shop/internal/events"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// eventsKeepAlive - интервал пинга открытого потока, чтобы прокси не закрывали простаивающее соединение
+const eventsKeepAlive = 15 * time.Second
+
+type EventSubscriberInterface interface {
+	Subscribe(userID int) *events.Subscription
+}
+
+// Events держит открытым поток Server-Sent Events и передает в него события пользователя
+func (r *Api) Events(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		r.log(c).Error("unidentified user")
+		r.respondError(c, errUnauthorized)
+		return
+	}
+
+	// Поток живет дольше WriteTimeout сервера, поэтому дедлайн записи для него снимается
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		r.log(c).Error("Failed to reset write deadline", zap.Error(err))
+	}
+
+	sub := r.events.Subscribe(userID)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(eventsKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			c.Render(-1, sse.Event{Id: strconv.FormatInt(event.ID, 10), Event: event.Type, Data: event.Data})
+		case <-ticker.C:
+			if _, err := c.Writer.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
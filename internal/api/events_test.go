@@ -0,0 +1,70 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"avito-shop/internal/events"
+	"bufio"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEvents_StreamsUserEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	bus := events.NewBus(4, nil)
+	api := &Api{logger: nopLogger{}, events: bus}
+
+	router := gin.New()
+	router.GET("/api/events", func(c *gin.Context) {
+		c.Set(userCtx, 1)
+	}, api.Events)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/events", nil)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// событие другого пользователя в поток попасть не должно
+	require.NoError(t, bus.Publish(ctx, events.Event{Type: events.TypeBalanceChanged, UserID: 2, Data: []byte(`{}`)}))
+	require.NoError(t, bus.Publish(ctx, events.Event{ID: 15, Type: events.TypeCoinsReceived, UserID: 1, Data: []byte(`{"fromUser":"user2"}`)}))
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "id:15\n", line)
+
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event:coins.received\n", line)
+
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "data:{\"fromUser\":\"user2\"}\n", line)
+
+	// закрытие шины завершает поток
+	bus.Close()
+	_, err = reader.ReadString('\n')
+	for err == nil {
+		_, err = reader.ReadString('\n')
+	}
+	assert.ErrorIs(t, err, io.EOF)
+}
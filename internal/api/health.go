@@ -0,0 +1,29 @@
+package api
+
+import (
+	"avito-shop/internal/health"
+	"context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type HealthCheckerInterface interface {
+	Check(ctx context.Context) health.Report
+}
+
+// Liveness сообщает только о том, что процесс жив и обрабатывает запросы
+func (r *Api) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, health.Report{Status: health.StatusOK, Checks: []health.CheckResult{}})
+}
+
+// Readiness выполняет проверки зависимостей и возвращает 503, если хотя бы одна из них не прошла
+func (r *Api) Readiness(c *gin.Context) {
+	report := r.health.Check(c.Request.Context())
+
+	status := http.StatusOK
+	if report.Status != health.StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, report)
+}
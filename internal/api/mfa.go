@@ -0,0 +1,87 @@
+package api
+
+import (
+	"avito-shop/internal/model"
+	"context"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// EnrollMFA выдает секрет второго фактора текущему пользователю. Второй фактор включается
+// только после подтверждения кодом в ConfirmMFA
+func (r *Api) EnrollMFA(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		r.log(c).Error("unidentified user")
+		r.respondError(c, errUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	enrollment, err := r.auth.EnrollMFA(ctx, userId)
+	if err != nil {
+		r.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmMFA включает второй фактор по коду из приложения и возвращает коды восстановления
+func (r *Api) ConfirmMFA(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		r.log(c).Error("unidentified user")
+		r.respondError(c, errUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	var input model.MFAConfirmRequestDTO
+
+	err = c.ShouldBindJSON(&input)
+	if err != nil {
+		r.log(c).Error("error bind json", zap.Error(err))
+		r.respondError(c, errInvalidInput)
+		return
+	}
+
+	codes, err := r.auth.ConfirmMFA(ctx, userId, input)
+	if err != nil {
+		r.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// VerifyMFA - второй шаг входа: токен из ответа /api/auth и код обмениваются на токен доступа
+func (r *Api) VerifyMFA(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	var input model.MFAVerifyRequestDTO
+
+	err := c.ShouldBindJSON(&input)
+	if err != nil {
+		r.log(c).Error("error bind json", zap.Error(err))
+		r.respondError(c, errInvalidInput)
+		return
+	}
+
+	token, err := r.auth.VerifyMFA(ctx, input)
+	if err != nil {
+		r.respondError(c, err)
+		return
+	}
+
+	c.Header("Authorization", "Bearer "+token)
+
+	c.JSON(http.StatusOK, token)
+}
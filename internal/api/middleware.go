@@ -1,54 +1,315 @@
 package api
 
 import (
+	"avito-shop/internal/audit"
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	"avito-shop/internal/logger"
+	"avito-shop/internal/metrics"
 	"avito-shop/internal/model"
+	"avito-shop/internal/ratelimit"
 	"avito-shop/internal/service"
 	"errors"
 	"net/http"
+	"runtime/debug"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
 const (
-	authHeader = "Authorization"
-	userCtx    = "user_id"
+	authHeader            = "Authorization"
+	wwwAuthenticateHeader = "WWW-Authenticate"
+	apiKeyHeader          = "X-API-Key"
+	requestIDHeader       = "X-Request-ID"
+	userCtx               = "user_id"
+	scopesCtx             = "scopes"
+
+	rateLimitLimitHeader     = "X-RateLimit-Limit"
+	rateLimitRemainingHeader = "X-RateLimit-Remaining"
+	rateLimitResetHeader     = "X-RateLimit-Reset"
 )
 
+// maxRequestIDLength ограничивает длину входящего X-Request-ID, чтобы клиент не мог раздуть логи
+const maxRequestIDLength = 128
+
+// RequestID принимает X-Request-ID клиента или генерирует новый, возвращает его в ответе
+// и кладет в контекст запроса логгер с этим идентификатором и сведения о запросе для журнала аудита
+func (r *Api) RequestID(c *gin.Context) {
+	requestID := c.GetHeader(requestIDHeader)
+	if !validRequestID(requestID) {
+		requestID = uuid.NewString()
+	}
+
+	c.Header(requestIDHeader, requestID)
+	c.Request = c.Request.WithContext(audit.WithRequestInfo(c.Request.Context(), audit.RequestInfo{
+		IP:        r.clientIP(c),
+		UserAgent: c.Request.UserAgent(),
+		RequestID: requestID,
+	}))
+	r.setRequestLogger(c, r.logger.With(zap.String("requestID", requestID)))
+}
+
+// clientIP - адрес клиента для блокировки входа и журнала аудита. Адрес из X-Forwarded-For берется,
+// только если заданы доверенные прокси и запрос пришел от одного из них, иначе - адрес соединения
+func (r *Api) clientIP(c *gin.Context) string {
+	if len(r.trustedProxies) == 0 {
+		return c.RemoteIP()
+	}
+
+	return c.ClientIP()
+}
+
+// AccessLog пишет одну запись на каждый обработанный запрос
+func (r *Api) AccessLog(c *gin.Context) {
+	start := time.Now()
+	requestLogger := r.log(c)
+
+	c.Next()
+
+	fields := []zap.Field{
+		zap.String("method", c.Request.Method),
+		zap.String("path", c.Request.URL.Path),
+		zap.String("route", c.FullPath()),
+		zap.Int("status", c.Writer.Status()),
+		zap.Duration("latency", time.Since(start)),
+		zap.String("clientIP", r.clientIP(c)),
+		zap.Int("size", c.Writer.Size()),
+	}
+
+	if userID, err := getUserId(c); err == nil {
+		fields = append(fields, zap.Int("userID", userID))
+	}
+
+	if errs := c.Errors.ByType(gin.ErrorTypePrivate); len(errs) > 0 {
+		fields = append(fields, zap.String("errors", errs.String()))
+	}
+
+	requestLogger.Info("request completed", fields...)
+}
+
+// Recovery перехватывает панику в обработчике, пишет ее в лог со стектрейсом
+// и отвечает стандартной ошибкой 500 вместо обрыва соединения
+func (r *Api) Recovery(c *gin.Context) {
+	defer func() {
+		rec := recover()
+		if rec == nil {
+			return
+		}
+
+		// http.ErrAbortHandler - штатный способ прервать ответ, его нужно пробросить дальше
+		if rec == http.ErrAbortHandler {
+			panic(rec)
+		}
+
+		r.log(c).Error("panic recovered", zap.Any("panic", rec), zap.ByteString("stack", debug.Stack()))
+		metrics.PanicRecovered(c.FullPath())
+
+		if c.Writer.Written() {
+			c.Abort()
+			return
+		}
+		c.AbortWithStatusJSON(internalError.status, model.ErrorResponseDTO{
+			Error: r.translate(c, internalError.message),
+			Code:  internalError.code,
+		})
+	}()
+
+	c.Next()
+}
+
+// UserIdentity определяет пользователя по JWT из Authorization или по ключу API из X-API-Key.
+// Отсутствующие и недействительные учетные данные, а также JWT, выпущенный до смены пароля, - 401,
+// нехватку прав проверяет RequireScope (403)
 func (r *Api) UserIdentity(c *gin.Context) {
+	if key := c.GetHeader(apiKeyHeader); key != "" {
+		r.apiKeyIdentity(c, key)
+		return
+	}
+
 	header := c.GetHeader(authHeader)
 	if header == "" {
-		r.logger.Info("Empty header")
-		c.JSON(http.StatusUnauthorized, model.ErrorResponseDTO{Error: "вы не авторизованы"})
-		c.Abort()
+		r.log(c).Info("Empty header")
+		r.rejectCredentials(c, errUnauthorized)
 		return
 	}
 
 	headerParts := strings.Split(header, " ")
 	if len(headerParts) != 2 || headerParts[0] != "Bearer" {
-		r.logger.Info("Wrong header")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponseDTO{Error: "вы не авторизованы"})
-		c.Abort()
+		r.log(c).Info("Wrong header")
+		r.rejectCredentials(c, errTokenInvalid)
 		return
 	}
 
 	token := headerParts[1]
 	if token == "" {
-		r.logger.Info("Empty token")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponseDTO{Error: "вы не авторизованы"})
-		c.Abort()
+		r.log(c).Info("Empty token")
+		r.rejectCredentials(c, errTokenInvalid)
+		return
+	}
+
+	parsed, err := service.ParseToken(token)
+	if err != nil {
+		r.log(c).Info("Failed to parse the token", zap.Error(err))
+		r.rejectCredentials(c, errTokenInvalid)
+		return
+	}
+
+	if err := r.auth.CheckSession(c.Request.Context(), parsed.UserID, parsed.Version); err != nil {
+		r.log(c).Info("Failed to check the session", zap.Error(err))
+		r.rejectCredentials(c, err)
 		return
 	}
 
-	userId, err := service.ParseToken(token)
+	c.Set(userCtx, parsed.UserID)
+	c.Set(scopesCtx, parsed.Scopes)
+	r.setRequestLogger(c, r.log(c).With(zap.Int("userID", parsed.UserID)))
+}
+
+// rejectCredentials отвечает 401 с заголовком WWW-Authenticate по RFC 6750
+func (r *Api) rejectCredentials(c *gin.Context, err error) {
+	challenge := "Bearer"
+	if errors.Is(err, errTokenInvalid) || errors.Is(err, erorrs.ErrSessionRevoked) {
+		challenge += ` error="invalid_token"`
+	}
+
+	c.Header(wwwAuthenticateHeader, challenge)
+	r.respondError(c, err)
+}
+
+// apiKeyIdentity определяет пользователя по ключу API и запоминает права ключа для RequireScope
+func (r *Api) apiKeyIdentity(c *gin.Context, plain string) {
+	key, err := r.apiKeys.Authenticate(c.Request.Context(), plain)
 	if err != nil {
-		r.logger.Error("Failed to parse the token")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponseDTO{Error: "вы не авторизованы"})
-		c.Abort()
+		r.log(c).Info("Failed to authenticate the api key", zap.Error(err))
+		r.respondError(c, err)
 		return
 	}
 
-	c.Set(userCtx, userId)
+	c.Set(userCtx, key.UserID)
+	c.Set(scopesCtx, key.Scopes)
+	r.setRequestLogger(c, r.log(c).With(zap.Int("userID", key.UserID), zap.Int("apiKeyID", key.ID)))
+}
+
+// RequireScope пропускает запрос, если токен или ключ API дают право scope. Должен стоять после UserIdentity
+func (r *Api) RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get(scopesCtx)
+		scopes, _ := value.([]string)
+		if !slices.Contains(scopes, scope) {
+			r.log(c).Info("Missing scope", zap.String("scope", scope))
+			c.Header(wwwAuthenticateHeader, `Bearer error="insufficient_scope", scope="`+scope+`"`)
+			r.respondError(c, errInsufficientScope)
+		}
+	}
+}
+
+type RateLimiterInterface interface {
+	Allow(userID int, route string) ratelimit.Decision
+}
+
+// RateLimit ограничивает частоту запросов пользователя к маршруту и сообщает остаток бюджета
+// в заголовках X-RateLimit-*. Должен стоять после UserIdentity
+func (r *Api) RateLimit(c *gin.Context) {
+	if r.limiter == nil {
+		return
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		r.log(c).Error("unidentified user")
+		r.respondError(c, errUnauthorized)
+		return
+	}
+
+	decision := r.limiter.Allow(userId, c.Request.Method+" "+c.FullPath())
+	if decision.Limit == 0 {
+		return
+	}
+
+	c.Header(rateLimitLimitHeader, strconv.Itoa(decision.Limit))
+	c.Header(rateLimitRemainingHeader, strconv.Itoa(decision.Remaining))
+	c.Header(rateLimitResetHeader, decision.ResetSeconds())
+
+	if !decision.Allowed {
+		r.log(c).Info("Rate limit exceeded", zap.String("route", c.FullPath()))
+		metrics.RateLimited(c.Request.Method, c.FullPath())
+		r.respondError(c, &ratelimit.RateLimitError{RetryAfter: decision.RetryAfter})
+	}
+}
+
+func (r *Api) AdminIdentity(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		r.log(c).Error("unidentified user")
+		r.respondError(c, errUnauthorized)
+		return
+	}
+
+	isAdmin, err := r.admin.IsAdmin(c.Request.Context(), userId)
+	if err != nil {
+		r.log(c).Error("Failed to check user role", zap.Error(err))
+		r.respondError(c, err)
+		return
+	}
+
+	if !isAdmin {
+		r.log(c).Info("Access denied", zap.Int("userID", userId))
+		r.respondError(c, erorrs.ErrForbidden)
+		return
+	}
+}
+
+// AuditorIdentity пропускает только пользователей с ролью auditor. Администраторам журнал
+// аудита недоступен: он в том числе фиксирует их собственные действия
+func (r *Api) AuditorIdentity(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		r.log(c).Error("unidentified user")
+		r.respondError(c, errUnauthorized)
+		return
+	}
+
+	isAuditor, err := r.admin.HasRole(c.Request.Context(), userId, domain.RoleAuditor)
+	if err != nil {
+		r.log(c).Error("Failed to check user role", zap.Error(err))
+		r.respondError(c, err)
+		return
+	}
+
+	if !isAuditor {
+		r.log(c).Info("Access denied", zap.Int("userID", userId))
+		r.respondError(c, erorrs.ErrForbidden)
+		return
+	}
+}
+
+func (r *Api) setRequestLogger(c *gin.Context, l logger.Logger) {
+	c.Request = c.Request.WithContext(logger.WithContext(c.Request.Context(), l))
+}
+
+// log возвращает логгер текущего запроса
+func (r *Api) log(c *gin.Context) logger.Logger {
+	return logger.FromContext(c.Request.Context(), r.logger)
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, ch := range id {
+		if ch < '!' || ch > '~' {
+			return false
+		}
+	}
+
+	return true
 }
 
 func getUserId(c *gin.Context) (int, error) {
@@ -0,0 +1,226 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	apimocks "avito-shop/internal/api/mocks"
+	"avito-shop/internal/audit"
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	"avito-shop/internal/logger"
+	"avito-shop/internal/logger/mocks"
+	"avito-shop/internal/model"
+	"avito-shop/internal/ratelimit"
+	"avito-shop/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		expectSame bool
+	}{
+		{name: "keeps client id", header: "req-123", expectSame: true},
+		{name: "generates id when missing", header: ""},
+		{name: "replaces invalid id", header: "bad id\n"},
+		{name: "replaces too long id", header: strings.Repeat("a", maxRequestIDLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseLogger := mocks.NewLogger(t)
+			requestLogger := mocks.NewLogger(t)
+			baseLogger.On("With", mock.Anything).Return(requestLogger)
+
+			var fromCtx logger.Logger
+			router := newTestRouter(&Api{logger: baseLogger}, func(c *gin.Context) {
+				fromCtx = logger.FromContext(c.Request.Context(), nil)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.header != "" {
+				req.Header.Set(requestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			requestID := w.Header().Get(requestIDHeader)
+			assert.NotEmpty(t, requestID)
+			assert.Equal(t, tt.expectSame, requestID == tt.header)
+			baseLogger.AssertCalled(t, "With", zap.String("requestID", requestID))
+			assert.Same(t, requestLogger, fromCtx)
+		})
+	}
+}
+
+func TestRequestID_ClientIP(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		expectedIP     string
+	}{
+		// заголовок от недоверенного адреса подделан, в журнал аудита попадает адрес соединения
+		{name: "no trusted proxies", expectedIP: "192.0.2.1"},
+		{name: "trusted proxy", trustedProxies: []string{"192.0.2.0/24"}, expectedIP: "198.51.100.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseLogger := mocks.NewLogger(t)
+			baseLogger.On("With", mock.Anything).Return(baseLogger)
+
+			var info audit.RequestInfo
+			router := newTestRouter(&Api{logger: baseLogger, trustedProxies: tt.trustedProxies}, func(c *gin.Context) {
+				info = audit.RequestInfoFromContext(c.Request.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.RemoteAddr = "192.0.2.1:40000"
+			req.Header.Set("X-Forwarded-For", "198.51.100.7")
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.expectedIP, info.IP)
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	baseLogger := mocks.NewLogger(t)
+	requestLogger := mocks.NewLogger(t)
+	baseLogger.On("With", mock.Anything).Return(requestLogger)
+
+	var fields []zap.Field
+	requestLogger.On("Info", "request completed", mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			for _, arg := range args[1:] {
+				fields = append(fields, arg.(zap.Field))
+			}
+		}).Once()
+
+	api := &Api{logger: baseLogger}
+	router := gin.New()
+	router.Use(api.RequestID, api.AccessLog)
+	router.GET("/items/:item", func(c *gin.Context) {
+		c.Set(userCtx, 42)
+		c.Status(http.StatusTeapot)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/cup", nil))
+
+	assert.Contains(t, fields, zap.String("route", "/items/:item"))
+	assert.Contains(t, fields, zap.Int("status", http.StatusTeapot))
+	assert.Contains(t, fields, zap.Int("userID", 42))
+}
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := ratelimit.NewRouteLimiter(ratelimit.Budget{}, map[string]ratelimit.Budget{
+		"POST /buy/:item": {Rate: 0.5, Burst: 2},
+	})
+	api := &Api{logger: nopLogger{}, i18n: newTestLocalizer(t), limiter: limiter}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set(userCtx, 42) }, api.RateLimit)
+	router.POST("/buy/:item", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/info", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	buy := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/buy/cup", nil))
+		return w
+	}
+
+	w := buy()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get(rateLimitLimitHeader))
+	assert.Equal(t, "1", w.Header().Get(rateLimitRemainingHeader))
+	assert.Equal(t, "2", w.Header().Get(rateLimitResetHeader))
+
+	assert.Equal(t, http.StatusOK, buy().Code)
+
+	w = buy()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get(rateLimitRemainingHeader))
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), CodeRateLimited)
+
+	// маршрут без бюджета не ограничивается и не получает заголовков
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/info", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(rateLimitLimitHeader))
+}
+
+func TestUserIdentity_TokenScopes(t *testing.T) {
+	readOnly, err := service.NewAuthService(nil, nil, nil, nopLogger{}, 0, domain.ExpiryPolicy{}, domain.PasswordPolicy{}).GenerateJwtToken(1, 0, []string{domain.ScopeInfoRead})
+	require.NoError(t, err)
+	// токен, выпущенный до смены пароля
+	stale, err := service.NewAuthService(nil, nil, nil, nopLogger{}, 0, domain.ExpiryPolicy{}, domain.PasswordPolicy{}).GenerateJwtToken(1, 1, domain.Scopes)
+	require.NoError(t, err)
+
+	mockAuth := apimocks.NewServiceAuthInterface(t)
+	mockAuth.On("CheckSession", mock.Anything, 1, 0).Return(nil).Maybe()
+	mockAuth.On("CheckSession", mock.Anything, 1, 1).Return(erorrs.ErrSessionRevoked).Maybe()
+	mockUser := apimocks.NewServiceUserInterface(t)
+	mockUser.On("GetUserInfo", mock.Anything, 1).Return(model.InfoResponseDTO{}, nil).Maybe()
+	router := NewApi(nopLogger{}, mockAuth, mockUser, nil, nil, nil, nil, nil, newTestLocalizer(t), nil, nil).InitRoutes(nil)
+
+	tests := []struct {
+		name              string
+		method            string
+		path              string
+		authorization     string
+		expectedStatus    int
+		expectedChallenge string
+	}{
+		{name: "scope granted", method: http.MethodGet, path: "/api/info", authorization: "Bearer " + readOnly, expectedStatus: http.StatusOK},
+		{name: "scope missing", method: http.MethodPost, path: "/api/buy/cup", authorization: "Bearer " + readOnly,
+			expectedStatus: http.StatusForbidden, expectedChallenge: `Bearer error="insufficient_scope", scope="shop:buy"`},
+		{name: "no token", method: http.MethodPost, path: "/api/buy/cup", expectedStatus: http.StatusUnauthorized, expectedChallenge: "Bearer"},
+		{name: "invalid token", method: http.MethodGet, path: "/api/info", authorization: "Bearer garbage",
+			expectedStatus: http.StatusUnauthorized, expectedChallenge: `Bearer error="invalid_token"`},
+		{name: "revoked session", method: http.MethodGet, path: "/api/info", authorization: "Bearer " + stale,
+			expectedStatus: http.StatusUnauthorized, expectedChallenge: `Bearer error="invalid_token"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set(authHeader, tt.authorization)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			assert.Equal(t, tt.expectedChallenge, w.Header().Get(wwwAuthenticateHeader))
+		})
+	}
+}
+
+// newSessionChecker - сервис авторизации, для которого все токены действительны
+func newSessionChecker(t *testing.T) *apimocks.ServiceAuthInterface {
+	mockAuth := apimocks.NewServiceAuthInterface(t)
+	mockAuth.On("CheckSession", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	return mockAuth
+}
+
+func newTestRouter(api *Api, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(api.RequestID)
+	router.GET("/test", handler)
+	return router
+}
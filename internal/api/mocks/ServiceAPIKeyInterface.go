@@ -0,0 +1,135 @@
+// Code generated by mockery v2.52.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	domain "avito-shop/internal/domain"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	model "avito-shop/internal/model"
+)
+
+// ServiceAPIKeyInterface is an autogenerated mock type for the ServiceAPIKeyInterface type
+type ServiceAPIKeyInterface struct {
+	mock.Mock
+}
+
+// Authenticate provides a mock function with given fields: ctx, plain
+func (_m *ServiceAPIKeyInterface) Authenticate(ctx context.Context, plain string) (domain.APIKey, error) {
+	ret := _m.Called(ctx, plain)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Authenticate")
+	}
+
+	var r0 domain.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (domain.APIKey, error)); ok {
+		return rf(ctx, plain)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) domain.APIKey); ok {
+		r0 = rf(ctx, plain)
+	} else {
+		r0 = ret.Get(0).(domain.APIKey)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, plain)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateKey provides a mock function with given fields: ctx, actorID, dto
+func (_m *ServiceAPIKeyInterface) CreateKey(ctx context.Context, actorID int, dto model.APIKeyRequestDTO) (model.APIKeyDTO, error) {
+	ret := _m.Called(ctx, actorID, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateKey")
+	}
+
+	var r0 model.APIKeyDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.APIKeyRequestDTO) (model.APIKeyDTO, error)); ok {
+		return rf(ctx, actorID, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.APIKeyRequestDTO) model.APIKeyDTO); ok {
+		r0 = rf(ctx, actorID, dto)
+	} else {
+		r0 = ret.Get(0).(model.APIKeyDTO)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, model.APIKeyRequestDTO) error); ok {
+		r1 = rf(ctx, actorID, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListKeys provides a mock function with given fields: ctx
+func (_m *ServiceAPIKeyInterface) ListKeys(ctx context.Context) ([]model.APIKeyDTO, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListKeys")
+	}
+
+	var r0 []model.APIKeyDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]model.APIKeyDTO, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []model.APIKeyDTO); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.APIKeyDTO)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RevokeKey provides a mock function with given fields: ctx, actorID, id
+func (_m *ServiceAPIKeyInterface) RevokeKey(ctx context.Context, actorID int, id int) error {
+	ret := _m.Called(ctx, actorID, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
+		r0 = rf(ctx, actorID, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewServiceAPIKeyInterface creates a new instance of ServiceAPIKeyInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewServiceAPIKeyInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ServiceAPIKeyInterface {
+	mock := &ServiceAPIKeyInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
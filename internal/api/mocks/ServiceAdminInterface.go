@@ -0,0 +1,177 @@
+// Code generated by mockery v2.52.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	model "avito-shop/internal/model"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ServiceAdminInterface is an autogenerated mock type for the ServiceAdminInterface type
+type ServiceAdminInterface struct {
+	mock.Mock
+}
+
+// AddGroupMember provides a mock function with given fields: ctx, actorID, group, username
+func (_m *ServiceAdminInterface) AddGroupMember(ctx context.Context, actorID int, group string, username string) error {
+	ret := _m.Called(ctx, actorID, group, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddGroupMember")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, string) error); ok {
+		r0 = rf(ctx, actorID, group, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// BurnCoins provides a mock function with given fields: ctx, actorID, dto
+func (_m *ServiceAdminInterface) BurnCoins(ctx context.Context, actorID int, dto model.CoinOperationRequestDTO) (int, error) {
+	ret := _m.Called(ctx, actorID, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BurnCoins")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.CoinOperationRequestDTO) (int, error)); ok {
+		return rf(ctx, actorID, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.CoinOperationRequestDTO) int); ok {
+		r0 = rf(ctx, actorID, dto)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, model.CoinOperationRequestDTO) error); ok {
+		r1 = rf(ctx, actorID, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HasRole provides a mock function with given fields: ctx, userID, role
+func (_m *ServiceAdminInterface) HasRole(ctx context.Context, userID int, role string) (bool, error) {
+	ret := _m.Called(ctx, userID, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HasRole")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) (bool, error)); ok {
+		return rf(ctx, userID, role)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) bool); ok {
+		r0 = rf(ctx, userID, role)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string) error); ok {
+		r1 = rf(ctx, userID, role)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsAdmin provides a mock function with given fields: ctx, userID
+func (_m *ServiceAdminInterface) IsAdmin(ctx context.Context, userID int) (bool, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsAdmin")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (bool, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) bool); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MintCoins provides a mock function with given fields: ctx, actorID, dto
+func (_m *ServiceAdminInterface) MintCoins(ctx context.Context, actorID int, dto model.CoinOperationRequestDTO) (int, error) {
+	ret := _m.Called(ctx, actorID, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MintCoins")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.CoinOperationRequestDTO) (int, error)); ok {
+		return rf(ctx, actorID, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.CoinOperationRequestDTO) int); ok {
+		r0 = rf(ctx, actorID, dto)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, model.CoinOperationRequestDTO) error); ok {
+		r1 = rf(ctx, actorID, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveGroupMember provides a mock function with given fields: ctx, actorID, group, username
+func (_m *ServiceAdminInterface) RemoveGroupMember(ctx context.Context, actorID int, group string, username string) error {
+	ret := _m.Called(ctx, actorID, group, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveGroupMember")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, string) error); ok {
+		r0 = rf(ctx, actorID, group, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewServiceAdminInterface creates a new instance of ServiceAdminInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewServiceAdminInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ServiceAdminInterface {
+	mock := &ServiceAdminInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
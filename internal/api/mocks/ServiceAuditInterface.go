@@ -0,0 +1,59 @@
+// Code generated by mockery v2.52.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	model "avito-shop/internal/model"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ServiceAuditInterface is an autogenerated mock type for the ServiceAuditInterface type
+type ServiceAuditInterface struct {
+	mock.Mock
+}
+
+// Query provides a mock function with given fields: ctx, dto
+func (_m *ServiceAuditInterface) Query(ctx context.Context, dto model.AuditQueryDTO) ([]model.AuditEntryDTO, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Query")
+	}
+
+	var r0 []model.AuditEntryDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.AuditQueryDTO) ([]model.AuditEntryDTO, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.AuditQueryDTO) []model.AuditEntryDTO); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.AuditEntryDTO)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.AuditQueryDTO) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewServiceAuditInterface creates a new instance of ServiceAuditInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewServiceAuditInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ServiceAuditInterface {
+	mock := &ServiceAuditInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -42,9 +42,111 @@ func (_m *ServiceAuthInterface) Authorization(ctx context.Context, dto model.Aut
 	return r0, r1
 }
 
-// GenerateJwtToken provides a mock function with given fields: userId
-func (_m *ServiceAuthInterface) GenerateJwtToken(userId int) (string, error) {
-	ret := _m.Called(userId)
+// ChangePassword provides a mock function with given fields: ctx, userID, scopes, dto
+func (_m *ServiceAuthInterface) ChangePassword(ctx context.Context, userID int, scopes []string, dto model.PasswordChangeRequestDTO) (string, error) {
+	ret := _m.Called(ctx, userID, scopes, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePassword")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, []string, model.PasswordChangeRequestDTO) (string, error)); ok {
+		return rf(ctx, userID, scopes, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, []string, model.PasswordChangeRequestDTO) string); ok {
+		r0 = rf(ctx, userID, scopes, dto)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, []string, model.PasswordChangeRequestDTO) error); ok {
+		r1 = rf(ctx, userID, scopes, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CheckSession provides a mock function with given fields: ctx, userID, version
+func (_m *ServiceAuthInterface) CheckSession(ctx context.Context, userID int, version int) error {
+	ret := _m.Called(ctx, userID, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckSession")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
+		r0 = rf(ctx, userID, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ConfirmMFA provides a mock function with given fields: ctx, userID, dto
+func (_m *ServiceAuthInterface) ConfirmMFA(ctx context.Context, userID int, dto model.MFAConfirmRequestDTO) (model.MFARecoveryCodesDTO, error) {
+	ret := _m.Called(ctx, userID, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConfirmMFA")
+	}
+
+	var r0 model.MFARecoveryCodesDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.MFAConfirmRequestDTO) (model.MFARecoveryCodesDTO, error)); ok {
+		return rf(ctx, userID, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.MFAConfirmRequestDTO) model.MFARecoveryCodesDTO); ok {
+		r0 = rf(ctx, userID, dto)
+	} else {
+		r0 = ret.Get(0).(model.MFARecoveryCodesDTO)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, model.MFAConfirmRequestDTO) error); ok {
+		r1 = rf(ctx, userID, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EnrollMFA provides a mock function with given fields: ctx, userID
+func (_m *ServiceAuthInterface) EnrollMFA(ctx context.Context, userID int) (model.MFAEnrollmentDTO, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnrollMFA")
+	}
+
+	var r0 model.MFAEnrollmentDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (model.MFAEnrollmentDTO, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) model.MFAEnrollmentDTO); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(model.MFAEnrollmentDTO)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GenerateJwtToken provides a mock function with given fields: userId, version, scopes
+func (_m *ServiceAuthInterface) GenerateJwtToken(userId int, version int, scopes []string) (string, error) {
+	ret := _m.Called(userId, version, scopes)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GenerateJwtToken")
@@ -52,17 +154,91 @@ func (_m *ServiceAuthInterface) GenerateJwtToken(userId int) (string, error) {
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int) (string, error)); ok {
-		return rf(userId)
+	if rf, ok := ret.Get(0).(func(int, int, []string) (string, error)); ok {
+		return rf(userId, version, scopes)
+	}
+	if rf, ok := ret.Get(0).(func(int, int, []string) string); ok {
+		r0 = rf(userId, version, scopes)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(int, int, []string) error); ok {
+		r1 = rf(userId, version, scopes)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IssueResetToken provides a mock function with given fields: ctx, actorID, username
+func (_m *ServiceAuthInterface) IssueResetToken(ctx context.Context, actorID int, username string) (model.PasswordResetTokenDTO, error) {
+	ret := _m.Called(ctx, actorID, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IssueResetToken")
+	}
+
+	var r0 model.PasswordResetTokenDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) (model.PasswordResetTokenDTO, error)); ok {
+		return rf(ctx, actorID, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) model.PasswordResetTokenDTO); ok {
+		r0 = rf(ctx, actorID, username)
+	} else {
+		r0 = ret.Get(0).(model.PasswordResetTokenDTO)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string) error); ok {
+		r1 = rf(ctx, actorID, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResetPassword provides a mock function with given fields: ctx, dto
+func (_m *ServiceAuthInterface) ResetPassword(ctx context.Context, dto model.PasswordResetRequestDTO) error {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetPassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.PasswordResetRequestDTO) error); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// VerifyMFA provides a mock function with given fields: ctx, dto
+func (_m *ServiceAuthInterface) VerifyMFA(ctx context.Context, dto model.MFAVerifyRequestDTO) (string, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyMFA")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.MFAVerifyRequestDTO) (string, error)); ok {
+		return rf(ctx, dto)
 	}
-	if rf, ok := ret.Get(0).(func(int) string); ok {
-		r0 = rf(userId)
+	if rf, ok := ret.Get(0).(func(context.Context, model.MFAVerifyRequestDTO) string); ok {
+		r0 = rf(ctx, dto)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(int) error); ok {
-		r1 = rf(userId)
+	if rf, ok := ret.Get(1).(func(context.Context, model.MFAVerifyRequestDTO) error); ok {
+		r1 = rf(ctx, dto)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"avito-shop/internal/i18n"
+	"avito-shop/internal/model"
+	"context"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// ChangePassword меняет пароль текущего пользователя и возвращает новый токен с теми же правами.
+// Прежние токены пользователя после этого отклоняются
+func (r *Api) ChangePassword(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		r.log(c).Error("unidentified user")
+		r.respondError(c, errUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	var input model.PasswordChangeRequestDTO
+
+	err = c.ShouldBindJSON(&input)
+	if err != nil {
+		r.log(c).Error("error bind json", zap.Error(err))
+		r.respondError(c, errInvalidInput)
+		return
+	}
+
+	value, _ := c.Get(scopesCtx)
+	scopes, _ := value.([]string)
+
+	token, err := r.auth.ChangePassword(ctx, userId, scopes, input)
+	if err != nil {
+		r.respondError(c, err)
+		return
+	}
+
+	c.Header("Authorization", "Bearer "+token)
+
+	c.JSON(http.StatusOK, token)
+}
+
+// IssuePasswordReset выпускает одноразовый токен сброса пароля пользователя
+func (r *Api) IssuePasswordReset(c *gin.Context) {
+	actorID, err := getUserId(c)
+	if err != nil {
+		r.log(c).Error("unidentified user")
+		r.respondError(c, errUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	token, err := r.auth.IssueResetToken(ctx, actorID, c.Param("username"))
+	if err != nil {
+		r.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
+
+// ResetPassword задает новый пароль по токену сброса. Маршрут публичный: токен сам подтверждает право
+func (r *Api) ResetPassword(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	var input model.PasswordResetRequestDTO
+
+	err := c.ShouldBindJSON(&input)
+	if err != nil {
+		r.log(c).Error("error bind json", zap.Error(err))
+		r.respondError(c, errInvalidInput)
+		return
+	}
+
+	err = r.auth.ResetPassword(ctx, input)
+	if err != nil {
+		r.respondError(c, err)
+		return
+	}
+
+	r.respondMessage(c, i18n.MsgPasswordReset)
+}
@@ -0,0 +1,88 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"avito-shop/internal/logger/mocks"
+	"avito-shop/internal/model"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecovery(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+	}{
+		{
+			name: "panic with value",
+			handler: func(c *gin.Context) {
+				panic("boom")
+			},
+		},
+		{
+			name: "nil pointer dereference",
+			handler: func(c *gin.Context) {
+				var dto *model.BuyItemRequestDTO
+				c.String(http.StatusOK, dto.Item)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLogger := mocks.NewLogger(t)
+			mockLogger.On("Error", "panic recovered", mock.Anything, mock.Anything).Once()
+
+			router := newRecoveryRouter(&Api{logger: mockLogger, i18n: newTestLocalizer(t)}, tt.handler)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+			assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+			var body model.ErrorResponseDTO
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, "внутренняя ошибка сервера", body.Error)
+		})
+	}
+}
+
+func TestRecovery_AfterResponseWritten(t *testing.T) {
+	mockLogger := mocks.NewLogger(t)
+	mockLogger.On("Error", "panic recovered", mock.Anything, mock.Anything).Once()
+
+	router := newRecoveryRouter(&Api{logger: mockLogger, i18n: newTestLocalizer(t)}, func(c *gin.Context) {
+		c.String(http.StatusOK, "partial")
+		panic("boom")
+	})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "partial", w.Body.String())
+}
+
+func TestRecovery_AbortHandler(t *testing.T) {
+	router := newRecoveryRouter(&Api{logger: mocks.NewLogger(t)}, func(c *gin.Context) {
+		panic(http.ErrAbortHandler)
+	})
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	})
+}
+
+func newRecoveryRouter(api *Api, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(api.Recovery)
+	router.GET("/panic", handler)
+	return router
+}
@@ -1,36 +1,109 @@
 package api
 
 import (
+	"avito-shop/internal/api/openapi"
+	"avito-shop/internal/domain"
+	"avito-shop/internal/i18n"
 	"avito-shop/internal/logger"
+	"avito-shop/internal/metrics"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.uber.org/zap"
+	"net/http"
 )
 
 type Api struct {
-	logger logger.Logger
-	auth   ServiceAuthInterface
-	user   ServiceUserInterface
+	logger   logger.Logger
+	auth     ServiceAuthInterface
+	user     ServiceUserInterface
+	admin    ServiceAdminInterface
+	webhooks ServiceWebhookInterface
+	audit    ServiceAuditInterface
+	apiKeys  ServiceAPIKeyInterface
+	health   HealthCheckerInterface
+	i18n     *i18n.Localizer
+	events   EventSubscriberInterface
+	limiter  RateLimiterInterface
+
+	trustedProxies []string
 }
 
-func NewApi(logger logger.Logger, auth ServiceAuthInterface, user ServiceUserInterface) *Api {
+func NewApi(logger logger.Logger, auth ServiceAuthInterface, user ServiceUserInterface, admin ServiceAdminInterface, webhooks ServiceWebhookInterface, audit ServiceAuditInterface, apiKeys ServiceAPIKeyInterface, health HealthCheckerInterface, localizer *i18n.Localizer, events EventSubscriberInterface, limiter RateLimiterInterface) *Api {
 	return &Api{
-		logger: logger,
-		auth:   auth,
-		user:   user,
+		logger:   logger,
+		auth:     auth,
+		user:     user,
+		admin:    admin,
+		webhooks: webhooks,
+		audit:    audit,
+		apiKeys:  apiKeys,
+		health:   health,
+		i18n:     localizer,
+		events:   events,
+		limiter:  limiter,
 	}
 }
 
-func (r *Api) InitRoutes() *gin.Engine {
+// InitRoutes собирает роутер. X-Forwarded-For учитывается только от trustedProxies, иначе IP клиента,
+// по которому работают блокировка входа и журнал аудита, подделывался бы заголовком
+func (r *Api) InitRoutes(trustedProxies []string) *gin.Engine {
 	router := gin.New()
+	r.trustedProxies = trustedProxies
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		r.logger.Error("invalid trusted proxies, forwarded headers are ignored", zap.Error(err))
+		r.trustedProxies = nil
+		_ = router.SetTrustedProxies(nil)
+	}
+	router.Use(r.RequestID, r.AccessLog)
+	router.Use(metrics.Middleware())
+	router.Use(otelgin.Middleware("avito-shop", otelgin.WithGinFilter(func(c *gin.Context) bool {
+		return c.FullPath() != "/metrics"
+	})))
+	router.Use(r.Recovery)
+
+	router.GET("/healthz", r.Liveness)
+	router.GET("/readyz", r.Readiness)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", openapi.Spec)
+	})
 
 	api := router.Group("/api")
 	{
 		api.POST("/auth", r.Auth)
+		api.POST("/auth/password-reset", r.ResetPassword)
+		api.POST("/auth/mfa", r.VerifyMFA)
 
-		protected := api.Group("", r.UserIdentity)
+		protected := api.Group("", r.UserIdentity, r.RateLimit)
 		{
-			protected.GET("/info", r.GetUserInfo)
-			protected.POST("/sendCoin", r.SendCoin)
-			protected.POST("/buy/:item", r.BuyItem)
+			protected.GET("/info", r.RequireScope(domain.ScopeInfoRead), r.GetUserInfo)
+			protected.GET("/events", r.RequireScope(domain.ScopeEventsRead), r.Events)
+			protected.POST("/sendCoin", r.RequireScope(domain.ScopeCoinsSend), r.SendCoin)
+			protected.GET("/sendCoin/quote", r.RequireScope(domain.ScopeCoinsSend), r.QuoteSendCoin)
+			protected.POST("/buy/:item", r.RequireScope(domain.ScopeShopBuy), r.BuyItem)
+			protected.POST("/me/password", r.RequireScope(domain.ScopeAccount), r.ChangePassword)
+			protected.POST("/me/mfa", r.RequireScope(domain.ScopeAccount), r.EnrollMFA)
+			protected.POST("/me/mfa/confirm", r.RequireScope(domain.ScopeAccount), r.ConfirmMFA)
+			protected.GET("/audit", r.RequireScope(domain.ScopeAuditRead), r.AuditorIdentity, r.GetAuditLog)
+
+			admin := protected.Group("/admin", r.RequireScope(domain.ScopeAdmin), r.AdminIdentity)
+			{
+				admin.POST("/coins/mint", r.MintCoins)
+				admin.POST("/coins/burn", r.BurnCoins)
+				admin.POST("/groups/:group/members", r.AddGroupMember)
+				admin.DELETE("/groups/:group/members/:username", r.RemoveGroupMember)
+				admin.POST("/users/:username/password-reset", r.IssuePasswordReset)
+
+				admin.POST("/webhooks", r.CreateWebhook)
+				admin.GET("/webhooks", r.ListWebhooks)
+				admin.DELETE("/webhooks/:id", r.DeleteWebhook)
+				admin.GET("/webhooks/:id/deliveries", r.GetWebhookDeliveries)
+				admin.POST("/webhooks/:id/deliveries/:delivery/retry", r.RetryWebhookDelivery)
+
+				admin.POST("/apikeys", r.CreateAPIKey)
+				admin.GET("/apikeys", r.ListAPIKeys)
+				admin.DELETE("/apikeys/:id", r.RevokeAPIKey)
+			}
 		}
 	}
 
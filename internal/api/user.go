@@ -1,7 +1,9 @@
 package api
 
 import (
+	"avito-shop/internal/domain"
 	"avito-shop/internal/erorrs"
+	"avito-shop/internal/i18n"
 	"avito-shop/internal/model"
 	"context"
 	"errors"
@@ -11,17 +13,19 @@ import (
 	"time"
 )
 
+//go:generate go run github.com/vektra/mockery/v2@latest --name=ServiceUserInterface
 type ServiceUserInterface interface {
-	SendCoinToUser(ctx context.Context, fromUserID int, toUserName string, amount int) error
+	SendCoinToUser(ctx context.Context, fromUserID int, toUserName string, amount domain.Money, currency string) error
 	BuyItem(ctx context.Context, userID int, input model.BuyItemRequestDTO) error
 	GetUserInfo(ctx context.Context, userID int) (model.InfoResponseDTO, error)
+	QuoteTransfer(ctx context.Context, amount domain.Money, currency string) (model.TransferQuoteDTO, error)
 }
 
 func (r *Api) SendCoin(c *gin.Context) {
 	userId, err := getUserId(c)
 	if err != nil {
-		r.logger.Error("unidentified user")
-		c.JSON(http.StatusUnauthorized, model.ErrorResponseDTO{Error: "пользователь не авторизован"})
+		r.log(c).Error("unidentified user")
+		r.respondError(c, errUnauthorized)
 		return
 	}
 
@@ -32,35 +36,48 @@ func (r *Api) SendCoin(c *gin.Context) {
 
 	err = c.ShouldBindJSON(&input)
 	if err != nil {
-		r.logger.Error("error bind json")
-		c.JSON(http.StatusBadRequest, model.ErrorResponseDTO{Error: "неверные данные для ввода"})
+		r.log(c).Error("error bind json")
+		r.respondError(c, errInvalidInput)
 		return
 	}
 
-	err = r.user.SendCoinToUser(ctx, userId, input.ToUser, input.Amount)
+	err = r.user.SendCoinToUser(ctx, userId, input.ToUser, input.Amount, input.Currency)
 	if err != nil {
-		switch {
-		case errors.Is(err, erorrs.ErrSelfTransfer):
-			c.JSON(http.StatusBadRequest, model.ErrorResponseDTO{Error: "нельзя отправить самому себе"})
-		case errors.Is(err, erorrs.ErrNotFound):
-			c.JSON(http.StatusBadRequest, model.ErrorResponseDTO{Error: "пользователь не найден"})
-		case errors.Is(err, erorrs.ErrInsufficientFunds):
-			c.JSON(http.StatusBadRequest, model.ErrorResponseDTO{Error: "недостаточно средств"})
-		default:
-			c.JSON(http.StatusInternalServerError, model.ErrorResponseDTO{Error: "внутренняя ошибка сервера"})
-		}
+		r.respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, "деньги успешно отправлены")
+	r.respondMessage(c, i18n.MsgCoinsSent)
+
+}
+
+func (r *Api) QuoteSendCoin(c *gin.Context) {
+	var input model.TransferQuoteRequestDTO
+
+	err := c.ShouldBindQuery(&input)
+	if err != nil {
+		r.log(c).Error("error bind query", zap.Error(err))
+		r.respondError(c, errInvalidInput)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	quote, err := r.user.QuoteTransfer(ctx, input.Amount, input.Currency)
+	if err != nil {
+		r.respondError(c, err)
+		return
+	}
 
+	c.JSON(http.StatusOK, quote)
 }
 
 func (r *Api) BuyItem(c *gin.Context) {
 	userID, err := getUserId(c)
 	if err != nil {
-		r.logger.Error("unidentified user")
-		c.JSON(http.StatusUnauthorized, model.ErrorResponseDTO{Error: "пользователь не авторизован"})
+		r.log(c).Error("unidentified user")
+		r.respondError(c, errUnauthorized)
 		return
 	}
 
@@ -71,36 +88,29 @@ func (r *Api) BuyItem(c *gin.Context) {
 
 	err = c.ShouldBindUri(&input)
 	if err != nil {
-		r.logger.Error("error bind json")
-		c.JSON(http.StatusBadRequest, model.ErrorResponseDTO{Error: "неверные данные для ввода"})
+		r.log(c).Error("error bind json")
+		r.respondError(c, errInvalidInput)
 		return
 	}
 
 	err = r.user.BuyItem(ctx, userID, input)
 	if err != nil {
-		switch {
-		case errors.Is(err, erorrs.ErrItemNotFound):
-			r.logger.Error("user not found", zap.String("item", input.Item))
-			c.JSON(http.StatusNotFound, model.ErrorResponseDTO{Error: "товар не найден"})
-		case errors.Is(err, erorrs.ErrNotFound):
-			c.JSON(http.StatusBadRequest, model.ErrorResponseDTO{Error: "пользователь не найден"})
-		case errors.Is(err, erorrs.ErrInsufficientFunds):
-			c.JSON(http.StatusBadRequest, model.ErrorResponseDTO{Error: "недостаточно средств"})
-		default:
-			c.JSON(http.StatusInternalServerError, model.ErrorResponseDTO{Error: "внутренняя ошибка сервера"})
+		if errors.Is(err, erorrs.ErrItemNotFound) {
+			r.log(c).Error("item not found", zap.String("item", input.Item))
 		}
+		r.respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, "товар успешно куплен")
+	r.respondMessage(c, i18n.MsgItemBought)
 
 }
 
 func (r *Api) GetUserInfo(c *gin.Context) {
 	userID, err := getUserId(c)
 	if err != nil {
-		r.logger.Error("unidentified user")
-		c.JSON(http.StatusUnauthorized, model.ErrorResponseDTO{Error: "пользователь не авторизован"})
+		r.log(c).Error("unidentified user")
+		r.respondError(c, errUnauthorized)
 		return
 	}
 
@@ -109,17 +119,14 @@ func (r *Api) GetUserInfo(c *gin.Context) {
 
 	userInfo, err := r.user.GetUserInfo(ctx, userID)
 	if err != nil {
-		switch {
-		case errors.Is(err, erorrs.ErrNotFound):
-			r.logger.Error("user not found", zap.Int("userID", userID))
-			c.JSON(http.StatusNotFound, model.ErrorResponseDTO{Error: "пользователь не найден"})
-		case errors.Is(err, erorrs.ErrItemNotFound):
-			r.logger.Error("no items found for user", zap.Int("userID", userID))
+		// отсутствие покупок не ошибка для клиента: отдаем информацию без инвентаря
+		if errors.Is(err, erorrs.ErrItemNotFound) {
+			r.log(c).Error("no items found for user", zap.Int("userID", userID))
 			c.JSON(http.StatusOK, userInfo)
-		default:
-			r.logger.Error("failed to get user info", zap.Int("userID", userID), zap.Error(err))
-			c.JSON(http.StatusInternalServerError, model.ErrorResponseDTO{Error: "внутренняя ошибка сервера"})
+			return
 		}
+		r.log(c).Error("failed to get user info", zap.Int("userID", userID), zap.Error(err))
+		r.respondError(c, err)
 		return
 	}
 
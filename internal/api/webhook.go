@@ -0,0 +1,129 @@
+package api
+
+import (
+	"avito-shop/internal/model"
+	"context"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type ServiceWebhookInterface interface {
+	CreateSubscription(ctx context.Context, actorID int, dto model.WebhookSubscriptionRequestDTO) (model.WebhookSubscriptionDTO, error)
+	ListSubscriptions(ctx context.Context) ([]model.WebhookSubscriptionDTO, error)
+	DeleteSubscription(ctx context.Context, actorID int, id int) error
+	GetDeliveries(ctx context.Context, subscriptionID int) ([]model.WebhookDeliveryDTO, error)
+	RetryDelivery(ctx context.Context, subscriptionID int, deliveryID int64) error
+}
+
+func (r *Api) CreateWebhook(c *gin.Context) {
+	actorID, err := getUserId(c)
+	if err != nil {
+		r.log(c).Error("unidentified user")
+		r.respondError(c, errUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	var input model.WebhookSubscriptionRequestDTO
+
+	err = c.ShouldBindJSON(&input)
+	if err != nil {
+		r.log(c).Error("error bind json", zap.Error(err))
+		r.respondError(c, errInvalidInput)
+		return
+	}
+
+	sub, err := r.webhooks.CreateSubscription(ctx, actorID, input)
+	if err != nil {
+		r.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, sub)
+}
+
+func (r *Api) ListWebhooks(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	subs, err := r.webhooks.ListSubscriptions(ctx)
+	if err != nil {
+		r.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subs)
+}
+
+func (r *Api) DeleteWebhook(c *gin.Context) {
+	actorID, err := getUserId(c)
+	if err != nil {
+		r.log(c).Error("unidentified user")
+		r.respondError(c, errUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		r.respondError(c, errInvalidInput)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := r.webhooks.DeleteSubscription(ctx, actorID, id); err != nil {
+		r.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (r *Api) GetWebhookDeliveries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		r.respondError(c, errInvalidInput)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	deliveries, err := r.webhooks.GetDeliveries(ctx, id)
+	if err != nil {
+		r.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+func (r *Api) RetryWebhookDelivery(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		r.respondError(c, errInvalidInput)
+		return
+	}
+
+	deliveryID, err := strconv.ParseInt(c.Param("delivery"), 10, 64)
+	if err != nil {
+		r.respondError(c, errInvalidInput)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := r.webhooks.RetryDelivery(ctx, id, deliveryID); err != nil {
+		r.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
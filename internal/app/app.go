@@ -2,16 +2,34 @@ package app
 
 import (
 	"avito-shop/internal/api"
+	"avito-shop/internal/api/openapi"
 	"avito-shop/internal/config"
 	"avito-shop/internal/db"
+	"avito-shop/internal/domain"
+	"avito-shop/internal/events"
+	"avito-shop/internal/grpcapi"
+	"avito-shop/internal/grpcapi/shopv1"
+	"avito-shop/internal/health"
+	"avito-shop/internal/i18n"
 	"avito-shop/internal/logger"
+	"avito-shop/internal/metrics"
+	"avito-shop/internal/ratelimit"
 	"avito-shop/internal/repository"
 	"avito-shop/internal/service"
+	"avito-shop/internal/tracing"
+	"avito-shop/internal/webhook"
+	"avito-shop/internal/worker"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"log"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 )
 
 func RunApp() {
@@ -21,6 +39,19 @@ func RunApp() {
 	logs := logger.NewLogger()
 	logs.Info("Logger initialized")
 
+	// Инициализация трассировки
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		ServiceName:  cfg.Tracing.ServiceName,
+		Exporter:     cfg.Tracing.Exporter,
+		OTLPEndpoint: cfg.Tracing.OTLPEndpoint,
+		SampleRatio:  cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		logs.Error("Failed to init tracing", zap.Error(err))
+		log.Fatalf("failed to init tracing: %v", err)
+	}
+	logs.Info("Tracing initialized", zap.String("exporter", cfg.Tracing.Exporter))
+
 	// Подключение к бд
 	db, err := storage.InitPostgres(cfg)
 	if err != nil {
@@ -31,40 +62,275 @@ func RunApp() {
 	// Инициализация репозиториев
 	repoAuth := repository.NewAuthRepo(db, logs)
 	repoUser := repository.NewUserRepo(db, logs)
+	repoAdmin := repository.NewAdminRepo(db, logs)
+	repoWebhook := repository.NewWebhookRepo(db, logs)
+	repoOutbox := repository.NewOutboxRepo(db, logs)
+	repoAudit := repository.NewAuditRepo(db, logs)
+	repoAPIKey := repository.NewAPIKeyRepo(db, logs)
 	logs.Info("Repos initialized")
 
+	// Инициализация шины событий
+	bus := events.NewBus(cfg.Events.BufferSize, func(ev events.Event) {
+		metrics.EventDropped(ev.Type)
+	})
+
+	var live events.Publisher = bus
+	var broker *events.PGBroker
+	if cfg.Events.Fanout == "postgres" {
+		broker = events.NewPGBroker(db, cfg.PgcConnString, bus, logs)
+		live = broker
+	}
+
 	// Инициализация сервисов
-	servAuth := service.NewAuthService(repoAuth, logs)
-	servUser := service.NewUserService(repoUser, logs)
+	expiry := domain.ExpiryPolicy{
+		Enabled:    cfg.Coins.ExpiryEnabled,
+		SoonWindow: cfg.Coins.ExpiringSoonWindow,
+	}
+
+	servAudit := service.NewAuditService(repoAudit, logs)
+	servWebhook := service.NewWebhookService(repoWebhook, webhook.NewSender(cfg.Webhooks.Timeout), servAudit, logs, domain.RetryPolicy{
+		MaxAttempts: cfg.Webhooks.MaxAttempts,
+		BaseDelay:   cfg.Webhooks.RetryBaseDelay,
+		MaxDelay:    cfg.Webhooks.RetryMaxDelay,
+	}, cfg.Webhooks.BatchSize)
+	publisher, err := outboxPublishers(cfg.Outbox, live, servWebhook, logs)
+	if err != nil {
+		log.Fatalf("invalid outbox settings: %v", err)
+	}
+
+	var lockoutStore ratelimit.LockoutStore = ratelimit.NewMemoryStore()
+	if cfg.AuthLimit.Store == "postgres" {
+		lockoutStore = repository.NewLockoutRepo(db, logs)
+	}
+	guard := ratelimit.NewGuard(lockoutStore, ratelimit.LockoutPolicy{
+		MaxFailures: cfg.AuthLimit.UsernameMaxFailures,
+		Window:      cfg.AuthLimit.Window,
+		BaseLockout: cfg.AuthLimit.BaseLockout,
+		MaxLockout:  cfg.AuthLimit.MaxLockout,
+	}, ratelimit.LockoutPolicy{
+		MaxFailures: cfg.AuthLimit.IPMaxFailures,
+		Window:      cfg.AuthLimit.Window,
+		BaseLockout: cfg.AuthLimit.BaseLockout,
+		MaxLockout:  cfg.AuthLimit.MaxLockout,
+	})
+
+	passwordPolicy := domain.PasswordPolicy{
+		MinLength:     cfg.Password.MinLength,
+		MaxLength:     cfg.Password.MaxLength,
+		RequireLetter: cfg.Password.RequireLetter,
+		RequireDigit:  cfg.Password.RequireDigit,
+		ResetTTL:      cfg.Password.ResetTTL,
+	}
+	servAuth := service.NewAuthService(repoAuth, servAudit, guard, logs, cfg.Coins.WelcomeGrant, expiry, passwordPolicy)
+	fees := domain.FeeRule{
+		Flat:        cfg.TransferFee.Flat,
+		BasisPoints: cfg.TransferFee.BasisPoints,
+		Min:         cfg.TransferFee.Min,
+		Max:         cfg.TransferFee.Max,
+		Currencies:  cfg.TransferFee.Currencies,
+	}
+
+	servUser := service.NewUserService(repoUser, servAudit, logs, expiry, fees)
+	servAdmin := service.NewAdminService(repoAdmin, servAudit, logs, expiry)
+	servAPIKey := service.NewAPIKeyService(repoAPIKey, servAudit, logs)
 	logs.Info("Services initialized")
 
+	// Инициализация проверок готовности
+	readiness := health.NewRegistry(2 * time.Second)
+	readiness.Register("database", health.Database(db))
+
+	migrationsCheck, err := health.Migrations(db, storage.MigrationsDir)
+	if err != nil {
+		logs.Error("Failed to init migrations check", zap.Error(err))
+		log.Fatalf("failed to init migrations check: %v", err)
+	}
+	readiness.Register("migrations", migrationsCheck)
+
+	shutdown := &health.ShutdownFlag{}
+	readiness.Register("shutdown", shutdown)
+
+	localizer, err := i18n.NewLocalizer(cfg.Locale.DefaultLanguage)
+	if err != nil {
+		logs.Error("Failed to init localizer", zap.Error(err))
+		log.Fatalf("failed to init localizer: %v", err)
+	}
+
+	var limiter api.RateLimiterInterface
+	if cfg.RateLimit.Enabled {
+		limiter = ratelimit.NewRouteLimiter(ratelimit.Budget{Rate: cfg.RateLimit.DefaultRate, Burst: cfg.RateLimit.DefaultBurst}, map[string]ratelimit.Budget{
+			"POST /api/buy/:item": {Rate: cfg.RateLimit.BuyRate, Burst: cfg.RateLimit.BuyBurst},
+			"POST /api/sendCoin":  {Rate: cfg.RateLimit.SendCoinRate, Burst: cfg.RateLimit.SendCoinBurst},
+			"GET /api/events":     {Rate: cfg.RateLimit.EventsRate, Burst: cfg.RateLimit.EventsBurst},
+			// gRPC-методы ведут к тем же транзакциям, что и HTTP-маршруты, и получают те же бюджеты
+			shopv1.UserService_BuyItem_FullMethodName:  {Rate: cfg.RateLimit.BuyRate, Burst: cfg.RateLimit.BuyBurst},
+			shopv1.UserService_SendCoin_FullMethodName: {Rate: cfg.RateLimit.SendCoinRate, Burst: cfg.RateLimit.SendCoinBurst},
+		})
+	}
+
 	// Инициализация обработчиков
-	handlers := api.NewApi(logs, servAuth, servUser)
+	handlers := api.NewApi(logs, servAuth, servUser, servAdmin, servWebhook, servAudit, servAPIKey, readiness, localizer, bus, limiter)
 	logs.Info("Handlers initialized")
 
 	// Инициализация роутера
-	router := handlers.InitRoutes()
+	router := handlers.InitRoutes(cfg.HTTPServer.TrustedProxies)
+	if cfg.OpenAPI.SwaggerUI {
+		router.GET("/docs", gin.WrapH(openapi.SwaggerUI("/openapi.json")))
+		logs.Info("Swagger UI enabled at /docs")
+	}
 	logs.Info("Routes initialized")
 
 	//Инициализация сервер
-	server := config.InitHttpServer(*cfg, router)
+	server := config.NewHttpServer(cfg.HTTPServer, router)
 	logs.Info("Server initialized")
 
+	grpcServer := grpcapi.NewServer(cfg.GRPCServer.Address, logs, servAuth, servUser, limiter)
+	logs.Info("gRPC server initialized")
+
+	serverErr := make(chan error, 2)
 	go func() {
 		logs.Info("Server staring")
+		serverErr <- server.Start()
+	}()
+	go func() {
+		logs.Info("gRPC server starting", zap.String("address", cfg.GRPCServer.Address))
+		serverErr <- grpcServer.Start()
+	}()
 
-		if err := config.RunServer(server); err != nil {
-			log.Fatalf("failed to start server: %v", err)
-		}
+	// Запуск фоновых задач
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	var workers sync.WaitGroup
+
+	if cfg.Coins.ExpiryEnabled {
+		expiryWorker := worker.NewExpiryWorker(servUser, logs, cfg.Coins.ExpiryCheckInterval)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			expiryWorker.Run(workersCtx)
+		}()
+		logs.Info("Expiry worker started")
+	}
+
+	lockoutWorker := worker.NewLockoutWorker(guard, logs, cfg.AuthLimit.PurgeInterval)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		lockoutWorker.Run(workersCtx)
 	}()
+	logs.Info("Lockout worker started", zap.String("store", cfg.AuthLimit.Store))
+
+	relay := worker.NewOutboxRelay(repoOutbox, publisher, logs, cfg.Outbox.PollInterval, cfg.Outbox.BatchSize)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		relay.Run(workersCtx)
+	}()
+	logs.Info("Outbox relay started", zap.Strings("publishers", cfg.Outbox.Publishers))
+
+	if cfg.Webhooks.Enabled {
+		webhookWorker := worker.NewWebhookWorker(servWebhook, logs, cfg.Webhooks.PollInterval)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			webhookWorker.Run(workersCtx)
+		}()
+		logs.Info("Webhook worker started")
+	}
+
+	if broker != nil {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			if err := broker.Run(workersCtx); err != nil {
+				logs.Error("Events listener failed", zap.Error(err))
+			}
+		}()
+		logs.Info("Events listener started")
+	}
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+
+	select {
+	case sig := <-quit:
+		logs.Info("Shutting down", zap.String("signal", sig.String()))
+	case err := <-serverErr:
+		if err != nil {
+			logs.Error("Server failed", zap.Error(err))
+		}
+	}
+
+	shutdown.Set()
+
+	// Открытые потоки событий не завершатся сами, поэтому закрываем их до остановки сервера
+	bus.Close()
+
+	// Порядок остановки: серверы дожидаются текущих запросов, затем останавливаются фоновые задачи,
+	// и только после этого закрывается пул соединений с бд. HTTP и gRPC останавливаются одновременно
+	// с общим дедлайном, чтобы gRPC не принимал новые вызовы, пока завершается HTTP, и не обрывал
+	// текущие переводы из-за уже истекшего контекста
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTPServer.ShutdownTimeout)
+	defer cancel()
+
+	var servers sync.WaitGroup
+	servers.Add(2)
+	go func() {
+		defer servers.Done()
+		if err := server.Stop(shutdownCtx); err != nil {
+			logs.Error("Server is not stopped gracefully", zap.Error(err))
+		} else {
+			logs.Info("Server stopped")
+		}
+	}()
+	go func() {
+		defer servers.Done()
+		if err := grpcServer.Stop(shutdownCtx); err != nil {
+			logs.Error("gRPC server is not stopped gracefully", zap.Error(err))
+		} else {
+			logs.Info("gRPC server stopped")
+		}
+	}()
+	servers.Wait()
+
+	stopWorkers()
+	workers.Wait()
+	logs.Info("Workers stopped")
 
 	err = db.Close()
 	if err != nil {
 		log.Fatalf("Database is not closed")
 	}
 	logs.Info("Database closed")
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logs.Error("Failed to flush traces", zap.Error(err))
+	}
+}
+
+// outboxPublishers собирает публикаторы событий outbox в порядке, заданном в конфиге. Релей отмечает
+// событие опубликованным, когда его приняли все публикаторы, поэтому без публикаторов или с опечаткой
+// в имени события терялись бы безвозвратно - такой конфиг отклоняется
+func outboxPublishers(cfg config.Outbox, live events.Publisher, webhooks events.Publisher, logs logger.Logger) (events.Publishers, error) {
+	publishers := make(events.Publishers, 0, len(cfg.Publishers))
+	for _, name := range cfg.Publishers {
+		switch name {
+		case "live":
+			publishers = append(publishers, live)
+		case "webhook":
+			publishers = append(publishers, webhooks)
+		case "log":
+			publishers = append(publishers, events.NewLogPublisher(logs))
+		case "kafka-memory":
+			publishers = append(publishers, events.NewKafkaPublisher(events.NewMemoryProducer(), cfg.KafkaTopic))
+		default:
+			return nil, fmt.Errorf("unknown outbox publisher %q", name)
+		}
+	}
+
+	if len(publishers) == 0 {
+		return nil, errors.New("no outbox publishers configured")
+	}
+
+	return publishers, nil
 }
@@ -0,0 +1,32 @@
+//go:build unit
+// +build unit
+
+package app
+
+import (
+	"avito-shop/internal/config"
+	"avito-shop/internal/events"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type nopPublisher struct{}
+
+func (nopPublisher) Publish(context.Context, events.Event) error {
+	return nil
+}
+
+func TestOutboxPublishers(t *testing.T) {
+	publishers, err := outboxPublishers(config.Outbox{Publishers: []string{"live", "webhook"}}, nopPublisher{}, nopPublisher{}, nil)
+	require.NoError(t, err)
+	assert.Len(t, publishers, 2)
+
+	// опечатка в имени не должна оставлять релей без публикаторов
+	_, err = outboxPublishers(config.Outbox{Publishers: []string{"live", "webhooks"}}, nopPublisher{}, nopPublisher{}, nil)
+	assert.ErrorContains(t, err, "webhooks")
+
+	_, err = outboxPublishers(config.Outbox{}, nopPublisher{}, nopPublisher{}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,95 @@
+// Package audit считает хеш-цепочку журнала аудита и переносит сведения о запросе
+// (IP, User-Agent, ID запроса) от транспорта к сервисам
+package audit
+
+import (
+	"avito-shop/internal/domain"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// canonicalEntry - содержимое записи, от которого считается хеш. Порядок полей и формат
+// времени фиксированы: изменение этой структуры ломает проверку уже записанного журнала
+type canonicalEntry struct {
+	OccurredAt string            `json:"occurred_at"`
+	ActorID    int               `json:"actor_id"`
+	Action     string            `json:"action"`
+	Target     string            `json:"target"`
+	IP         string            `json:"ip"`
+	UserAgent  string            `json:"user_agent"`
+	RequestID  string            `json:"request_id"`
+	Details    map[string]string `json:"details,omitempty"`
+}
+
+// Timestamp приводит время к точности Postgres, чтобы хеш не менялся после чтения записи из базы
+func Timestamp(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+// Hash возвращает хеш записи, связанный с хешем предыдущей записи prevHash
+func Hash(prevHash string, entry domain.AuditEntry) string {
+	body, _ := json.Marshal(canonicalEntry{
+		OccurredAt: Timestamp(entry.OccurredAt).Format(time.RFC3339Nano),
+		ActorID:    entry.ActorID,
+		Action:     entry.Action,
+		Target:     entry.Target,
+		IP:         entry.IP,
+		UserAgent:  entry.UserAgent,
+		RequestID:  entry.RequestID,
+		Details:    entry.Details,
+	})
+
+	h := sha256.New()
+	h.Write([]byte(prevHash))
+	h.Write([]byte{'\n'})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ChainError описывает первую запись, на которой нарушена цепочка
+type ChainError struct {
+	EntryID int64
+	Reason  string
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("audit chain broken at entry %d: %s", e.EntryID, e.Reason)
+}
+
+// Verify проверяет, что записи идут подряд за записью с хешем prevHash и ни одна не изменена.
+// Возвращает хеш последней записи, чтобы журнал можно было проверять по частям
+func Verify(prevHash string, entries []domain.AuditEntry) (string, error) {
+	for _, entry := range entries {
+		if entry.PrevHash != prevHash {
+			return "", &ChainError{EntryID: entry.ID, Reason: "previous hash mismatch"}
+		}
+		if Hash(prevHash, entry) != entry.Hash {
+			return "", &ChainError{EntryID: entry.ID, Reason: "content hash mismatch"}
+		}
+		prevHash = entry.Hash
+	}
+
+	return prevHash, nil
+}
+
+// RequestInfo - сведения о запросе, которые попадают в журнал аудита
+type RequestInfo struct {
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+type requestInfoKey struct{}
+
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+func RequestInfoFromContext(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info
+}
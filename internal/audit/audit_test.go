@@ -0,0 +1,106 @@
+//go:build unit
+// +build unit
+
+package audit
+
+import (
+	"avito-shop/internal/domain"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func buildChain(n int) []domain.AuditEntry {
+	prev := ""
+	start := time.Date(2026, 10, 19, 12, 0, 0, 123456789, time.UTC)
+	entries := make([]domain.AuditEntry, 0, n)
+	for i := 0; i < n; i++ {
+		entry := domain.AuditEntry{
+			ID:         int64(i + 1),
+			OccurredAt: Timestamp(start.Add(time.Duration(i) * time.Second)),
+			ActorID:    i,
+			Action:     domain.AuditTransfer,
+			Target:     "user:friend",
+			IP:         "10.0.0.1",
+			RequestID:  "req",
+			Details:    map[string]string{"amount": "10.00", "currency": domain.DefaultCurrency},
+			PrevHash:   prev,
+		}
+		entry.Hash = Hash(prev, entry)
+		prev = entry.Hash
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name     string
+		tamper   func(entries []domain.AuditEntry) []domain.AuditEntry
+		brokenID int64
+	}{
+		{name: "intact", tamper: func(e []domain.AuditEntry) []domain.AuditEntry { return e }},
+		{name: "changed details", brokenID: 2, tamper: func(e []domain.AuditEntry) []domain.AuditEntry {
+			e[1].Details = map[string]string{"amount": "1000.00", "currency": domain.DefaultCurrency}
+			return e
+		}},
+		{name: "changed actor", brokenID: 3, tamper: func(e []domain.AuditEntry) []domain.AuditEntry {
+			e[2].ActorID = 42
+			return e
+		}},
+		{name: "deleted entry", brokenID: 3, tamper: func(e []domain.AuditEntry) []domain.AuditEntry {
+			return append(e[:1], e[2:]...)
+		}},
+		{name: "rehashed entry", brokenID: 3, tamper: func(e []domain.AuditEntry) []domain.AuditEntry {
+			e[1].Target = "user:thief"
+			e[1].Hash = Hash(e[1].PrevHash, e[1])
+			return e
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := tt.tamper(buildChain(4))
+
+			last, err := Verify("", entries)
+
+			if tt.brokenID == 0 {
+				require.NoError(t, err)
+				assert.Equal(t, entries[len(entries)-1].Hash, last)
+				return
+			}
+			var chainErr *ChainError
+			require.True(t, errors.As(err, &chainErr))
+			assert.Equal(t, tt.brokenID, chainErr.EntryID)
+		})
+	}
+}
+
+func TestVerify_InParts(t *testing.T) {
+	entries := buildChain(5)
+
+	last, err := Verify("", entries[:2])
+	require.NoError(t, err)
+
+	last, err = Verify(last, entries[2:])
+	require.NoError(t, err)
+	assert.Equal(t, entries[4].Hash, last)
+}
+
+func TestHash_EmptyDetails(t *testing.T) {
+	entry := domain.AuditEntry{OccurredAt: time.Now(), Action: domain.AuditLogin}
+	withEmpty := entry
+	withEmpty.Details = map[string]string{}
+
+	assert.Equal(t, Hash("", entry), Hash("", withEmpty))
+}
+
+func TestRequestInfo(t *testing.T) {
+	info := RequestInfo{IP: "10.0.0.1", UserAgent: "curl/8", RequestID: "req-1"}
+
+	assert.Equal(t, info, RequestInfoFromContext(WithRequestInfo(context.Background(), info)))
+	assert.Equal(t, RequestInfo{}, RequestInfoFromContext(context.Background()))
+}
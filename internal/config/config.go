@@ -1,7 +1,9 @@
 package config
 
 import (
+	"avito-shop/internal/domain"
 	"log"
+	"net"
 	"os"
 	"time"
 
@@ -12,14 +14,136 @@ import (
 
 type Config struct {
 	HTTPServer    `env:"HTTP_SERVER"`
+	GRPCServer    `env:"GRPC_SERVER"`
+	Coins         `env:"COINS"`
+	TransferFee   `env:"TRANSFER_FEE"`
+	Tracing       `env:"TRACING"`
+	Locale        `env:"LOCALE"`
+	OpenAPI       `env:"OPENAPI"`
+	Events        `env:"EVENTS"`
+	Webhooks      `env:"WEBHOOKS"`
+	Outbox        `env:"OUTBOX"`
+	AuthLimit     `env:"AUTH_LIMIT"`
+	RateLimit     `env:"RATE_LIMIT"`
+	Password      `env:"PASSWORD"`
 	PgcConnString string `env:"PG_DSN"`
 }
 
-// HTTPServer содержит настройки HTTP-сервера
+// HTTPServer содержит настройки HTTP-сервера. TrustedProxies - адреса и подсети прокси, которым
+// доверяется X-Forwarded-For. По умолчанию не доверяется никому и IP клиента - адрес соединения
 type HTTPServer struct {
-	Address     string        `env:"HTTP_SERVER_ADDRESS" env-default:"0.0.0.0:8080"`
-	Timeout     time.Duration `env:"HTTP_SERVER_TIMEOUT" env-default:"5s"`
-	IdleTimeout time.Duration `env:"HTTP_SERVER_IDLE_TIMEOUT" env-default:"60s"`
+	Address         string        `env:"HTTP_SERVER_ADDRESS" env-default:"0.0.0.0:8080"`
+	Timeout         time.Duration `env:"HTTP_SERVER_TIMEOUT" env-default:"5s"`
+	IdleTimeout     time.Duration `env:"HTTP_SERVER_IDLE_TIMEOUT" env-default:"60s"`
+	ShutdownTimeout time.Duration `env:"HTTP_SERVER_SHUTDOWN_TIMEOUT" env-default:"15s"`
+	TrustedProxies  []string      `env:"HTTP_SERVER_TRUSTED_PROXIES" env-separator:","`
+}
+
+// GRPCServer содержит настройки gRPC-сервера для внутренних сервисов
+type GRPCServer struct {
+	Address string `env:"GRPC_SERVER_ADDRESS" env-default:"0.0.0.0:9090"`
+}
+
+// Coins содержит настройки начисления монет
+type Coins struct {
+	WelcomeGrant        domain.Money  `env:"COINS_WELCOME_GRANT" env-default:"1000"`
+	ExpiryEnabled       bool          `env:"COINS_EXPIRY_ENABLED" env-default:"true"`
+	ExpiryCheckInterval time.Duration `env:"COINS_EXPIRY_CHECK_INTERVAL" env-default:"1h"`
+	ExpiringSoonWindow  time.Duration `env:"COINS_EXPIRING_SOON_WINDOW" env-default:"720h"`
+}
+
+// TransferFee содержит правила комиссии за перевод монет
+type TransferFee struct {
+	Flat        domain.Money `env:"TRANSFER_FEE_FLAT" env-default:"0"`
+	BasisPoints int64        `env:"TRANSFER_FEE_BASIS_POINTS" env-default:"0"`
+	Min         domain.Money `env:"TRANSFER_FEE_MIN" env-default:"0"`
+	Max         domain.Money `env:"TRANSFER_FEE_MAX" env-default:"0"`
+	Currencies  []string     `env:"TRANSFER_FEE_CURRENCIES" env-default:"coin" env-separator:","`
+}
+
+// Tracing содержит настройки экспорта трассировок: none, stdout или otlp
+type Tracing struct {
+	ServiceName  string  `env:"TRACING_SERVICE_NAME" env-default:"avito-shop"`
+	Exporter     string  `env:"TRACING_EXPORTER" env-default:"none"`
+	OTLPEndpoint string  `env:"TRACING_OTLP_ENDPOINT" env-default:"localhost:4318"`
+	SampleRatio  float64 `env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+}
+
+// Locale содержит настройки языка сообщений для пользователя
+type Locale struct {
+	DefaultLanguage string `env:"LOCALE_DEFAULT_LANGUAGE" env-default:"ru"`
+}
+
+// OpenAPI содержит настройки публикации документации API
+type OpenAPI struct {
+	SwaggerUI bool `env:"OPENAPI_SWAGGER_UI" env-default:"false"`
+}
+
+// Events содержит настройки доставки событий пользователям. Fanout postgres рассылает
+// события всем репликам через LISTEN/NOTIFY, none - только внутри процесса
+type Events struct {
+	Fanout     string `env:"EVENTS_FANOUT" env-default:"postgres"`
+	BufferSize int    `env:"EVENTS_BUFFER_SIZE" env-default:"16"`
+}
+
+// Webhooks содержит настройки отправки вебхуков: опрос очереди и повторы с экспоненциальной задержкой
+type Webhooks struct {
+	Enabled        bool          `env:"WEBHOOKS_ENABLED" env-default:"true"`
+	PollInterval   time.Duration `env:"WEBHOOKS_POLL_INTERVAL" env-default:"2s"`
+	BatchSize      int           `env:"WEBHOOKS_BATCH_SIZE" env-default:"20"`
+	Timeout        time.Duration `env:"WEBHOOKS_TIMEOUT" env-default:"5s"`
+	MaxAttempts    int           `env:"WEBHOOKS_MAX_ATTEMPTS" env-default:"8"`
+	RetryBaseDelay time.Duration `env:"WEBHOOKS_RETRY_BASE_DELAY" env-default:"30s"`
+	RetryMaxDelay  time.Duration `env:"WEBHOOKS_RETRY_MAX_DELAY" env-default:"1h"`
+}
+
+// Outbox содержит настройки релея событий. Publishers - куда публиковать события:
+// live (потоки событий пользователей), webhook, log, kafka-memory (брокер в памяти, для разработки)
+type Outbox struct {
+	PollInterval time.Duration `env:"OUTBOX_POLL_INTERVAL" env-default:"1s"`
+	BatchSize    int           `env:"OUTBOX_BATCH_SIZE" env-default:"100"`
+	Publishers   []string      `env:"OUTBOX_PUBLISHERS" env-default:"live,webhook" env-separator:","`
+	KafkaTopic   string        `env:"OUTBOX_KAFKA_TOPIC" env-default:"shop-events"`
+}
+
+// AuthLimit содержит настройки защиты /api/auth от перебора паролей. После MaxFailures неверных
+// паролей за Window имя пользователя или IP блокируется на BaseLockout, каждая следующая блокировка
+// вдвое дольше, но не дольше MaxLockout. MaxFailures 0 отключает блокировку по этому ключу.
+// Store - где хранить счетчики: memory (один экземпляр) или postgres (общие для всех реплик)
+type AuthLimit struct {
+	Store               string        `env:"AUTH_LIMIT_STORE" env-default:"postgres"`
+	UsernameMaxFailures int           `env:"AUTH_LIMIT_USERNAME_MAX_FAILURES" env-default:"5"`
+	IPMaxFailures       int           `env:"AUTH_LIMIT_IP_MAX_FAILURES" env-default:"20"`
+	Window              time.Duration `env:"AUTH_LIMIT_WINDOW" env-default:"15m"`
+	BaseLockout         time.Duration `env:"AUTH_LIMIT_BASE_LOCKOUT" env-default:"1m"`
+	MaxLockout          time.Duration `env:"AUTH_LIMIT_MAX_LOCKOUT" env-default:"1h"`
+	PurgeInterval       time.Duration `env:"AUTH_LIMIT_PURGE_INTERVAL" env-default:"10m"`
+}
+
+// RateLimit содержит бюджеты запросов пользователя к защищенным маршрутам: Rate запросов в секунду,
+// не больше Burst подряд. Default действует на маршруты без собственного бюджета, нулевой бюджет
+// снимает ограничение. Бюджеты Buy и SendCoin действуют и на методы BuyItem и SendCoin в gRPC API.
+// Бюджет считается отдельно на каждой реплике
+type RateLimit struct {
+	Enabled       bool    `env:"RATE_LIMIT_ENABLED" env-default:"true"`
+	DefaultRate   float64 `env:"RATE_LIMIT_DEFAULT_RATE" env-default:"10"`
+	DefaultBurst  int     `env:"RATE_LIMIT_DEFAULT_BURST" env-default:"20"`
+	BuyRate       float64 `env:"RATE_LIMIT_BUY_RATE" env-default:"1"`
+	BuyBurst      int     `env:"RATE_LIMIT_BUY_BURST" env-default:"5"`
+	SendCoinRate  float64 `env:"RATE_LIMIT_SEND_COIN_RATE" env-default:"1"`
+	SendCoinBurst int     `env:"RATE_LIMIT_SEND_COIN_BURST" env-default:"5"`
+	EventsRate    float64 `env:"RATE_LIMIT_EVENTS_RATE" env-default:"0.2"`
+	EventsBurst   int     `env:"RATE_LIMIT_EVENTS_BURST" env-default:"3"`
+}
+
+// Password содержит требования к паролям пользователей и срок действия токена сброса пароля.
+// MaxLength 0 снимает ограничение длины сверху
+type Password struct {
+	MinLength     int           `env:"PASSWORD_MIN_LENGTH" env-default:"8"`
+	MaxLength     int           `env:"PASSWORD_MAX_LENGTH" env-default:"72"`
+	RequireLetter bool          `env:"PASSWORD_REQUIRE_LETTER" env-default:"true"`
+	RequireDigit  bool          `env:"PASSWORD_REQUIRE_DIGIT" env-default:"true"`
+	ResetTTL      time.Duration `env:"PASSWORD_RESET_TTL" env-default:"1h"`
 }
 
 func MustLoad() *Config {
@@ -40,5 +164,67 @@ func MustLoad() *Config {
 		log.Fatalf("Failed to read environment variables: %v", err)
 	}
 
+	for _, proxy := range cfg.HTTPServer.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				log.Fatalf("invalid HTTP_SERVER_TRUSTED_PROXIES entry %q", proxy)
+			}
+		}
+	}
+
+	if cfg.Coins.WelcomeGrant < 0 {
+		log.Fatalf("COINS_WELCOME_GRANT must not be negative")
+	}
+
+	if cfg.Events.Fanout != "postgres" && cfg.Events.Fanout != "none" {
+		log.Fatalf("EVENTS_FANOUT must be postgres or none")
+	}
+
+	if cfg.Webhooks.BatchSize <= 0 || cfg.Webhooks.MaxAttempts <= 0 {
+		log.Fatalf("WEBHOOKS_BATCH_SIZE and WEBHOOKS_MAX_ATTEMPTS must be positive")
+	}
+
+	if cfg.Outbox.BatchSize <= 0 {
+		log.Fatalf("OUTBOX_BATCH_SIZE must be positive")
+	}
+
+	if len(cfg.Outbox.Publishers) == 0 {
+		log.Fatalf("OUTBOX_PUBLISHERS must not be empty")
+	}
+
+	for _, publisher := range cfg.Outbox.Publishers {
+		switch publisher {
+		case "live", "webhook", "log", "kafka-memory":
+		default:
+			log.Fatalf("unknown OUTBOX_PUBLISHERS entry %q", publisher)
+		}
+	}
+
+	if cfg.AuthLimit.Store != "memory" && cfg.AuthLimit.Store != "postgres" {
+		log.Fatalf("AUTH_LIMIT_STORE must be memory or postgres")
+	}
+
+	if cfg.AuthLimit.UsernameMaxFailures < 0 || cfg.AuthLimit.IPMaxFailures < 0 || cfg.AuthLimit.BaseLockout <= 0 || cfg.AuthLimit.MaxLockout < cfg.AuthLimit.BaseLockout {
+		log.Fatalf("invalid AUTH_LIMIT settings")
+	}
+
+	limit := cfg.RateLimit
+	for _, value := range []float64{limit.DefaultRate, limit.BuyRate, limit.SendCoinRate, limit.EventsRate,
+		float64(limit.DefaultBurst), float64(limit.BuyBurst), float64(limit.SendCoinBurst), float64(limit.EventsBurst)} {
+		if value < 0 {
+			log.Fatalf("invalid RATE_LIMIT settings")
+		}
+	}
+
+	password := cfg.Password
+	if password.MinLength < 0 || password.MaxLength < 0 || (password.MaxLength > 0 && password.MinLength > password.MaxLength) || password.ResetTTL <= 0 {
+		log.Fatalf("invalid PASSWORD settings")
+	}
+
+	fee := cfg.TransferFee
+	if fee.Flat < 0 || fee.BasisPoints < 0 || fee.BasisPoints > domain.MaxFeeBasisPoints || fee.Min < 0 || fee.Max < 0 || (fee.Max > 0 && fee.Min > fee.Max) {
+		log.Fatalf("invalid TRANSFER_FEE settings")
+	}
+
 	return &cfg
 }
@@ -1,29 +1,37 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"net/http"
-	"time"
 )
 
 type HttpServer struct {
-	server http.Server
+	server *http.Server
 }
 
-func InitHttpServer(cfg Config, router http.Handler) *HttpServer {
+func NewHttpServer(cfg HTTPServer, router http.Handler) *HttpServer {
 	return &HttpServer{
-		server: http.Server{
+		server: &http.Server{
 			Addr:         cfg.Address,
 			Handler:      router,
-			ReadTimeout:  cfg.HTTPServer.Timeout * time.Second,
-			WriteTimeout: cfg.HTTPServer.Timeout * time.Second,
-			IdleTimeout:  cfg.HTTPServer.IdleTimeout * time.Second,
+			ReadTimeout:  cfg.Timeout,
+			WriteTimeout: cfg.Timeout,
+			IdleTimeout:  cfg.IdleTimeout,
 		},
 	}
 }
 
-func RunServer(server *HttpServer) error {
-	if err := server.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+// Start принимает соединения до вызова Stop. После штатной остановки возвращает nil
+func (s *HttpServer) Start() error {
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
 	return nil
 }
+
+// Stop перестает принимать новые соединения и ждет завершения обрабатываемых запросов,
+// но не дольше дедлайна ctx
+func (s *HttpServer) Stop(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
@@ -2,16 +2,25 @@ package storage
 
 import (
 	"avito-shop/internal/config"
+	"avito-shop/internal/metrics"
 	"database/sql"
 	"fmt"
+	"github.com/XSAM/otelsql"
 	"github.com/pressly/goose"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 	"log"
 
 	_ "github.com/lib/pq"
 )
 
+// MigrationsDir - каталог с миграциями, применяемыми при запуске
+const MigrationsDir = "./migrations"
+
 func InitPostgres(cfg *config.Config) (*sql.DB, error) {
-	db, err := sql.Open("postgres", cfg.PgcConnString)
+	db, err := otelsql.Open("postgres", cfg.PgcConnString,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{OmitConnResetSession: true, OmitRows: true}),
+	)
 	if err != nil {
 		log.Fatal("Error open connection to db")
 		return nil, fmt.Errorf("storage.psql.New: failed to open database connection: %w", err)
@@ -23,11 +32,13 @@ func InitPostgres(cfg *config.Config) (*sql.DB, error) {
 		return nil, fmt.Errorf("storage.psql.New: failed to ping database: %w", err)
 	}
 
+	metrics.RegisterDBStats(db)
+
 	if err := goose.SetDialect("postgres"); err != nil {
 		return nil, fmt.Errorf("storage.psql.New: failed to set dialect: %w", err)
 	}
 
-	if err = goose.Up(db, "./migrations"); err != nil {
+	if err = goose.Up(db, MigrationsDir); err != nil {
 		return nil, fmt.Errorf("storage.psql.New: failed to up migrations: %w", err)
 	}
 
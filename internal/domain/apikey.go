@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// Права доступа. Ключ API разрешает только перечисленные в нем действия
+const (
+	ScopeInfoRead   = "info:read"
+	ScopeEventsRead = "events:read"
+	ScopeCoinsSend  = "coins:send"
+	ScopeShopBuy    = "shop:buy"
+	ScopeAccount    = "account:write"
+	ScopeAuditRead  = "audit:read"
+	ScopeAdmin      = "admin"
+)
+
+// Scopes - все известные права доступа
+var Scopes = []string{ScopeInfoRead, ScopeEventsRead, ScopeCoinsSend, ScopeShopBuy, ScopeAccount, ScopeAuditRead, ScopeAdmin}
+
+// APIKey - долгоживущий ключ для ботов и сервисных учетных записей. Сам ключ не хранится,
+// только его хеш, Prefix показывается в списке ключей, чтобы их можно было различить
+type APIKey struct {
+	ID         int
+	UserID     int
+	Username   string
+	Name       string
+	Prefix     string
+	Hash       string
+	Scopes     []string
+	CreatedBy  int
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+// Active сообщает, можно ли аутентифицироваться ключом в момент now
+func (k APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+func (k APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
@@ -0,0 +1,54 @@
+package domain
+
+import "time"
+
+// Действия, которые пишутся в журнал аудита
+const (
+	AuditLogin              = "auth.login"
+	AuditLoginFailed        = "auth.login_failed"
+	AuditRegister           = "auth.register"
+	AuditTokenIssued        = "auth.token_issued"
+	AuditLockout            = "auth.lockout"
+	AuditPasswordChange     = "auth.password_change"
+	AuditPasswordReset      = "auth.password_reset"
+	AuditMFAEnable          = "auth.mfa_enable"
+	AuditMFAChallenge       = "auth.mfa_challenge"
+	AuditTransfer           = "coins.transfer"
+	AuditMint               = "admin.coins_mint"
+	AuditBurn               = "admin.coins_burn"
+	AuditGroupAdd           = "admin.group_member_add"
+	AuditGroupRemove        = "admin.group_member_remove"
+	AuditWebhookCreate      = "admin.webhook_create"
+	AuditWebhookDelete      = "admin.webhook_delete"
+	AuditAPIKeyCreate       = "admin.api_key_create"
+	AuditAPIKeyRevoke       = "admin.api_key_revoke"
+	AuditPasswordResetIssue = "admin.password_reset_issue"
+)
+
+// AuditEntry - запись журнала аудита. ActorID равен 0, если пользователь не установлен,
+// например при неудачном входе. Hash считается от PrevHash и содержимого записи
+type AuditEntry struct {
+	ID         int64
+	OccurredAt time.Time
+	ActorID    int
+	Action     string
+	Target     string
+	IP         string
+	UserAgent  string
+	RequestID  string
+	Details    map[string]string
+	PrevHash   string
+	Hash       string
+}
+
+// AuditFilter - условия выборки из журнала аудита. Нулевые значения не ограничивают выборку,
+// BeforeID позволяет листать журнал от новых записей к старым
+type AuditFilter struct {
+	ActorID  int
+	Action   string
+	Target   string
+	From     time.Time
+	To       time.Time
+	BeforeID int64
+	Limit    int
+}
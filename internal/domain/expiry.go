@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// ExpiryPolicy определяет срок жизни начисленных монет: монеты, начисленные в квартале,
+// сгорают в конце следующего квартала
+type ExpiryPolicy struct {
+	Enabled    bool
+	SoonWindow time.Duration
+}
+
+// ExpiresAt возвращает момент сгорания монет, начисленных в grantedAt, или nil, если монеты бессрочные
+func (p ExpiryPolicy) ExpiresAt(grantedAt time.Time) *time.Time {
+	if !p.Enabled {
+		return nil
+	}
+
+	quarterStart := time.Month((int(grantedAt.Month())-1)/3*3 + 1)
+	expiresAt := time.Date(grantedAt.Year(), quarterStart+6, 1, 0, 0, 0, 0, grantedAt.Location())
+
+	return &expiresAt
+}
@@ -0,0 +1,54 @@
+//go:build unit
+// +build unit
+
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestExpiryPolicy_ExpiresAt(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    ExpiryPolicy
+		grantedAt time.Time
+		expected  *time.Time
+	}{
+		{
+			name:      "disabled",
+			policy:    ExpiryPolicy{},
+			grantedAt: time.Date(2026, time.February, 10, 12, 0, 0, 0, time.UTC),
+			expected:  nil,
+		},
+		{
+			name:      "first day of quarter",
+			policy:    ExpiryPolicy{Enabled: true},
+			grantedAt: time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC),
+			expected:  ptr(time.Date(2026, time.July, 1, 0, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:      "last day of quarter",
+			policy:    ExpiryPolicy{Enabled: true},
+			grantedAt: time.Date(2026, time.September, 30, 23, 59, 0, 0, time.UTC),
+			expected:  ptr(time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:      "fourth quarter rolls over year",
+			policy:    ExpiryPolicy{Enabled: true},
+			grantedAt: time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC),
+			expected:  ptr(time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.policy.ExpiresAt(tt.grantedAt))
+		})
+	}
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,56 @@
+package domain
+
+import "slices"
+
+// TreasuryUsername - системный пользователь, на который зачисляются комиссии
+const TreasuryUsername = SystemUsernamePrefix + "treasury__"
+
+// SystemUsernamePrefix зарезервирован за системными пользователями, зарегистрироваться с ним нельзя
+const SystemUsernamePrefix = "__"
+
+// basisPointsPerUnit - количество базисных пунктов в 100%
+const basisPointsPerUnit = 10000
+
+// MaxFeeBasisPoints - наибольшая процентная часть комиссии, 100% суммы. С ней комиссия любой суммы,
+// которую принимает ParseMoney, вместе с самой суммой помещается в int64
+const MaxFeeBasisPoints = basisPointsPerUnit
+
+// FeeRule описывает комиссию за перевод: фиксированная часть плюс процент от суммы,
+// ограниченные снизу Min и сверху Max (Max == 0 - без ограничения)
+type FeeRule struct {
+	Flat        Money
+	BasisPoints int64
+	Min         Money
+	Max         Money
+	Currencies  []string
+}
+
+// Calculate возвращает комиссию за перевод amount в валюте currency.
+// Процентная часть округляется до минимальной единицы по правилам арифметического округления.
+// Сумма делится до умножения, чтобы произведение не переполняло int64 при BasisPoints не больше MaxFeeBasisPoints
+func (r FeeRule) Calculate(currency string, amount Money) Money {
+	if !slices.Contains(r.Currencies, currency) {
+		return 0
+	}
+
+	whole, rest := int64(amount)/basisPointsPerUnit, int64(amount)%basisPointsPerUnit
+	percent := whole*r.BasisPoints + (rest*r.BasisPoints+basisPointsPerUnit/2)/basisPointsPerUnit
+	fee := r.Flat + Money(percent)
+
+	if fee < r.Min {
+		fee = r.Min
+	}
+	if r.Max > 0 && fee > r.Max {
+		fee = r.Max
+	}
+
+	return fee
+}
+
+type Transfer struct {
+	FromUserID int
+	ToUserID   int
+	Amount     Money
+	Fee        Money
+	Currency   string
+}
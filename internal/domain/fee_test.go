@@ -0,0 +1,64 @@
+//go:build unit
+// +build unit
+
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFeeRule_Calculate(t *testing.T) {
+	rule := FeeRule{
+		Flat:        NewMoney(1),
+		BasisPoints: 150,
+		Min:         NewMoney(2),
+		Max:         NewMoney(50),
+		Currencies:  []string{DefaultCurrency},
+	}
+
+	tests := []struct {
+		name     string
+		currency string
+		amount   Money
+		expected Money
+	}{
+		{name: "percent with flat part", currency: DefaultCurrency, amount: NewMoney(1000), expected: NewMoney(16)},
+		{name: "rounds half up", currency: DefaultCurrency, amount: 100100, expected: 1602},
+		{name: "clamped to min", currency: DefaultCurrency, amount: NewMoney(10), expected: NewMoney(2)},
+		{name: "clamped to max", currency: DefaultCurrency, amount: NewMoney(100000), expected: NewMoney(50)},
+		{name: "currency without fee", currency: "karma", amount: NewMoney(1000), expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, rule.Calculate(tt.currency, tt.amount))
+		})
+	}
+}
+
+func TestFeeRule_Calculate_LargestAmount(t *testing.T) {
+	largest, err := ParseMoney("9999999999999999.99")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		basisPoints int64
+		expected    string
+	}{
+		{name: "one percent", basisPoints: 100, expected: "100000000000000.00"},
+		{name: "max basis points", basisPoints: MaxFeeBasisPoints, expected: "9999999999999999.99"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := FeeRule{BasisPoints: tt.basisPoints, Currencies: []string{DefaultCurrency}}
+
+			fee := rule.Calculate(DefaultCurrency, largest)
+			assert.Equal(t, tt.expected, fee.String())
+			// перевод вместе с комиссией тоже не переполняется
+			assert.Positive(t, int64(largest+fee))
+		})
+	}
+}
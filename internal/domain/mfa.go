@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// MFA - второй фактор пользователя (TOTP). Пока ConfirmedAt не задан, подключение не завершено
+// и при входе код не запрашивается. LastStep - последний принятый шаг TOTP
+type MFA struct {
+	UserID      int
+	Secret      string
+	ConfirmedAt *time.Time
+	LastStep    int64
+}
+
+func (m MFA) Enabled() bool {
+	return m.ConfirmedAt != nil
+}
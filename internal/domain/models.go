@@ -1,16 +1,46 @@
 package domain
 
+import "time"
+
+// DefaultCurrency - валюта магазина, в которой работает API без явного указания валюты
+const DefaultCurrency = "coin"
+
+const (
+	RoleUser    = "user"
+	RoleAdmin   = "admin"
+	RoleAuditor = "auditor"
+	RoleSystem  = "system"
+)
+
+const (
+	OperationWelcome = "welcome"
+	OperationMint    = "mint"
+	OperationBurn    = "burn"
+	OperationExpire  = "expire"
+)
+
+// User - пользователь. TokenVersion растет при смене пароля, токены с меньшей версией недействительны.
+// MFAEnabled - после пароля при входе нужен код второго фактора
 type User struct {
 	ID           int
 	Username     string
 	PasswordHash string
-	Coins        int
+	Coins        Money
+	Role         string
+	TokenVersion int
+	MFAEnabled   bool
 }
 
 type Item struct {
-	ID    int
-	Name  string
-	Price int
+	ID       int
+	Name     string
+	Price    Money
+	Currency string
+}
+
+type Wallet struct {
+	Currency string
+	Balance  Money
 }
 
 type InventoryItem struct {
@@ -23,5 +53,16 @@ type InventoryItem struct {
 type TransferWithUsernames struct {
 	FromUser string
 	ToUser   string
-	Amount   int
+	Amount   Money
+}
+
+// CoinOperation - начисление или списание монет, не связанное с переводом между пользователями.
+// ExpiresAt задает срок жизни начисленных монет, nil - бессрочно
+type CoinOperation struct {
+	Type      string
+	Currency  string
+	Amount    Money
+	Reason    string
+	ActorID   int
+	ExpiresAt *time.Time
 }
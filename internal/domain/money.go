@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MoneyScale - количество знаков после запятой в денежных суммах
+const MoneyScale = 2
+
+// MinorUnits - количество минимальных единиц в одной монете
+const MinorUnits = 100
+
+// maxMoneyDigits ограничивает целую часть суммы так, чтобы она помещалась в int64 вместе с дробной
+const maxMoneyDigits = 16
+
+var ErrInvalidMoney = errors.New("invalid money amount")
+
+// Money - денежная сумма в минимальных единицах (сотых долях монеты).
+// В JSON представляется десятичной строкой, например "19.99"
+type Money int64
+
+// NewMoney возвращает сумму из целого количества монет
+func NewMoney(coins int64) Money {
+	return Money(coins * MinorUnits)
+}
+
+// ParseMoney разбирает десятичную запись суммы ("20", "19.9", "-0.50") без использования чисел с плавающей точкой
+func ParseMoney(s string) (Money, error) {
+	negative := strings.HasPrefix(s, "-")
+	if negative {
+		s = s[1:]
+	}
+
+	whole, fraction, hasFraction := strings.Cut(s, ".")
+	if whole == "" || len(whole) > maxMoneyDigits || !isDigits(whole) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+	if hasFraction && (fraction == "" || len(fraction) > MoneyScale || !isDigits(fraction)) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+
+	fraction += strings.Repeat("0", MoneyScale-len(fraction))
+
+	units, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+
+	if negative {
+		units = -units
+	}
+
+	return Money(units), nil
+}
+
+func (m Money) String() string {
+	sign := ""
+	units := int64(m)
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+
+	return fmt.Sprintf("%s%d.%0*d", sign, units/MinorUnits, MoneyScale, units%MinorUnits)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(m.String())), nil
+}
+
+// UnmarshalJSON принимает как строку "19.99", так и число 19.99 - число разбирается по его
+// текстовой записи, поэтому ошибок округления не возникает
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := bytes.Trim(data, `"`)
+	if len(text) != len(data) && len(data)-len(text) != 2 {
+		return fmt.Errorf("%w: %s", ErrInvalidMoney, data)
+	}
+
+	return m.UnmarshalText(text)
+}
+
+func (m *Money) UnmarshalText(text []byte) error {
+	parsed, err := ParseMoney(string(text))
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
+
+// UnmarshalParam позволяет принимать суммы в query-параметрах запроса
+func (m *Money) UnmarshalParam(param string) error {
+	return m.UnmarshalText([]byte(param))
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
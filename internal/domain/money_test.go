@@ -0,0 +1,70 @@
+//go:build unit
+// +build unit
+
+package domain
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  Money
+		expectErr bool
+	}{
+		{input: "20", expected: 2000},
+		{input: "19.99", expected: 1999},
+		{input: "19.9", expected: 1990},
+		{input: "0.01", expected: 1},
+		{input: "-0.50", expected: -50},
+		{input: "19.999", expectErr: true},
+		{input: "19.", expectErr: true},
+		{input: ".5", expectErr: true},
+		{input: "1e3", expectErr: true},
+		{input: "", expectErr: true},
+		{input: "12345678901234567", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			money, err := ParseMoney(tt.input)
+			if tt.expectErr {
+				assert.ErrorIs(t, err, ErrInvalidMoney)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, money)
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	assert.Equal(t, "19.99", Money(1999).String())
+	assert.Equal(t, "0.05", Money(5).String())
+	assert.Equal(t, "-1.50", Money(-150).String())
+	assert.Equal(t, "1000.00", NewMoney(1000).String())
+}
+
+func TestMoney_JSON(t *testing.T) {
+	var payload struct {
+		Amount Money `json:"amount"`
+	}
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount":"19.99"}`), &payload))
+	assert.Equal(t, Money(1999), payload.Amount)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount":100}`), &payload))
+	assert.Equal(t, NewMoney(100), payload.Amount)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount":0.1}`), &payload))
+	assert.Equal(t, Money(10), payload.Amount)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"amount":"abc"}`), &payload))
+
+	data, err := json.Marshal(payload)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"0.10"}`, string(data))
+}
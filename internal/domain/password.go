@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PasswordPolicy - требования к паролю при регистрации, смене и сбросе. Нулевые значения
+// не ограничивают пароль. ResetTTL - сколько действует токен сброса пароля
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireLetter bool
+	RequireDigit  bool
+	ResetTTL      time.Duration
+}
+
+// Allows проверяет пароль на соответствие политике. Длина считается в символах, а не в байтах
+func (p PasswordPolicy) Allows(password string) bool {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength || (p.MaxLength > 0 && length > p.MaxLength) {
+		return false
+	}
+
+	var hasLetter, hasDigit bool
+	for _, ch := range password {
+		hasLetter = hasLetter || unicode.IsLetter(ch)
+		hasDigit = hasDigit || unicode.IsDigit(ch)
+	}
+
+	return (!p.RequireLetter || hasLetter) && (!p.RequireDigit || hasDigit)
+}
+
+// PasswordResetToken - одноразовый токен сброса пароля, выданный администратором. В бд хранится только хеш
+type PasswordResetToken struct {
+	ID        int
+	UserID    int
+	Username  string
+	Hash      string
+	CreatedBy int
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
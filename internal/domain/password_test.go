@@ -0,0 +1,34 @@
+//go:build unit
+// +build unit
+
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPasswordPolicy_Allows(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 8, MaxLength: 12, RequireLetter: true, RequireDigit: true}
+
+	tests := []struct {
+		name     string
+		policy   PasswordPolicy
+		password string
+		expected bool
+	}{
+		{name: "no policy", policy: PasswordPolicy{}, password: "1", expected: true},
+		{name: "valid", policy: policy, password: "secret42", expected: true},
+		{name: "too short", policy: policy, password: "abc1234", expected: false},
+		{name: "too long", policy: policy, password: "secret4242424", expected: false},
+		{name: "length in runes", policy: policy, password: "пароль12", expected: true},
+		{name: "no digit", policy: policy, password: "secretpass", expected: false},
+		{name: "no letter", policy: policy, password: "12345678", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.policy.Allows(tt.password))
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package domain
+
+import "time"
+
+// Статусы доставки вебхука. dead - попытки исчерпаны, доставка больше не повторяется
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	WebhookDead      = "dead"
+)
+
+type WebhookSubscription struct {
+	ID         int
+	URL        string
+	Secret     string
+	EventTypes []string
+	CreatedBy  int
+	CreatedAt  time.Time
+}
+
+// WebhookDelivery - событие, поставленное в очередь на отправку одному подписчику
+type WebhookDelivery struct {
+	ID             int64
+	SubscriptionID int
+	URL            string
+	Secret         string
+	EventType      string
+	Payload        []byte
+	Attempts       int
+}
+
+// WebhookAttempt - результат одной попытки доставки. StatusCode равен 0, если ответа не было
+type WebhookAttempt struct {
+	DeliveryID int64
+	Attempt    int
+	StatusCode int
+	Error      string
+	Duration   time.Duration
+}
+
+// RetryPolicy задает экспоненциальную задержку между попытками доставки
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Delay возвращает задержку перед попыткой, следующей за attempt-й неудачной
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+
+	return delay
+}
+
+// Exhausted сообщает, что после attempt-й неудачи доставку пора отправить в dead
+func (p RetryPolicy) Exhausted(attempt int) bool {
+	return attempt >= p.MaxAttempts
+}
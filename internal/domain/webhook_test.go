@@ -0,0 +1,32 @@
+//go:build unit
+// +build unit
+
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: 10 * time.Second, MaxDelay: time.Minute}
+
+	tests := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{attempt: 1, expected: 10 * time.Second},
+		{attempt: 2, expected: 20 * time.Second},
+		{attempt: 3, expected: 40 * time.Second},
+		{attempt: 4, expected: time.Minute},
+		{attempt: 40, expected: time.Minute},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, policy.Delay(tt.attempt), "attempt %d", tt.attempt)
+	}
+
+	assert.False(t, policy.Exhausted(4))
+	assert.True(t, policy.Exhausted(5))
+}
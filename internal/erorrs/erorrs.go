@@ -7,6 +7,7 @@ import (
 var (
 	ErrNotFound      = errors.New("user not found")
 	ErrUserExist     = errors.New("user already exist")
+	ErrReservedName  = errors.New("username is reserved")
 	ErrSigningMethod = errors.New("invalid signing method")
 	ErrItemNotFound  = errors.New("items not found")
 )
@@ -15,3 +16,43 @@ var (
 	ErrSelfTransfer      = errors.New("нельзя переводить самому себе")
 	ErrInsufficientFunds = errors.New("недостаточно средств")
 )
+
+var (
+	ErrGroupNotFound    = errors.New("group not found")
+	ErrCurrencyNotFound = errors.New("currency not found")
+	ErrForbidden        = errors.New("access denied")
+	ErrInvalidTarget    = errors.New("укажите либо пользователя, либо группу")
+	ErrNotGroupMember   = errors.New("user is not a group member")
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook subscription not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrUnknownEventType = errors.New("unknown event type")
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrAPIKeyInvalid  = errors.New("invalid api key")
+	ErrUnknownScope   = errors.New("unknown scope")
+)
+
+var (
+	ErrWeakPassword      = errors.New("password does not satisfy the policy")
+	ErrWrongPassword     = errors.New("wrong current password")
+	ErrResetTokenInvalid = errors.New("invalid or expired password reset token")
+	ErrSessionRevoked    = errors.New("session revoked")
+)
+
+var (
+	ErrMFARequired         = errors.New("second factor required")
+	ErrMFAChallengeInvalid = errors.New("invalid or expired mfa challenge")
+	ErrMFACodeInvalid      = errors.New("invalid mfa code")
+	ErrMFAAlreadyEnabled   = errors.New("mfa already enabled")
+	ErrMFANotEnrolled      = errors.New("mfa enrollment not started")
+)
+
+var (
+	ErrTooManyAttempts = errors.New("too many attempts")
+	ErrRateLimited     = errors.New("rate limit exceeded")
+)
@@ -0,0 +1,166 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Типы событий, доставляемых пользователю
+const (
+	TypeCoinsReceived     = "coins.received"
+	TypePurchaseCompleted = "purchase.completed"
+	TypeBalanceChanged    = "balance.changed"
+	TypeUserRegistered    = "user.registered"
+)
+
+// Event - событие для конкретного пользователя. Data содержит полезную нагрузку в JSON.
+// ID - номер записи в outbox. Релей публикует события по возрастанию ID, но после сбоя
+// событие может прийти повторно, получатели могут отбрасывать повторы по ID
+type Event struct {
+	ID         int64           `json:"id"`
+	Type       string          `json:"type"`
+	UserID     int             `json:"userId"`
+	Data       json.RawMessage `json:"data"`
+	OccurredAt time.Time       `json:"occurredAt"`
+}
+
+// New собирает событие, сериализуя payload в JSON
+func New(eventType string, userID int, payload any) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("events.New: failed to marshal payload: %w", err)
+	}
+
+	return Event{Type: eventType, UserID: userID, Data: data}, nil
+}
+
+// Types - все типы событий, на которые можно подписаться
+var Types = []string{TypeCoinsReceived, TypePurchaseCompleted, TypeBalanceChanged, TypeUserRegistered}
+
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// Publishers передает событие каждому получателю по очереди. Ошибка одного
+// получателя не мешает доставке остальным. Релей outbox обходит публикаторов сам,
+// чтобы при повторе не отправлять событие тем, кто его уже принял
+type Publishers []Publisher
+
+func (p Publishers) Publish(ctx context.Context, event Event) error {
+	var errs []error
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Subscription - подписка на события одного пользователя
+type Subscription struct {
+	C <-chan Event
+
+	ch    chan Event
+	close func()
+	once  sync.Once
+}
+
+// Close отписывается от событий. Повторный вызов безопасен
+func (s *Subscription) Close() {
+	s.once.Do(s.close)
+}
+
+// Bus - шина событий внутри процесса. Медленный подписчик не блокирует публикацию:
+// если его буфер заполнен, событие для него отбрасывается
+type Bus struct {
+	mu     sync.RWMutex
+	subs   map[int]map[*Subscription]struct{}
+	buffer int
+	closed bool
+
+	onDrop func(Event)
+}
+
+func NewBus(buffer int, onDrop func(Event)) *Bus {
+	if onDrop == nil {
+		onDrop = func(Event) {}
+	}
+
+	return &Bus{
+		subs:   make(map[int]map[*Subscription]struct{}),
+		buffer: buffer,
+		onDrop: onDrop,
+	}
+}
+
+// Subscribe подписывает на события пользователя userID
+func (b *Bus) Subscribe(userID int) *Subscription {
+	ch := make(chan Event, b.buffer)
+	sub := &Subscription{C: ch, ch: ch}
+	sub.close = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		// после Close шины канал уже закрыт
+		if _, ok := b.subs[userID][sub]; !ok {
+			return
+		}
+
+		delete(b.subs[userID], sub)
+		if len(b.subs[userID]) == 0 {
+			delete(b.subs, userID)
+		}
+		close(ch)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(ch)
+		return sub
+	}
+
+	if b.subs[userID] == nil {
+		b.subs[userID] = make(map[*Subscription]struct{})
+	}
+	b.subs[userID][sub] = struct{}{}
+
+	return sub
+}
+
+// Publish доставляет событие подписчикам этого процесса
+func (b *Bus) Publish(_ context.Context, event Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subs[event.UserID] {
+		select {
+		case sub.ch <- event:
+		default:
+			b.onDrop(event)
+		}
+	}
+
+	return nil
+}
+
+// Close закрывает все подписки, чтобы открытые потоки завершились до остановки сервера
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, subs := range b.subs {
+		for sub := range subs {
+			close(sub.ch)
+		}
+	}
+
+	b.subs = make(map[int]map[*Subscription]struct{})
+	b.closed = true
+}
@@ -0,0 +1,110 @@
+//go:build unit
+// +build unit
+
+package events
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBus_PublishToSubscriber(t *testing.T) {
+	bus := NewBus(1, nil)
+
+	first := bus.Subscribe(1)
+	defer first.Close()
+	second := bus.Subscribe(1)
+	defer second.Close()
+	other := bus.Subscribe(2)
+	defer other.Close()
+
+	event, err := New(TypeBalanceChanged, 1, map[string]string{"currency": "coin"})
+	require.NoError(t, err)
+	require.NoError(t, bus.Publish(context.Background(), event))
+
+	assert.Equal(t, event, <-first.C)
+	assert.Equal(t, event, <-second.C)
+	assert.Empty(t, other.C)
+}
+
+func TestBus_DropsWhenBufferFull(t *testing.T) {
+	var dropped []string
+	bus := NewBus(1, func(ev Event) {
+		dropped = append(dropped, ev.Type)
+	})
+
+	sub := bus.Subscribe(1)
+	defer sub.Close()
+
+	_ = bus.Publish(context.Background(), Event{Type: TypeCoinsReceived, UserID: 1})
+	_ = bus.Publish(context.Background(), Event{Type: TypeBalanceChanged, UserID: 1})
+
+	assert.Equal(t, TypeCoinsReceived, (<-sub.C).Type)
+	assert.Equal(t, []string{TypeBalanceChanged}, dropped)
+}
+
+func TestBus_Close(t *testing.T) {
+	bus := NewBus(1, nil)
+	sub := bus.Subscribe(1)
+
+	bus.Close()
+
+	_, ok := <-sub.C
+	assert.False(t, ok)
+
+	// повторное закрытие подписки после закрытия шины не паникует
+	sub.Close()
+
+	late := bus.Subscribe(1)
+	_, ok = <-late.C
+	assert.False(t, ok)
+}
+
+func TestPGBroker_Dispatch(t *testing.T) {
+	bus := NewBus(1, nil)
+	broker := &PGBroker{bus: bus}
+
+	sub := bus.Subscribe(7)
+	defer sub.Close()
+
+	broker.dispatch(context.Background(), `{"type":"coins.received","userId":7,"data":{"amount":"1.00"}}`)
+
+	event := <-sub.C
+	assert.Equal(t, TypeCoinsReceived, event.Type)
+	assert.JSONEq(t, `{"amount":"1.00"}`, string(event.Data))
+}
+
+func TestKafkaPublisher(t *testing.T) {
+	producer := NewMemoryProducer()
+	publisher := NewKafkaPublisher(producer, "shop-events")
+
+	event := Event{ID: 12, Type: TypeCoinsReceived, UserID: 3, Data: []byte(`{"amount":"1.00"}`)}
+	require.NoError(t, publisher.Publish(context.Background(), event))
+
+	messages := producer.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "shop-events", messages[0].Topic)
+	assert.Equal(t, []byte("3"), messages[0].Key)
+	assert.Equal(t, "12", messages[0].Headers["event-id"])
+	assert.Contains(t, string(messages[0].Value), `"data":{"amount":"1.00"}`)
+}
+
+func TestPublishers_ContinueAfterError(t *testing.T) {
+	producer := NewMemoryProducer()
+	failing := NewKafkaPublisher(producerFunc(func(context.Context, Message) error {
+		return assert.AnError
+	}), "shop-events")
+
+	err := Publishers{failing, NewKafkaPublisher(producer, "shop-events")}.Publish(context.Background(), Event{Type: TypeBalanceChanged})
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Len(t, producer.Messages(), 1)
+}
+
+type producerFunc func(ctx context.Context, msg Message) error
+
+func (f producerFunc) Produce(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
@@ -0,0 +1,94 @@
+package events
+
+import (
+	"avito-shop/internal/logger"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"time"
+)
+
+// Channel - канал LISTEN/NOTIFY, через который реплики обмениваются событиями
+const Channel = "shop_events"
+
+// pingInterval - как часто проверять соединение слушателя, если уведомлений нет
+const pingInterval = 90 * time.Second
+
+// PGBroker рассылает события всем репликам через Postgres NOTIFY. Каждая реплика
+// слушает канал и передает полученные события в свою локальную шину
+type PGBroker struct {
+	db       *sql.DB
+	bus      *Bus
+	listener *pq.Listener
+	logger   logger.Logger
+}
+
+func NewPGBroker(db *sql.DB, connString string, bus *Bus, logger logger.Logger) *PGBroker {
+	listener := pq.NewListener(connString, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			logger.Error("events.PGBroker: listener error", zap.Error(err))
+		}
+	})
+
+	return &PGBroker{
+		db:       db,
+		bus:      bus,
+		listener: listener,
+		logger:   logger,
+	}
+}
+
+// Publish отправляет событие в канал. Вызывать после фиксации транзакции:
+// NOTIFY вне транзакции доставляется сразу
+func (b *PGBroker) Publish(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("events.PGBroker.Publish: failed to marshal event: %w", err)
+	}
+
+	if _, err := b.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, Channel, string(payload)); err != nil {
+		return fmt.Errorf("events.PGBroker.Publish: failed to notify: %w", err)
+	}
+
+	return nil
+}
+
+// Run слушает канал и передает события в локальную шину до отмены ctx
+func (b *PGBroker) Run(ctx context.Context) error {
+	if err := b.listener.Listen(Channel); err != nil {
+		return fmt.Errorf("events.PGBroker.Run: failed to listen: %w", err)
+	}
+	defer b.listener.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.logger.Info("Events listener stopped")
+			return nil
+		case n := <-b.listener.Notify:
+			// nil приходит после переподключения: уведомления за время обрыва потеряны
+			if n == nil {
+				b.logger.Info("Events listener reconnected")
+				continue
+			}
+			b.dispatch(ctx, n.Extra)
+		case <-time.After(pingInterval):
+			if err := b.listener.Ping(); err != nil {
+				b.logger.Error("events.PGBroker.Run: ping failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (b *PGBroker) dispatch(ctx context.Context, payload string) {
+	var event Event
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		b.logger.Error("events.PGBroker.dispatch: invalid payload", zap.Error(err))
+		return
+	}
+
+	_ = b.bus.Publish(ctx, event)
+}
@@ -0,0 +1,105 @@
+package events
+
+import (
+	"avito-shop/internal/logger"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap"
+	"strconv"
+	"sync"
+)
+
+// LogPublisher пишет события в лог. Удобен для отладки и как журнал опубликованного
+type LogPublisher struct {
+	logger logger.Logger
+}
+
+func NewLogPublisher(logger logger.Logger) *LogPublisher {
+	return &LogPublisher{logger: logger}
+}
+
+func (p *LogPublisher) Publish(_ context.Context, event Event) error {
+	p.logger.Info("event published",
+		zap.Int64("eventID", event.ID),
+		zap.String("type", event.Type),
+		zap.Int("userID", event.UserID),
+		zap.ByteString("data", event.Data),
+	)
+	return nil
+}
+
+// Message - сообщение в формате брокера вида Kafka: топик, ключ партиционирования и значение
+type Message struct {
+	Topic   string
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
+}
+
+// Producer - минимальный интерфейс продюсера Kafka-совместимого брокера
+type Producer interface {
+	Produce(ctx context.Context, msg Message) error
+}
+
+// KafkaPublisher отправляет события в топик. Ключом служит пользователь, поэтому события
+// одного пользователя попадают в одну партицию и читаются по порядку
+type KafkaPublisher struct {
+	producer Producer
+	topic    string
+}
+
+func NewKafkaPublisher(producer Producer, topic string) *KafkaPublisher {
+	return &KafkaPublisher{
+		producer: producer,
+		topic:    topic,
+	}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, event Event) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("events.KafkaPublisher.Publish: failed to marshal event: %w", err)
+	}
+
+	err = p.producer.Produce(ctx, Message{
+		Topic: p.topic,
+		Key:   []byte(strconv.Itoa(event.UserID)),
+		Value: value,
+		Headers: map[string]string{
+			"event-type": event.Type,
+			"event-id":   strconv.FormatInt(event.ID, 10),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("events.KafkaPublisher.Publish: failed to produce: %w", err)
+	}
+
+	return nil
+}
+
+// MemoryProducer хранит сообщения в памяти. Заменяет брокер в тестах и локальной разработке
+type MemoryProducer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryProducer() *MemoryProducer {
+	return &MemoryProducer{}
+}
+
+func (p *MemoryProducer) Produce(_ context.Context, msg Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.messages = append(p.messages, msg)
+	return nil
+}
+
+// Messages возвращает копию отправленных сообщений
+func (p *MemoryProducer) Messages() []Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]Message(nil), p.messages...)
+}
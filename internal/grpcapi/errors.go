@@ -0,0 +1,47 @@
+package grpcapi
+
+import (
+	"avito-shop/internal/erorrs"
+	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusCatalog сопоставляет ошибки сервисов с кодами gRPC. Проверяется по порядку через errors.Is
+var statusCatalog = []struct {
+	err  error
+	code codes.Code
+}{
+	{erorrs.ErrNotFound, codes.NotFound},
+	{erorrs.ErrItemNotFound, codes.NotFound},
+	{erorrs.ErrGroupNotFound, codes.NotFound},
+	{erorrs.ErrNotGroupMember, codes.NotFound},
+	{erorrs.ErrCurrencyNotFound, codes.InvalidArgument},
+	{erorrs.ErrSelfTransfer, codes.InvalidArgument},
+	{erorrs.ErrInvalidTarget, codes.InvalidArgument},
+	{erorrs.ErrWeakPassword, codes.InvalidArgument},
+	{erorrs.ErrReservedName, codes.InvalidArgument},
+	{erorrs.ErrInsufficientFunds, codes.FailedPrecondition},
+	{erorrs.ErrForbidden, codes.PermissionDenied},
+	{erorrs.ErrTooManyAttempts, codes.ResourceExhausted},
+	{erorrs.ErrRateLimited, codes.ResourceExhausted},
+}
+
+// errMFAUnsupported объясняет клиенту, где пройти второй шаг входа: в gRPC API его нет,
+// и токен входа через gRPC не отдается
+const errMFAUnsupported = "two-factor authentication is not supported over gRPC, sign in via POST /api/auth and POST /api/auth/mfa"
+
+// toStatus возвращает gRPC-статус для ошибки сервиса. Текст неизвестных ошибок клиенту не отдается
+func toStatus(err error) error {
+	if errors.Is(err, erorrs.ErrMFARequired) {
+		return status.Error(codes.FailedPrecondition, errMFAUnsupported)
+	}
+
+	for _, entry := range statusCatalog {
+		if errors.Is(err, entry.err) {
+			return status.Error(entry.code, entry.err.Error())
+		}
+	}
+
+	return status.Error(codes.Internal, "internal error")
+}
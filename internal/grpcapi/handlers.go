@@ -0,0 +1,198 @@
+package grpcapi
+
+import (
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	"avito-shop/internal/grpcapi/shopv1"
+	"avito-shop/internal/logger"
+	"avito-shop/internal/model"
+	"avito-shop/internal/ratelimit"
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strconv"
+	"time"
+)
+
+type authServer struct {
+	shopv1.UnimplementedAuthServiceServer
+	auth   ServiceAuthInterface
+	logger logger.Logger
+}
+
+func (s *authServer) Authorize(ctx context.Context, req *shopv1.AuthorizeRequest) (*shopv1.AuthorizeResponse, error) {
+	if req.GetUsername() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "username and password are required")
+	}
+
+	token, err := s.auth.Authorization(ctx, model.AuthRequestDTO{
+		Username: req.GetUsername(),
+		Password: req.GetPassword(),
+	})
+	if err != nil {
+		// как и в HTTP API: ErrUserExist означает, что пароль не подошел к существующему имени
+		if errors.Is(err, erorrs.ErrNotFound) || errors.Is(err, erorrs.ErrUserExist) {
+			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+		}
+		var retryErr *ratelimit.RetryAfterError
+		if errors.As(err, &retryErr) {
+			_ = grpc.SetHeader(ctx, metadata.Pairs(retryAfterMetadata, strconv.Itoa(retryErr.RetryAfterSeconds())))
+			return nil, toStatus(err)
+		}
+		logger.FromContext(ctx, s.logger).Error("grpc.Auth.Authorize: authorization error", zap.Error(err))
+		return nil, toStatus(err)
+	}
+
+	return &shopv1.AuthorizeResponse{Token: token}, nil
+}
+
+type userServer struct {
+	shopv1.UnimplementedUserServiceServer
+	user   ServiceUserInterface
+	logger logger.Logger
+}
+
+func (s *userServer) GetInfo(ctx context.Context, _ *shopv1.GetInfoRequest) (*shopv1.GetInfoResponse, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.user.GetUserInfo(ctx, userID)
+	if err != nil && !errors.Is(err, erorrs.ErrItemNotFound) {
+		logger.FromContext(ctx, s.logger).Error("grpc.User.GetInfo: error getting user info", zap.Error(err))
+		return nil, toStatus(err)
+	}
+
+	return infoToProto(info), nil
+}
+
+func (s *userServer) SendCoin(ctx context.Context, req *shopv1.SendCoinRequest) (*shopv1.SendCoinResponse, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := parseAmount(req.GetAmount())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetToUser() == "" {
+		return nil, status.Error(codes.InvalidArgument, "to_user is required")
+	}
+
+	if err := s.user.SendCoinToUser(ctx, userID, req.GetToUser(), amount, req.GetCurrency()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &shopv1.SendCoinResponse{}, nil
+}
+
+func (s *userServer) QuoteTransfer(ctx context.Context, req *shopv1.QuoteTransferRequest) (*shopv1.QuoteTransferResponse, error) {
+	if _, err := requireUser(ctx); err != nil {
+		return nil, err
+	}
+
+	amount, err := parseAmount(req.GetAmount())
+	if err != nil {
+		return nil, err
+	}
+
+	quote, err := s.user.QuoteTransfer(ctx, amount, req.GetCurrency())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &shopv1.QuoteTransferResponse{
+		Amount:   quote.Amount.String(),
+		Fee:      quote.Fee.String(),
+		Total:    quote.Total.String(),
+		Currency: quote.Currency,
+	}, nil
+}
+
+func (s *userServer) BuyItem(ctx context.Context, req *shopv1.BuyItemRequest) (*shopv1.BuyItemResponse, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetItem() == "" {
+		return nil, status.Error(codes.InvalidArgument, "item is required")
+	}
+
+	if err := s.user.BuyItem(ctx, userID, model.BuyItemRequestDTO{Item: req.GetItem()}); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &shopv1.BuyItemResponse{}, nil
+}
+
+func requireUser(ctx context.Context) (int, error) {
+	userID, ok := userIDFromContext(ctx)
+	if !ok {
+		return 0, status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+
+	return userID, nil
+}
+
+func parseAmount(value string) (domain.Money, error) {
+	amount, err := domain.ParseMoney(value)
+	if err != nil || amount <= 0 {
+		return 0, status.Error(codes.InvalidArgument, "amount must be a positive decimal, e.g. \"10.50\"")
+	}
+
+	return amount, nil
+}
+
+func infoToProto(info model.InfoResponseDTO) *shopv1.GetInfoResponse {
+	resp := &shopv1.GetInfoResponse{
+		Coins:       info.Coins.String(),
+		CoinHistory: &shopv1.CoinHistory{},
+	}
+
+	for _, item := range info.Inventory {
+		resp.Inventory = append(resp.Inventory, &shopv1.Item{Type: item.Type, Quantity: int32(item.Quantity)})
+	}
+	for _, wallet := range info.Wallets {
+		resp.Wallets = append(resp.Wallets, &shopv1.Wallet{Currency: wallet.Currency, Balance: wallet.Balance.String()})
+	}
+	for _, tx := range info.CoinHistory.Received {
+		resp.CoinHistory.Received = append(resp.CoinHistory.Received, transactionToProto(tx))
+	}
+	for _, tx := range info.CoinHistory.Sent {
+		resp.CoinHistory.Sent = append(resp.CoinHistory.Sent, transactionToProto(tx))
+	}
+	for _, op := range info.CoinHistory.Operations {
+		resp.CoinHistory.Operations = append(resp.CoinHistory.Operations, &shopv1.Operation{
+			Type:     op.Type,
+			Amount:   op.Amount.String(),
+			Currency: op.Currency,
+			Reason:   op.Reason,
+		})
+	}
+	for _, coins := range info.ExpiringSoon {
+		resp.ExpiringSoon = append(resp.ExpiringSoon, &shopv1.ExpiringCoins{
+			Amount:    coins.Amount.String(),
+			Currency:  coins.Currency,
+			ExpiresAt: coins.ExpiresAt.Format(time.RFC3339),
+		})
+	}
+
+	return resp
+}
+
+func transactionToProto(tx model.TransactionHistoryDTO) *shopv1.Transaction {
+	return &shopv1.Transaction{
+		FromUser: tx.FromUser,
+		ToUser:   tx.ToUser,
+		Amount:   tx.Amount.String(),
+		Fee:      tx.Fee.String(),
+		Currency: tx.Currency,
+	}
+}
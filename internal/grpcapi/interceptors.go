@@ -0,0 +1,170 @@
+package grpcapi
+
+import (
+	"avito-shop/internal/audit"
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	"avito-shop/internal/grpcapi/shopv1"
+	"avito-shop/internal/logger"
+	"avito-shop/internal/metrics"
+	"avito-shop/internal/ratelimit"
+	"avito-shop/internal/service"
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"net"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	authMetadata      = "authorization"
+	requestIDMetadata = "x-request-id"
+	userAgentMetadata = "user-agent"
+	// retryAfterMetadata - через сколько секунд можно повторить запрос, отклоненный с ResourceExhausted
+	retryAfterMetadata = "retry-after"
+)
+
+// publicMethods не требуют токена
+var publicMethods = map[string]bool{
+	shopv1.AuthService_Authorize_FullMethodName: true,
+}
+
+// methodScopes - право, которое должен давать токен для вызова метода. Методы, которых нет
+// ни здесь, ни в publicMethods, недоступны никому
+var methodScopes = map[string]string{
+	shopv1.UserService_GetInfo_FullMethodName:       domain.ScopeInfoRead,
+	shopv1.UserService_SendCoin_FullMethodName:      domain.ScopeCoinsSend,
+	shopv1.UserService_QuoteTransfer_FullMethodName: domain.ScopeCoinsSend,
+	shopv1.UserService_BuyItem_FullMethodName:       domain.ScopeShopBuy,
+}
+
+type userIDKey struct{}
+
+// authInterceptor проверяет JWT из метаданных authorization, его версию и права токена на метод,
+// кладет ID пользователя в контекст
+func authInterceptor(log logger.Logger, auth ServiceAuthInterface) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if publicMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		token, err := bearerToken(ctx)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		parsed, err := service.ParseToken(token)
+		if err != nil {
+			log.Info("grpc: invalid token", zap.String("method", info.FullMethod), zap.Error(err))
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		userID := parsed.UserID
+
+		if err := auth.CheckSession(ctx, userID, parsed.Version); err != nil {
+			if errors.Is(err, erorrs.ErrSessionRevoked) {
+				log.Info("grpc: revoked token", zap.String("method", info.FullMethod), zap.Int("userID", userID))
+				return nil, status.Error(codes.Unauthenticated, "session revoked")
+			}
+			log.Error("grpc: error checking session", zap.String("method", info.FullMethod), zap.Error(err))
+			return nil, toStatus(err)
+		}
+
+		scope, ok := methodScopes[info.FullMethod]
+		if !ok || !slices.Contains(parsed.Scopes, scope) {
+			log.Info("grpc: missing scope", zap.String("method", info.FullMethod), zap.String("scope", scope))
+			return nil, status.Error(codes.PermissionDenied, "token does not grant "+scope)
+		}
+
+		ctx = context.WithValue(ctx, userIDKey{}, userID)
+		ctx = logger.WithContext(ctx, logger.FromContext(ctx, log).With(zap.Int("userID", userID)))
+
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitInterceptor ограничивает частоту вызовов пользователя к методу тем же ограничителем,
+// что и HTTP API. Должен стоять после authInterceptor, публичные методы не ограничивает
+func rateLimitInterceptor(log logger.Logger, limiter RateLimiterInterface) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		userID, ok := userIDFromContext(ctx)
+		if limiter == nil || !ok {
+			return handler(ctx, req)
+		}
+
+		decision := limiter.Allow(userID, info.FullMethod)
+		if !decision.Allowed {
+			logger.FromContext(ctx, log).Info("grpc: rate limit exceeded", zap.String("method", info.FullMethod))
+			metrics.GRPCRateLimited(info.FullMethod)
+			limitErr := &ratelimit.RateLimitError{RetryAfter: decision.RetryAfter}
+			_ = grpc.SetHeader(ctx, metadata.Pairs(retryAfterMetadata, strconv.Itoa(limitErr.RetryAfterSeconds())))
+			return nil, toStatus(limitErr)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func bearerToken(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md.Get(authMetadata)) == 0 {
+		return "", errors.New("authorization metadata is required")
+	}
+
+	scheme, token, found := strings.Cut(md.Get(authMetadata)[0], " ")
+	if !found || scheme != "Bearer" || token == "" {
+		return "", errors.New("authorization metadata must be \"Bearer <token>\"")
+	}
+
+	return token, nil
+}
+
+func userIDFromContext(ctx context.Context) (int, bool) {
+	userID, ok := ctx.Value(userIDKey{}).(int)
+	return userID, ok
+}
+
+// requestInfoInterceptor кладет в контекст адрес клиента, User-Agent и x-request-id для журнала аудита
+func requestInfoInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var requestInfo audit.RequestInfo
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			requestInfo.IP = p.Addr.String()
+			if host, _, err := net.SplitHostPort(requestInfo.IP); err == nil {
+				requestInfo.IP = host
+			}
+		}
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(userAgentMetadata); len(values) > 0 {
+				requestInfo.UserAgent = values[0]
+			}
+			if values := md.Get(requestIDMetadata); len(values) > 0 {
+				requestInfo.RequestID = values[0]
+			}
+		}
+
+		return handler(audit.WithRequestInfo(ctx, requestInfo), req)
+	}
+}
+
+// recoveryInterceptor превращает панику в обработчике в codes.Internal
+func recoveryInterceptor(log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				logger.FromContext(ctx, log).Error("grpc: panic recovered",
+					zap.String("method", info.FullMethod), zap.Any("panic", rec), zap.ByteString("stack", debug.Stack()))
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
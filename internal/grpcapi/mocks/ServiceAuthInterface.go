@@ -0,0 +1,76 @@
+// Code generated by mockery v2.52.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	model "avito-shop/internal/model"
+)
+
+// ServiceAuthInterface is an autogenerated mock type for the ServiceAuthInterface type
+type ServiceAuthInterface struct {
+	mock.Mock
+}
+
+// Authorization provides a mock function with given fields: ctx, dto
+func (_m *ServiceAuthInterface) Authorization(ctx context.Context, dto model.AuthRequestDTO) (string, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Authorization")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.AuthRequestDTO) (string, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.AuthRequestDTO) string); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.AuthRequestDTO) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CheckSession provides a mock function with given fields: ctx, userID, version
+func (_m *ServiceAuthInterface) CheckSession(ctx context.Context, userID int, version int) error {
+	ret := _m.Called(ctx, userID, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckSession")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
+		r0 = rf(ctx, userID, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewServiceAuthInterface creates a new instance of ServiceAuthInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewServiceAuthInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ServiceAuthInterface {
+	mock := &ServiceAuthInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
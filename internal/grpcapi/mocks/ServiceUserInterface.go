@@ -0,0 +1,123 @@
+// Code generated by mockery v2.52.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	domain "avito-shop/internal/domain"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	model "avito-shop/internal/model"
+)
+
+// ServiceUserInterface is an autogenerated mock type for the ServiceUserInterface type
+type ServiceUserInterface struct {
+	mock.Mock
+}
+
+// BuyItem provides a mock function with given fields: ctx, userID, input
+func (_m *ServiceUserInterface) BuyItem(ctx context.Context, userID int, input model.BuyItemRequestDTO) error {
+	ret := _m.Called(ctx, userID, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BuyItem")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.BuyItemRequestDTO) error); ok {
+		r0 = rf(ctx, userID, input)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetUserInfo provides a mock function with given fields: ctx, userID
+func (_m *ServiceUserInterface) GetUserInfo(ctx context.Context, userID int) (model.InfoResponseDTO, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserInfo")
+	}
+
+	var r0 model.InfoResponseDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (model.InfoResponseDTO, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) model.InfoResponseDTO); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(model.InfoResponseDTO)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QuoteTransfer provides a mock function with given fields: ctx, amount, currency
+func (_m *ServiceUserInterface) QuoteTransfer(ctx context.Context, amount domain.Money, currency string) (model.TransferQuoteDTO, error) {
+	ret := _m.Called(ctx, amount, currency)
+
+	if len(ret) == 0 {
+		panic("no return value specified for QuoteTransfer")
+	}
+
+	var r0 model.TransferQuoteDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Money, string) (model.TransferQuoteDTO, error)); ok {
+		return rf(ctx, amount, currency)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Money, string) model.TransferQuoteDTO); ok {
+		r0 = rf(ctx, amount, currency)
+	} else {
+		r0 = ret.Get(0).(model.TransferQuoteDTO)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Money, string) error); ok {
+		r1 = rf(ctx, amount, currency)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SendCoinToUser provides a mock function with given fields: ctx, fromUserID, toUserName, amount, currency
+func (_m *ServiceUserInterface) SendCoinToUser(ctx context.Context, fromUserID int, toUserName string, amount domain.Money, currency string) error {
+	ret := _m.Called(ctx, fromUserID, toUserName, amount, currency)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SendCoinToUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, domain.Money, string) error); ok {
+		r0 = rf(ctx, fromUserID, toUserName, amount, currency)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewServiceUserInterface creates a new instance of ServiceUserInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewServiceUserInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ServiceUserInterface {
+	mock := &ServiceUserInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
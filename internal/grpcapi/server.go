@@ -0,0 +1,94 @@
+package grpcapi
+
+import (
+	"avito-shop/internal/domain"
+	"avito-shop/internal/grpcapi/shopv1"
+	"avito-shop/internal/logger"
+	"avito-shop/internal/model"
+	"avito-shop/internal/ratelimit"
+	"context"
+	"errors"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"net"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=ServiceAuthInterface
+type ServiceAuthInterface interface {
+	Authorization(ctx context.Context, dto model.AuthRequestDTO) (string, error)
+	CheckSession(ctx context.Context, userID int, version int) error
+}
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=ServiceUserInterface
+type ServiceUserInterface interface {
+	SendCoinToUser(ctx context.Context, fromUserID int, toUserName string, amount domain.Money, currency string) error
+	BuyItem(ctx context.Context, userID int, input model.BuyItemRequestDTO) error
+	GetUserInfo(ctx context.Context, userID int) (model.InfoResponseDTO, error)
+	QuoteTransfer(ctx context.Context, amount domain.Money, currency string) (model.TransferQuoteDTO, error)
+}
+
+// RateLimiterInterface - ограничитель частоты вызовов. Маршрутом для него служит полное имя метода gRPC
+type RateLimiterInterface interface {
+	Allow(userID int, route string) ratelimit.Decision
+}
+
+// Server - gRPC API сервиса для вызовов из других внутренних сервисов
+type Server struct {
+	address string
+	server  *grpc.Server
+}
+
+// NewServer создает сервер. limiter может быть nil - тогда частота вызовов не ограничивается
+func NewServer(address string, logger logger.Logger, auth ServiceAuthInterface, user ServiceUserInterface, limiter RateLimiterInterface) *Server {
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			recoveryInterceptor(logger),
+			requestInfoInterceptor(),
+			authInterceptor(logger, auth),
+			rateLimitInterceptor(logger, limiter),
+		),
+	)
+
+	shopv1.RegisterAuthServiceServer(server, &authServer{auth: auth, logger: logger})
+	shopv1.RegisterUserServiceServer(server, &userServer{user: user, logger: logger})
+
+	return &Server{
+		address: address,
+		server:  server,
+	}
+}
+
+// Start принимает соединения до вызова Stop. После штатной остановки возвращает nil
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(listener)
+}
+
+func (s *Server) Serve(listener net.Listener) error {
+	if err := s.server.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	return nil
+}
+
+// Stop дожидается завершения текущих вызовов, а по истечении дедлайна ctx обрывает их
+func (s *Server) Stop(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,234 @@
+//go:build unit
+// +build unit
+
+package grpcapi
+
+import (
+	"avito-shop/internal/audit"
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	"avito-shop/internal/grpcapi/mocks"
+	"avito-shop/internal/grpcapi/shopv1"
+	mocks2 "avito-shop/internal/logger/mocks"
+	"avito-shop/internal/model"
+	"avito-shop/internal/ratelimit"
+	"avito-shop/internal/service"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+	"time"
+)
+
+func startTestServer(t *testing.T, auth ServiceAuthInterface, user ServiceUserInterface) *grpc.ClientConn {
+	t.Helper()
+
+	return startLimitedTestServer(t, auth, user, nil)
+}
+
+func startLimitedTestServer(t *testing.T, auth ServiceAuthInterface, user ServiceUserInterface, limiter RateLimiterInterface) *grpc.ClientConn {
+	t.Helper()
+
+	mockLogger := mocks2.NewLogger(t)
+	mockLogger.On("Info", mock.Anything, mock.Anything, mock.Anything).Maybe()
+	mockLogger.On("Error", mock.Anything, mock.Anything).Maybe()
+	mockLogger.On("With", mock.Anything).Return(mockLogger).Maybe()
+
+	if auth == nil {
+		mockAuth := mocks.NewServiceAuthInterface(t)
+		mockAuth.On("CheckSession", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		auth = mockAuth
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer("", mockLogger, auth, user, limiter)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = server.Stop(ctx)
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+func withToken(t *testing.T, userID int) context.Context {
+	t.Helper()
+
+	token, err := service.NewAuthService(nil, nil, nil, nil, 0, domain.ExpiryPolicy{}, domain.PasswordPolicy{}).GenerateJwtToken(userID, 0, domain.Scopes)
+	require.NoError(t, err)
+
+	return metadata.AppendToOutgoingContext(context.Background(), authMetadata, "Bearer "+token)
+}
+
+func TestAuthorize(t *testing.T) {
+	mockAuth := mocks.NewServiceAuthInterface(t)
+	mockAuth.On("Authorization", mock.Anything, model.AuthRequestDTO{Username: "user", Password: "pass"}).Return("token", nil)
+	mockAuth.On("Authorization", mock.Anything, model.AuthRequestDTO{Username: "user", Password: "wrong"}).Return("", erorrs.ErrUserExist)
+	mockAuth.On("Authorization", mock.Anything, model.AuthRequestDTO{Username: "mfa", Password: "pass"}).
+		Return("", &service.MFARequiredError{Token: "challenge", ExpiresAt: time.Now().Add(time.Minute)})
+
+	client := shopv1.NewAuthServiceClient(startTestServer(t, mockAuth, nil))
+
+	resp, err := client.Authorize(context.Background(), &shopv1.AuthorizeRequest{Username: "user", Password: "pass"})
+	require.NoError(t, err)
+	assert.Equal(t, "token", resp.GetToken())
+
+	_, err = client.Authorize(context.Background(), &shopv1.AuthorizeRequest{Username: "user", Password: "wrong"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.Authorize(context.Background(), &shopv1.AuthorizeRequest{Username: "mfa", Password: "pass"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.Equal(t, errMFAUnsupported, status.Convert(err).Message())
+}
+
+func TestAuthorize_LockedOut(t *testing.T) {
+	mockAuth := mocks.NewServiceAuthInterface(t)
+	mockAuth.On("Authorization", mock.Anything, model.AuthRequestDTO{Username: "victim", Password: "guess"}).
+		Return("", &ratelimit.RetryAfterError{RetryAfter: 30 * time.Second})
+
+	client := shopv1.NewAuthServiceClient(startTestServer(t, mockAuth, nil))
+
+	var header metadata.MD
+	_, err := client.Authorize(context.Background(), &shopv1.AuthorizeRequest{Username: "victim", Password: "guess"}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"30"}, header.Get(retryAfterMetadata))
+}
+
+func TestAuthorize_PassesRequestInfo(t *testing.T) {
+	mockAuth := mocks.NewServiceAuthInterface(t)
+	mockAuth.On("Authorization", mock.MatchedBy(func(ctx context.Context) bool {
+		info := audit.RequestInfoFromContext(ctx)
+		return info.RequestID == "req-7" && info.IP != "" && info.UserAgent != ""
+	}), mock.Anything).Return("token", nil).Once()
+
+	client := shopv1.NewAuthServiceClient(startTestServer(t, mockAuth, nil))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), requestIDMetadata, "req-7")
+	_, err := client.Authorize(ctx, &shopv1.AuthorizeRequest{Username: "user", Password: "pass"})
+	require.NoError(t, err)
+}
+
+func TestUserService_RequiresToken(t *testing.T) {
+	client := shopv1.NewUserServiceClient(startTestServer(t, nil, mocks.NewServiceUserInterface(t)))
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{name: "no metadata", ctx: context.Background()},
+		{name: "wrong scheme", ctx: metadata.AppendToOutgoingContext(context.Background(), authMetadata, "Basic abc")},
+		{name: "invalid token", ctx: metadata.AppendToOutgoingContext(context.Background(), authMetadata, "Bearer not.a.jwt")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.GetInfo(tt.ctx, &shopv1.GetInfoRequest{})
+			assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		})
+	}
+}
+
+func TestUserService_RequiresScope(t *testing.T) {
+	client := shopv1.NewUserServiceClient(startTestServer(t, nil, mocks.NewServiceUserInterface(t)))
+
+	token, err := service.NewAuthService(nil, nil, nil, nil, 0, domain.ExpiryPolicy{}, domain.PasswordPolicy{}).GenerateJwtToken(1, 0, []string{domain.ScopeInfoRead})
+	require.NoError(t, err)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), authMetadata, "Bearer "+token)
+
+	_, err = client.BuyItem(ctx, &shopv1.BuyItemRequest{Item: "cup"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestUserService_RateLimited(t *testing.T) {
+	mockUser := mocks.NewServiceUserInterface(t)
+	mockUser.On("BuyItem", mock.Anything, 1, model.BuyItemRequestDTO{Item: "cup"}).Return(nil).Twice()
+
+	limiter := ratelimit.NewRouteLimiter(ratelimit.Budget{}, map[string]ratelimit.Budget{
+		shopv1.UserService_BuyItem_FullMethodName: {Rate: 0.5, Burst: 2},
+	})
+	client := shopv1.NewUserServiceClient(startLimitedTestServer(t, nil, mockUser, limiter))
+
+	for range 2 {
+		_, err := client.BuyItem(withToken(t, 1), &shopv1.BuyItemRequest{Item: "cup"})
+		require.NoError(t, err)
+	}
+
+	var header metadata.MD
+	_, err := client.BuyItem(withToken(t, 1), &shopv1.BuyItemRequest{Item: "cup"}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"2"}, header.Get(retryAfterMetadata))
+
+	// бюджет считается на пользователя
+	mockUser.On("BuyItem", mock.Anything, 2, model.BuyItemRequestDTO{Item: "cup"}).Return(nil).Once()
+	_, err = client.BuyItem(withToken(t, 2), &shopv1.BuyItemRequest{Item: "cup"})
+	assert.NoError(t, err)
+}
+
+func TestSendCoin(t *testing.T) {
+	mockUser := mocks.NewServiceUserInterface(t)
+	mockUser.On("SendCoinToUser", mock.Anything, 1, "friend", domain.Money(1050), "").Return(nil)
+	mockUser.On("SendCoinToUser", mock.Anything, 1, "friend", domain.NewMoney(5000), "").Return(erorrs.ErrInsufficientFunds)
+	mockUser.On("SendCoinToUser", mock.Anything, 1, "ghost", domain.NewMoney(1), "").Return(erorrs.ErrNotFound)
+	mockUser.On("SendCoinToUser", mock.Anything, 1, "friend", domain.NewMoney(2), "").Return(assert.AnError)
+
+	client := shopv1.NewUserServiceClient(startTestServer(t, nil, mockUser))
+	ctx := withToken(t, 1)
+
+	tests := []struct {
+		name         string
+		req          *shopv1.SendCoinRequest
+		expectedCode codes.Code
+	}{
+		{name: "success", req: &shopv1.SendCoinRequest{ToUser: "friend", Amount: "10.50"}, expectedCode: codes.OK},
+		{name: "insufficient funds", req: &shopv1.SendCoinRequest{ToUser: "friend", Amount: "5000"}, expectedCode: codes.FailedPrecondition},
+		{name: "unknown user", req: &shopv1.SendCoinRequest{ToUser: "ghost", Amount: "1"}, expectedCode: codes.NotFound},
+		{name: "internal error", req: &shopv1.SendCoinRequest{ToUser: "friend", Amount: "2"}, expectedCode: codes.Internal},
+		{name: "invalid amount", req: &shopv1.SendCoinRequest{ToUser: "friend", Amount: "-1"}, expectedCode: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.SendCoin(ctx, tt.req)
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+		})
+	}
+}
+
+func TestGetInfo(t *testing.T) {
+	mockUser := mocks.NewServiceUserInterface(t)
+	mockUser.On("GetUserInfo", mock.Anything, 7).Return(model.InfoResponseDTO{
+		Coins:     domain.NewMoney(990),
+		Inventory: []model.ItemDTO{{Type: "cup", Quantity: 1}},
+		CoinHistory: model.CoinHistoryDTO{
+			Sent: []model.TransactionHistoryDTO{{ToUser: "friend", Amount: domain.NewMoney(10), Currency: domain.DefaultCurrency}},
+		},
+	}, nil)
+
+	client := shopv1.NewUserServiceClient(startTestServer(t, nil, mockUser))
+
+	resp, err := client.GetInfo(withToken(t, 7), &shopv1.GetInfoRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "990.00", resp.GetCoins())
+	assert.Equal(t, "cup", resp.GetInventory()[0].GetType())
+	assert.Equal(t, "10.00", resp.GetCoinHistory().GetSent()[0].GetAmount())
+}
@@ -0,0 +1,95 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/pressly/goose"
+	"sync/atomic"
+)
+
+var ErrShuttingDown = errors.New("service is shutting down")
+
+// Database проверяет доступность бд
+func Database(db *sql.DB) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	})
+}
+
+// Migrations проверяет, что в бд применены все миграции из каталога dir
+func Migrations(db *sql.DB, dir string) (Checker, error) {
+	migrations, err := goose.CollectMigrations(dir, 0, goose.MaxVersion)
+	if err != nil {
+		return nil, fmt.Errorf("health.Migrations: collect migrations: %w", err)
+	}
+
+	last, err := migrations.Last()
+	if err != nil {
+		return nil, fmt.Errorf("health.Migrations: last migration: %w", err)
+	}
+	expected := last.Version
+
+	return CheckerFunc(func(ctx context.Context) error {
+		current, err := dbVersion(ctx, db)
+		if err != nil {
+			return err
+		}
+
+		if current != expected {
+			return fmt.Errorf("migration version %d, expected %d", current, expected)
+		}
+
+		return nil
+	}), nil
+}
+
+// dbVersion повторяет логику goose.GetDBVersion, но учитывает контекст:
+// версия - последняя примененная миграция, которая не была откачена позже
+func dbVersion(ctx context.Context, db *sql.DB) (int64, error) {
+	rows, err := db.QueryContext(ctx, `SELECT version_id, is_applied FROM goose_db_version ORDER BY id DESC`)
+	if err != nil {
+		return 0, fmt.Errorf("select migration version: %w", err)
+	}
+	defer rows.Close()
+
+	rolledBack := make(map[int64]bool)
+	for rows.Next() {
+		var (
+			version int64
+			applied bool
+		)
+		if err := rows.Scan(&version, &applied); err != nil {
+			return 0, fmt.Errorf("scan migration version: %w", err)
+		}
+
+		if rolledBack[version] {
+			continue
+		}
+		if applied {
+			return version, nil
+		}
+		rolledBack[version] = true
+	}
+
+	return 0, rows.Err()
+}
+
+// ShutdownFlag не дает сервису считаться готовым после начала остановки,
+// чтобы балансировщик перестал направлять на него запросы
+type ShutdownFlag struct {
+	shuttingDown atomic.Bool
+}
+
+func (f *ShutdownFlag) Set() {
+	f.shuttingDown.Store(true)
+}
+
+func (f *ShutdownFlag) Check(_ context.Context) error {
+	if f.shuttingDown.Load() {
+		return ErrShuttingDown
+	}
+
+	return nil
+}
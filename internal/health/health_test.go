@@ -0,0 +1,72 @@
+//go:build unit
+// +build unit
+
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRegistry_Check(t *testing.T) {
+	ok := CheckerFunc(func(ctx context.Context) error { return nil })
+	failing := CheckerFunc(func(ctx context.Context) error { return errors.New("connection refused") })
+	slow := CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	tests := []struct {
+		name           string
+		checks         map[string]Checker
+		expectedStatus Status
+	}{
+		{
+			name:           "all checks pass",
+			checks:         map[string]Checker{"database": ok, "migrations": ok},
+			expectedStatus: StatusOK,
+		},
+		{
+			name:           "one check fails",
+			checks:         map[string]Checker{"database": failing, "migrations": ok},
+			expectedStatus: StatusFail,
+		},
+		{
+			name:           "check exceeds timeout",
+			checks:         map[string]Checker{"database": slow},
+			expectedStatus: StatusFail,
+		},
+		{
+			name:           "shutting down",
+			checks:         map[string]Checker{"shutdown": shuttingDown()},
+			expectedStatus: StatusFail,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry(10 * time.Millisecond)
+			for name, checker := range tt.checks {
+				registry.Register(name, checker)
+			}
+
+			report := registry.Check(context.Background())
+
+			assert.Equal(t, tt.expectedStatus, report.Status)
+			assert.Len(t, report.Checks, len(tt.checks))
+			for _, result := range report.Checks {
+				assert.NotEmpty(t, result.Latency)
+				assert.Equal(t, result.Status == StatusFail, result.Error != "")
+			}
+		})
+	}
+}
+
+func shuttingDown() *ShutdownFlag {
+	flag := &ShutdownFlag{}
+	flag.Set()
+	return flag
+}
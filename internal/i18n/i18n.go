@@ -0,0 +1,60 @@
+package i18n
+
+import (
+	"fmt"
+	"golang.org/x/text/language"
+)
+
+// Localizer выбирает язык ответа по заголовку Accept-Language
+type Localizer struct {
+	matcher   language.Matcher
+	supported []language.Tag
+}
+
+// NewLocalizer создает Localizer, который отвечает на языке defaultLang,
+// если клиент не указал поддерживаемый язык
+func NewLocalizer(defaultLang string) (*Localizer, error) {
+	fallback, err := language.Parse(defaultLang)
+	if err != nil {
+		return nil, fmt.Errorf("i18n: parse default language: %w", err)
+	}
+
+	base, _ := fallback.Base()
+	fallback = language.Make(base.String())
+	if _, ok := catalog[fallback]; !ok {
+		return nil, fmt.Errorf("i18n: unsupported default language %q", defaultLang)
+	}
+
+	// первый тег в списке matcher использует как язык по умолчанию
+	supported := []language.Tag{fallback}
+	for tag := range catalog {
+		if tag != fallback {
+			supported = append(supported, tag)
+		}
+	}
+
+	return &Localizer{
+		matcher:   language.NewMatcher(supported),
+		supported: supported,
+	}, nil
+}
+
+// Language возвращает язык ответа для значения заголовка Accept-Language
+func (l *Localizer) Language(acceptLanguage string) language.Tag {
+	tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	_, index, confidence := l.matcher.Match(tags...)
+	if confidence == language.No {
+		return l.supported[0]
+	}
+
+	return l.supported[index]
+}
+
+// Translate возвращает сообщение key на языке lang. Если перевода нет, возвращается сам ключ
+func (l *Localizer) Translate(lang language.Tag, key Key) string {
+	if msg, ok := catalog[lang][key]; ok {
+		return msg
+	}
+
+	return string(key)
+}
@@ -0,0 +1,53 @@
+//go:build unit
+// +build unit
+
+package i18n
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+	"testing"
+)
+
+func TestLocalizer_Language(t *testing.T) {
+	localizer, err := NewLocalizer("ru")
+	require.NoError(t, err)
+
+	tests := []struct {
+		acceptLanguage string
+		expected       language.Tag
+	}{
+		{acceptLanguage: "", expected: language.Russian},
+		{acceptLanguage: "en", expected: language.English},
+		{acceptLanguage: "en-GB,en;q=0.9", expected: language.English},
+		{acceptLanguage: "de-DE,en;q=0.5", expected: language.English},
+		{acceptLanguage: "fr", expected: language.Russian},
+		{acceptLanguage: "ru-RU,ru;q=0.9,en;q=0.8", expected: language.Russian},
+		{acceptLanguage: "garbage;;q=", expected: language.Russian},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			assert.Equal(t, tt.expected, localizer.Language(tt.acceptLanguage))
+		})
+	}
+}
+
+func TestLocalizer_DefaultLanguage(t *testing.T) {
+	localizer, err := NewLocalizer("en-US")
+	require.NoError(t, err)
+	assert.Equal(t, language.English, localizer.Language("fr"))
+
+	_, err = NewLocalizer("de")
+	assert.Error(t, err)
+}
+
+func TestCatalog_Complete(t *testing.T) {
+	for key := range catalog[language.Russian] {
+		for lang, messages := range catalog {
+			assert.NotEmpty(t, messages[key], "missing %s translation for %s", lang, key)
+		}
+	}
+	assert.Equal(t, len(catalog[language.Russian]), len(catalog[language.English]))
+}
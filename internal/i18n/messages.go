@@ -0,0 +1,137 @@
+package i18n
+
+import "golang.org/x/text/language"
+
+// Key - идентификатор сообщения для пользователя
+type Key string
+
+const (
+	MsgInvalidInput       Key = "invalid_input"
+	MsgMissingCredentials Key = "missing_credentials"
+	MsgReservedName       Key = "reserved_name"
+	MsgInvalidCredentials Key = "invalid_credentials"
+	MsgUnauthorized       Key = "unauthorized"
+	MsgTokenInvalid       Key = "token_invalid"
+	MsgForbidden          Key = "forbidden"
+	MsgInsufficientScope  Key = "insufficient_scope"
+	MsgUserNotFound       Key = "user_not_found"
+	MsgItemNotFound       Key = "item_not_found"
+	MsgGroupNotFound      Key = "group_not_found"
+	MsgNotGroupMember     Key = "not_group_member"
+	MsgCurrencyNotFound   Key = "currency_not_found"
+	MsgInsufficientFunds  Key = "insufficient_funds"
+	MsgSelfTransfer       Key = "self_transfer"
+	MsgInvalidTarget      Key = "invalid_target"
+	MsgWebhookNotFound    Key = "webhook_not_found"
+	MsgDeliveryNotFound   Key = "delivery_not_found"
+	MsgUnknownEventType   Key = "unknown_event_type"
+	MsgTooManyAttempts    Key = "too_many_attempts"
+	MsgRateLimited        Key = "rate_limited"
+	MsgAPIKeyInvalid      Key = "api_key_invalid"
+	MsgAPIKeyNotFound     Key = "api_key_not_found"
+	MsgUnknownScope       Key = "unknown_scope"
+	MsgWeakPassword       Key = "weak_password"
+	MsgWrongPassword      Key = "wrong_password"
+	MsgResetTokenInvalid  Key = "reset_token_invalid"
+	MsgSessionRevoked     Key = "session_revoked"
+	MsgMFARequired        Key = "mfa_required"
+	MsgMFAChallenge       Key = "mfa_challenge_invalid"
+	MsgMFACodeInvalid     Key = "mfa_code_invalid"
+	MsgMFAAlreadyEnabled  Key = "mfa_already_enabled"
+	MsgMFANotEnrolled     Key = "mfa_not_enrolled"
+	MsgInternal           Key = "internal_error"
+
+	MsgCoinsSent          Key = "coins_sent"
+	MsgItemBought         Key = "item_bought"
+	MsgGroupMemberAdded   Key = "group_member_added"
+	MsgGroupMemberRemoved Key = "group_member_removed"
+	MsgPasswordReset      Key = "password_reset"
+)
+
+// catalog - переводы сообщений. Первый язык в supported используется, если ни один не подошел
+var catalog = map[language.Tag]map[Key]string{
+	language.Russian: {
+		MsgInvalidInput:       "неверные данные для ввода",
+		MsgMissingCredentials: "поля имя и пароль обязательны к заполнению",
+		MsgReservedName:       "это имя пользователя зарезервировано",
+		MsgInvalidCredentials: "неправильные данные для входа",
+		MsgUnauthorized:       "вы не авторизованы",
+		MsgTokenInvalid:       "недействительный токен",
+		MsgForbidden:          "доступ запрещен",
+		MsgInsufficientScope:  "токен не дает права на это действие",
+		MsgUserNotFound:       "пользователь не найден",
+		MsgItemNotFound:       "товар не найден",
+		MsgGroupNotFound:      "группа не найдена",
+		MsgNotGroupMember:     "пользователь не состоит в группе",
+		MsgCurrencyNotFound:   "валюта не найдена",
+		MsgInsufficientFunds:  "недостаточно средств",
+		MsgSelfTransfer:       "нельзя отправить самому себе",
+		MsgInvalidTarget:      "укажите либо пользователя, либо группу",
+		MsgWebhookNotFound:    "подписка на вебхуки не найдена",
+		MsgDeliveryNotFound:   "доставка не найдена или не находится в списке недоставленных",
+		MsgUnknownEventType:   "неизвестный тип события",
+		MsgTooManyAttempts:    "слишком много попыток, повторите позже",
+		MsgRateLimited:        "слишком много запросов, повторите позже",
+		MsgAPIKeyInvalid:      "недействительный ключ API",
+		MsgAPIKeyNotFound:     "ключ API не найден",
+		MsgUnknownScope:       "неизвестное право доступа",
+		MsgWeakPassword:       "пароль не соответствует требованиям безопасности",
+		MsgWrongPassword:      "неверный текущий пароль",
+		MsgResetTokenInvalid:  "ссылка для сброса пароля недействительна или устарела",
+		MsgSessionRevoked:     "сессия завершена, войдите заново",
+		MsgMFARequired:        "введите код подтверждения",
+		MsgMFAChallenge:       "вход не подтвержден вовремя, войдите заново",
+		MsgMFACodeInvalid:     "неверный код подтверждения",
+		MsgMFAAlreadyEnabled:  "двухфакторная аутентификация уже включена",
+		MsgMFANotEnrolled:     "двухфакторная аутентификация не подключена",
+		MsgInternal:           "внутренняя ошибка сервера",
+
+		MsgCoinsSent:          "деньги успешно отправлены",
+		MsgItemBought:         "товар успешно куплен",
+		MsgGroupMemberAdded:   "пользователь добавлен в группу",
+		MsgGroupMemberRemoved: "пользователь удален из группы",
+		MsgPasswordReset:      "пароль изменен",
+	},
+	language.English: {
+		MsgInvalidInput:       "invalid input data",
+		MsgMissingCredentials: "username and password are required",
+		MsgReservedName:       "this username is reserved",
+		MsgInvalidCredentials: "invalid username or password",
+		MsgUnauthorized:       "you are not authorized",
+		MsgTokenInvalid:       "invalid token",
+		MsgForbidden:          "access denied",
+		MsgInsufficientScope:  "the token does not grant this action",
+		MsgUserNotFound:       "user not found",
+		MsgItemNotFound:       "item not found",
+		MsgGroupNotFound:      "group not found",
+		MsgNotGroupMember:     "user is not a member of the group",
+		MsgCurrencyNotFound:   "currency not found",
+		MsgInsufficientFunds:  "insufficient funds",
+		MsgSelfTransfer:       "you cannot send coins to yourself",
+		MsgInvalidTarget:      "specify either a user or a group",
+		MsgWebhookNotFound:    "webhook subscription not found",
+		MsgDeliveryNotFound:   "delivery not found or not dead-lettered",
+		MsgUnknownEventType:   "unknown event type",
+		MsgTooManyAttempts:    "too many attempts, try again later",
+		MsgRateLimited:        "too many requests, try again later",
+		MsgAPIKeyInvalid:      "invalid API key",
+		MsgAPIKeyNotFound:     "API key not found",
+		MsgUnknownScope:       "unknown scope",
+		MsgWeakPassword:       "the password does not meet the security requirements",
+		MsgWrongPassword:      "wrong current password",
+		MsgResetTokenInvalid:  "the password reset token is invalid or expired",
+		MsgSessionRevoked:     "the session has ended, sign in again",
+		MsgMFARequired:        "enter the verification code",
+		MsgMFAChallenge:       "the sign-in was not verified in time, sign in again",
+		MsgMFACodeInvalid:     "wrong verification code",
+		MsgMFAAlreadyEnabled:  "two-factor authentication is already enabled",
+		MsgMFANotEnrolled:     "two-factor authentication is not set up",
+		MsgInternal:           "internal server error",
+
+		MsgCoinsSent:          "coins sent successfully",
+		MsgItemBought:         "item purchased successfully",
+		MsgGroupMemberAdded:   "user added to the group",
+		MsgGroupMemberRemoved: "user removed from the group",
+		MsgPasswordReset:      "password changed",
+	},
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"context"
 	"go.uber.org/zap"
 )
 
@@ -8,6 +9,7 @@ import (
 type Logger interface {
 	Info(msg string, fields ...zap.Field)
 	Error(msg string, fields ...zap.Field)
+	With(fields ...zap.Field) Logger
 }
 
 type LoggerStruct struct {
@@ -26,3 +28,24 @@ func (l *LoggerStruct) Info(msg string, fields ...zap.Field) {
 func (l *LoggerStruct) Error(msg string, fields ...zap.Field) {
 	l.logger.Error(msg, fields...)
 }
+
+// With возвращает дочерний логгер, добавляющий fields к каждой записи
+func (l *LoggerStruct) With(fields ...zap.Field) Logger {
+	return &LoggerStruct{logger: l.logger.With(fields...)}
+}
+
+type ctxKey struct{}
+
+// WithContext сохраняет в контексте логгер запроса
+func WithContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext возвращает логгер запроса, а если его нет - fallback
+func FromContext(ctx context.Context, fallback Logger) Logger {
+	if l, ok := ctx.Value(ctxKey{}).(Logger); ok {
+		return l
+	}
+
+	return fallback
+}
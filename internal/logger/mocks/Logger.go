@@ -3,7 +3,10 @@
 package mocks
 
 import (
+	logger "avito-shop/internal/logger"
+
 	mock "github.com/stretchr/testify/mock"
+
 	zapcore "go.uber.org/zap/zapcore"
 )
 
@@ -36,6 +39,32 @@ func (_m *Logger) Info(msg string, fields ...zapcore.Field) {
 	_m.Called(_ca...)
 }
 
+// With provides a mock function with given fields: fields
+func (_m *Logger) With(fields ...zapcore.Field) logger.Logger {
+	_va := make([]interface{}, len(fields))
+	for _i := range fields {
+		_va[_i] = fields[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for With")
+	}
+
+	var r0 logger.Logger
+	if rf, ok := ret.Get(0).(func(...zapcore.Field) logger.Logger); ok {
+		r0 = rf(fields...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(logger.Logger)
+		}
+	}
+
+	return r0
+}
+
 // NewLogger creates a new instance of Logger. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewLogger(t interface {
@@ -0,0 +1,186 @@
+package metrics
+
+import (
+	"avito-shop/internal/domain"
+	"database/sql"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const namespace = "avito_shop"
+
+// Операции, при которых может не хватить средств
+const (
+	OperationSendCoin = "send_coin"
+	OperationBuyItem  = "buy_item"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	coinsTransferred = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "coins_transferred_total",
+		Help:      "Amount of coins transferred between users.",
+	}, []string{"currency"})
+
+	itemsBought = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "items_bought_total",
+		Help:      "Number of purchased items by item.",
+	}, []string{"item"})
+
+	insufficientFunds = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "insufficient_funds_total",
+		Help:      "Number of operations rejected because of insufficient funds.",
+	}, []string{"operation"})
+
+	panicsRecovered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_panics_recovered_total",
+		Help:      "Number of panics recovered in HTTP handlers by route.",
+	}, []string{"route"})
+
+	registrations = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "registrations_total",
+		Help:      "Number of registered users.",
+	})
+
+	eventsDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_dropped_total",
+		Help:      "Number of user events dropped because the subscriber was too slow.",
+	}, []string{"type"})
+
+	webhookAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_attempts_total",
+		Help:      "Number of webhook delivery attempts by result: delivered, retry or dead.",
+	}, []string{"result"})
+
+	authLockouts = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_lockouts_total",
+		Help:      "Number of times a username or IP was locked out after failed logins.",
+	})
+
+	rateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_rate_limited_total",
+		Help:      "Number of HTTP requests rejected by the per-user rate limiter by method and route.",
+	}, []string{"method", "route"})
+
+	grpcRateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_rate_limited_total",
+		Help:      "Number of gRPC calls rejected by the per-user rate limiter by method.",
+	}, []string{"method"})
+
+	auditWriteFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "audit_write_failures_total",
+		Help:      "Number of audit log entries that could not be written.",
+	}, []string{"action"})
+)
+
+// Результаты попытки доставки вебхука
+const (
+	WebhookDelivered = "delivered"
+	WebhookRetry     = "retry"
+	WebhookDead      = "dead"
+)
+
+// Handler отдает метрики в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware считает запросы и время их обработки. Маршрут берется из шаблона пути,
+// чтобы параметры вроде /buy/:item не порождали отдельные серии
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		status := strconv.Itoa(c.Writer.Status())
+		httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		httpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// RegisterDBStats публикует статистику пула соединений с бд
+func RegisterDBStats(db *sql.DB) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, "postgres"))
+}
+
+func CoinsTransferred(currency string, amount domain.Money) {
+	coinsTransferred.WithLabelValues(currency).Add(float64(amount) / domain.MinorUnits)
+}
+
+func ItemBought(item string) {
+	itemsBought.WithLabelValues(item).Inc()
+}
+
+func InsufficientFunds(operation string) {
+	insufficientFunds.WithLabelValues(operation).Inc()
+}
+
+func PanicRecovered(route string) {
+	if route == "" {
+		route = "unmatched"
+	}
+	panicsRecovered.WithLabelValues(route).Inc()
+}
+
+func UserRegistered() {
+	registrations.Inc()
+}
+
+func EventDropped(eventType string) {
+	eventsDropped.WithLabelValues(eventType).Inc()
+}
+
+func WebhookAttempt(result string) {
+	webhookAttempts.WithLabelValues(result).Inc()
+}
+
+func AuditWriteFailed(action string) {
+	auditWriteFailures.WithLabelValues(action).Inc()
+}
+
+func AuthLockout() {
+	authLockouts.Inc()
+}
+
+func RateLimited(method string, route string) {
+	rateLimited.WithLabelValues(method, route).Inc()
+}
+
+func GRPCRateLimited(method string) {
+	grpcRateLimited.WithLabelValues(method).Inc()
+}
@@ -1,13 +1,35 @@
 package model
 
+import (
+	"avito-shop/internal/domain"
+	"encoding/json"
+	"time"
+)
+
+// AuthRequestDTO - запрос токена. Scopes сужает права токена, например только info:read
+// для дашбордов и киосков. Без Scopes токен дает все права пользователя
 type AuthRequestDTO struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Username string   `json:"username" binding:"required"`
+	Password string   `json:"password" binding:"required"`
+	Scopes   []string `json:"scopes"`
 }
 
 type SendCoinRequestDTO struct {
-	ToUser string `json:"toUser" binding:"required"`
-	Amount int    `json:"amount" binding:"required,gt=0"`
+	ToUser   string       `json:"toUser" binding:"required"`
+	Amount   domain.Money `json:"amount" binding:"required,gt=0"`
+	Currency string       `json:"currency"`
+}
+
+type TransferQuoteRequestDTO struct {
+	Amount   domain.Money `form:"amount" binding:"required,gt=0"`
+	Currency string       `form:"currency"`
+}
+
+type TransferQuoteDTO struct {
+	Amount   domain.Money `json:"amount"`
+	Fee      domain.Money `json:"fee"`
+	Total    domain.Money `json:"total"`
+	Currency string       `json:"currency"`
 }
 
 type ItemDTO struct {
@@ -16,26 +38,226 @@ type ItemDTO struct {
 }
 
 type TransactionHistoryDTO struct {
-	FromUser string `json:"fromUser,omitempty"`
-	ToUser   string `json:"toUser,omitempty"`
-	Amount   int    `json:"amount"`
+	FromUser string       `json:"fromUser,omitempty"`
+	ToUser   string       `json:"toUser,omitempty"`
+	Amount   domain.Money `json:"amount"`
+	Fee      domain.Money `json:"fee,omitempty"`
+	Currency string       `json:"currency"`
+}
+
+type OperationHistoryDTO struct {
+	Type     string       `json:"type"`
+	Amount   domain.Money `json:"amount"`
+	Currency string       `json:"currency"`
+	Reason   string       `json:"reason,omitempty"`
 }
 
 type CoinHistoryDTO struct {
-	Received []TransactionHistoryDTO `json:"received"`
-	Sent     []TransactionHistoryDTO `json:"sent"`
+	Received   []TransactionHistoryDTO `json:"received"`
+	Sent       []TransactionHistoryDTO `json:"sent"`
+	Operations []OperationHistoryDTO   `json:"operations"`
+}
+
+type ExpiringCoinsDTO struct {
+	Amount    domain.Money `json:"amount"`
+	Currency  string       `json:"currency"`
+	ExpiresAt time.Time    `json:"expiresAt"`
+}
+
+type WalletDTO struct {
+	Currency string       `json:"currency"`
+	Balance  domain.Money `json:"balance"`
 }
 
 type InfoResponseDTO struct {
-	Coins       int            `json:"coins"`
-	Inventory   []ItemDTO      `json:"inventory"`
-	CoinHistory CoinHistoryDTO `json:"coinHistory"`
+	Coins        domain.Money       `json:"coins"`
+	Inventory    []ItemDTO          `json:"inventory"`
+	Wallets      []WalletDTO        `json:"wallets"`
+	CoinHistory  CoinHistoryDTO     `json:"coinHistory"`
+	ExpiringSoon []ExpiringCoinsDTO `json:"expiringSoon"`
 }
 
+// ErrorResponseDTO - ответ с ошибкой. Error - сообщение для пользователя (прежний контракт),
+// Code - стабильный машиночитаемый код ошибки
 type ErrorResponseDTO struct {
 	Error string `json:"error"`
+	Code  string `json:"code"`
 }
 
 type BuyItemRequestDTO struct {
 	Item string `uri:"item" binding:"required"`
 }
+
+type CoinOperationRequestDTO struct {
+	ToUser   string       `json:"toUser"`
+	Group    string       `json:"group"`
+	Amount   domain.Money `json:"amount" binding:"required,gt=0"`
+	Currency string       `json:"currency"`
+	Reason   string       `json:"reason" binding:"required"`
+}
+
+type CoinOperationResponseDTO struct {
+	Affected int `json:"affected"`
+}
+
+type GroupMemberRequestDTO struct {
+	Username string `json:"username" binding:"required"`
+}
+
+type CoinsReceivedEventDTO struct {
+	FromUser string       `json:"fromUser"`
+	Amount   domain.Money `json:"amount"`
+	Currency string       `json:"currency"`
+}
+
+type PurchaseCompletedEventDTO struct {
+	Item     string       `json:"item"`
+	Price    domain.Money `json:"price"`
+	Currency string       `json:"currency"`
+}
+
+type UserRegisteredEventDTO struct {
+	Username string `json:"username"`
+}
+
+// BalanceChangedEventDTO описывает изменение баланса: Delta отрицательна при списании
+type BalanceChangedEventDTO struct {
+	Currency string       `json:"currency"`
+	Delta    domain.Money `json:"delta"`
+}
+
+type WebhookSubscriptionRequestDTO struct {
+	URL        string   `json:"url" binding:"required,url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"eventTypes" binding:"required,min=1"`
+}
+
+// WebhookSubscriptionDTO - подписка на вебхуки. Secret возвращается только при создании
+type WebhookSubscriptionDTO struct {
+	ID         int       `json:"id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret,omitempty"`
+	EventTypes []string  `json:"eventTypes"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// WebhookEventDTO - тело запроса, которое получает подписчик вебхука
+type WebhookEventDTO struct {
+	ID         int64           `json:"id"`
+	Type       string          `json:"type"`
+	UserID     int             `json:"userId"`
+	Data       json.RawMessage `json:"data"`
+	OccurredAt time.Time       `json:"occurredAt"`
+}
+
+type WebhookAttemptDTO struct {
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type WebhookDeliveryDTO struct {
+	ID            int64               `json:"id"`
+	EventType     string              `json:"eventType"`
+	Status        string              `json:"status"`
+	Attempts      int                 `json:"attempts"`
+	NextAttemptAt time.Time           `json:"nextAttemptAt"`
+	LastError     string              `json:"lastError,omitempty"`
+	CreatedAt     time.Time           `json:"createdAt"`
+	DeliveredAt   *time.Time          `json:"deliveredAt,omitempty"`
+	Log           []WebhookAttemptDTO `json:"log"`
+}
+
+// AuditQueryDTO - фильтры журнала аудита. From и To задаются в RFC 3339, BeforeID - курсор
+// для следующей страницы: ID последней полученной записи
+type AuditQueryDTO struct {
+	ActorID  int       `form:"actorId" binding:"gte=0"`
+	Action   string    `form:"action"`
+	Target   string    `form:"target"`
+	From     time.Time `form:"from"`
+	To       time.Time `form:"to"`
+	BeforeID int64     `form:"beforeId" binding:"gte=0"`
+	Limit    int       `form:"limit" binding:"gte=0,lte=1000"`
+}
+
+type AuditEntryDTO struct {
+	ID         int64             `json:"id"`
+	OccurredAt time.Time         `json:"occurredAt"`
+	ActorID    int               `json:"actorId,omitempty"`
+	Action     string            `json:"action"`
+	Target     string            `json:"target,omitempty"`
+	IP         string            `json:"ip,omitempty"`
+	UserAgent  string            `json:"userAgent,omitempty"`
+	RequestID  string            `json:"requestId,omitempty"`
+	Details    map[string]string `json:"details,omitempty"`
+	PrevHash   string            `json:"prevHash"`
+	Hash       string            `json:"hash"`
+}
+
+type APIKeyRequestDTO struct {
+	Username  string     `json:"username" binding:"required"`
+	Name      string     `json:"name" binding:"required,max=255"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// APIKeyDTO - ключ API. Key возвращается только при создании, потом восстановить его нельзя
+type APIKeyDTO struct {
+	ID         int        `json:"id"`
+	Username   string     `json:"username"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Key        string     `json:"key,omitempty"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+type PasswordChangeRequestDTO struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required"`
+}
+
+// PasswordResetTokenDTO - токен сброса пароля. Показывается только администратору, который его выпустил
+type PasswordResetTokenDTO struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type PasswordResetRequestDTO struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required"`
+}
+
+// MFAEnrollmentDTO - секрет второго фактора для приложения-аутентификатора: вручную или QR-кодом по OTPAuthURI
+type MFAEnrollmentDTO struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauthUri"`
+}
+
+type MFAConfirmRequestDTO struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFARecoveryCodesDTO - коды восстановления. Показываются один раз, в бд хранятся только хеши
+type MFARecoveryCodesDTO struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// MFAVerifyRequestDTO - второй шаг входа. Code - код из приложения или код восстановления
+type MFAVerifyRequestDTO struct {
+	MFAToken string `json:"mfaToken" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// MFAChallengeResponseDTO - ответ на вход по паролю, когда нужен второй фактор
+type MFAChallengeResponseDTO struct {
+	Error        string    `json:"error"`
+	Code         string    `json:"code"`
+	MFAToken     string    `json:"mfaToken"`
+	MFAExpiresAt time.Time `json:"mfaExpiresAt"`
+}
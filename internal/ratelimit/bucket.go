@@ -0,0 +1,149 @@
+package ratelimit
+
+import (
+	"avito-shop/internal/erorrs"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitError - отказ из-за исчерпания бюджета запросов. RetryAfter - когда появится следующий токен
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded, retry after %s", e.RetryAfter)
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return erorrs.ErrRateLimited
+}
+
+func (e *RateLimitError) RetryAfterSeconds() int {
+	return ceilSeconds(e.RetryAfter)
+}
+
+// Budget - бюджет токен-бакета: Rate токенов в секунду, не больше Burst подряд.
+// Нулевой Rate или Burst отключает ограничение
+type Budget struct {
+	Rate  float64
+	Burst int
+}
+
+func (b Budget) Enabled() bool {
+	return b.Rate > 0 && b.Burst > 0
+}
+
+// Decision - результат проверки лимита. Limit равный 0 означает, что лимит для маршрута не задан
+type Decision struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// ResetSeconds - через сколько секунд бакет наполнится полностью, для заголовка X-RateLimit-Reset
+func (d Decision) ResetSeconds() string {
+	return strconv.Itoa(ceilSeconds(d.Reset))
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	budget  Budget
+}
+
+// refill пополняет бакет за время, прошедшее с последнего обращения
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = math.Min(float64(b.budget.Burst), b.tokens+elapsed.Seconds()*b.budget.Rate)
+		b.updated = now
+	}
+}
+
+// RouteLimiter ограничивает частоту запросов каждого пользователя к каждому маршруту.
+// Бакеты хранятся в памяти процесса, поэтому при нескольких репликах бюджет действует на каждую отдельно
+type RouteLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	fallback  Budget
+	routes    map[string]Budget
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewRouteLimiter создает ограничитель. Ключ routes - метод и шаблон маршрута, например "POST /api/buy/:item",
+// маршруты без собственного бюджета получают fallback
+func NewRouteLimiter(fallback Budget, routes map[string]Budget) *RouteLimiter {
+	return &RouteLimiter{
+		buckets:  make(map[string]*bucket),
+		fallback: fallback,
+		routes:   routes,
+		now:      time.Now,
+	}
+}
+
+// sweepInterval - как часто удалять бакеты, которые успели наполниться и ничего не ограничивают
+const sweepInterval = time.Minute
+
+// Allow списывает токен из бакета пользователя для маршрута
+func (l *RouteLimiter) Allow(userID int, route string) Decision {
+	budget, ok := l.routes[route]
+	if !ok {
+		budget = l.fallback
+	}
+	if !budget.Enabled() {
+		return Decision{Allowed: true}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+
+	key := strconv.Itoa(userID) + " " + route
+	b, ok := l.buckets[key]
+	if !ok || b.budget != budget {
+		b = &bucket{tokens: float64(budget.Burst), updated: now, budget: budget}
+		l.buckets[key] = b
+	}
+	b.refill(now)
+
+	decision := Decision{Limit: budget.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = secondsToDuration((1 - b.tokens) / budget.Rate)
+	}
+
+	decision.Remaining = int(b.tokens)
+	decision.Reset = secondsToDuration((float64(budget.Burst) - b.tokens) / budget.Rate)
+	return decision
+}
+
+// sweep удаляет полные бакеты: новый бакет создается полным, так что их удаление ничего не меняет
+func (l *RouteLimiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.budget.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
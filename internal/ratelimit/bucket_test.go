@@ -0,0 +1,75 @@
+//go:build unit
+// +build unit
+
+package ratelimit
+
+import (
+	"avito-shop/internal/erorrs"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+const buyRoute = "POST /api/buy/:item"
+
+func TestRouteLimiter_Allow(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	limiter := NewRouteLimiter(Budget{}, map[string]Budget{buyRoute: {Rate: 1, Burst: 3}})
+	limiter.now = func() time.Time { return now }
+
+	for remaining := 2; remaining >= 0; remaining-- {
+		decision := limiter.Allow(1, buyRoute)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, 3, decision.Limit)
+		assert.Equal(t, remaining, decision.Remaining)
+	}
+
+	decision := limiter.Allow(1, buyRoute)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, time.Second, decision.RetryAfter)
+	assert.Equal(t, 3*time.Second, decision.Reset)
+
+	// у другого пользователя свой бакет
+	assert.True(t, limiter.Allow(2, buyRoute).Allowed)
+
+	// за секунду бакет пополняется на один токен
+	now = now.Add(time.Second)
+	assert.True(t, limiter.Allow(1, buyRoute).Allowed)
+	assert.False(t, limiter.Allow(1, buyRoute).Allowed)
+
+	// маршрут без бюджета не ограничивается
+	decision = limiter.Allow(1, "GET /api/info")
+	assert.True(t, decision.Allowed)
+	assert.Zero(t, decision.Limit)
+}
+
+func TestRouteLimiter_Fallback(t *testing.T) {
+	limiter := NewRouteLimiter(Budget{Rate: 1, Burst: 1}, nil)
+
+	assert.True(t, limiter.Allow(1, "GET /api/info").Allowed)
+	assert.False(t, limiter.Allow(1, "GET /api/info").Allowed)
+	assert.True(t, limiter.Allow(1, "GET /api/events").Allowed)
+}
+
+func TestRouteLimiter_SweepsFullBuckets(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	limiter := NewRouteLimiter(Budget{}, map[string]Budget{buyRoute: {Rate: 1, Burst: 5}})
+	limiter.now = func() time.Time { return now }
+
+	limiter.Allow(1, buyRoute)
+	limiter.Allow(2, buyRoute)
+	assert.Len(t, limiter.buckets, 2)
+
+	now = now.Add(sweepInterval)
+	limiter.Allow(3, buyRoute)
+	assert.Len(t, limiter.buckets, 1)
+}
+
+func TestRateLimitError(t *testing.T) {
+	err := error(&RateLimitError{RetryAfter: 200 * time.Millisecond})
+
+	assert.True(t, errors.Is(err, erorrs.ErrRateLimited))
+	assert.False(t, errors.Is(err, erorrs.ErrTooManyAttempts))
+	assert.Equal(t, 1, err.(*RateLimitError).RetryAfterSeconds())
+}
@@ -0,0 +1,199 @@
+// Package ratelimit ограничивает частоту попыток: блокирует ключи (имя пользователя, IP)
+// после серии неудачных входов с экспоненциально растущим сроком блокировки
+// и ограничивает частоту запросов пользователей к маршрутам токен-бакетом
+package ratelimit
+
+import (
+	"avito-shop/internal/erorrs"
+	"context"
+	"fmt"
+	"time"
+)
+
+// RetryAfterError - отказ из-за превышения лимита. RetryAfter - через сколько можно повторить
+type RetryAfterError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("too many attempts, retry after %s", e.RetryAfter)
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return erorrs.ErrTooManyAttempts
+}
+
+// RetryAfterSeconds округляет задержку вверх до целых секунд для заголовка Retry-After
+func (e *RetryAfterError) RetryAfterSeconds() int {
+	return ceilSeconds(e.RetryAfter)
+}
+
+// LockoutPolicy задает блокировку ключа: после MaxFailures неудач за Window ключ блокируется
+// на BaseLockout, каждая следующая блокировка подряд вдвое дольше, но не дольше MaxLockout.
+// Счетчик блокировок сбрасывается, если после окончания последней прошло больше MaxLockout.
+// MaxFailures равный 0 отключает блокировку
+type LockoutPolicy struct {
+	MaxFailures int
+	Window      time.Duration
+	BaseLockout time.Duration
+	MaxLockout  time.Duration
+}
+
+// LockoutState - состояние ключа в хранилище
+type LockoutState struct {
+	Failures    int
+	WindowStart time.Time
+	Lockouts    int
+	LockedUntil time.Time
+}
+
+// RetryAfter возвращает оставшийся срок блокировки или 0, если ключ не заблокирован
+func (s LockoutState) RetryAfter(now time.Time) time.Duration {
+	if now.Before(s.LockedUntil) {
+		return s.LockedUntil.Sub(now)
+	}
+	return 0
+}
+
+func (p LockoutPolicy) Enabled() bool {
+	return p.MaxFailures > 0
+}
+
+// Fail учитывает неудачную попытку и при достижении лимита блокирует ключ
+func (p LockoutPolicy) Fail(state LockoutState, now time.Time) LockoutState {
+	if state.Lockouts > 0 && now.Sub(state.LockedUntil) > p.MaxLockout {
+		state.Lockouts = 0
+	}
+
+	if now.Sub(state.WindowStart) > p.Window {
+		state.Failures = 0
+		state.WindowStart = now
+	}
+
+	state.Failures++
+	if state.Failures >= p.MaxFailures {
+		state.Lockouts++
+		state.LockedUntil = now.Add(p.lockout(state.Lockouts))
+		state.Failures = 0
+		state.WindowStart = now
+	}
+
+	return state
+}
+
+// lockout возвращает срок n-й блокировки подряд
+func (p LockoutPolicy) lockout(n int) time.Duration {
+	delay := p.BaseLockout
+	for i := 1; i < n; i++ {
+		delay *= 2
+		if p.MaxLockout > 0 && delay >= p.MaxLockout {
+			return p.MaxLockout
+		}
+	}
+
+	if p.MaxLockout > 0 && delay > p.MaxLockout {
+		return p.MaxLockout
+	}
+	return delay
+}
+
+// retention - через сколько после последнего изменения состояние ключа ни на что не влияет
+func (p LockoutPolicy) retention() time.Duration {
+	return p.Window + 2*p.MaxLockout
+}
+
+// LockoutStore хранит состояние ключей: MemoryStore для одного экземпляра, repository.LockoutRepo для нескольких реплик
+type LockoutStore interface {
+	Get(ctx context.Context, key string) (LockoutState, error)
+	// Update атомарно заменяет состояние ключа результатом fn
+	Update(ctx context.Context, key string, fn func(LockoutState) LockoutState) (LockoutState, error)
+	Delete(ctx context.Context, key string) error
+	// Purge удаляет ключи, не менявшиеся с before
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
+
+// Guard блокирует попытки входа отдельно по имени пользователя и по IP
+type Guard struct {
+	store    LockoutStore
+	username LockoutPolicy
+	ip       LockoutPolicy
+	now      func() time.Time
+}
+
+func NewGuard(store LockoutStore, username LockoutPolicy, ip LockoutPolicy) *Guard {
+	return &Guard{
+		store:    store,
+		username: username,
+		ip:       ip,
+		now:      time.Now,
+	}
+}
+
+// Check возвращает, сколько осталось ждать до следующей попытки, или 0, если попытка разрешена
+func (g *Guard) Check(ctx context.Context, username string, ip string) (time.Duration, error) {
+	now := g.now()
+
+	var retryAfter time.Duration
+	for _, key := range g.keys(username, ip) {
+		state, err := g.store.Get(ctx, key.name)
+		if err != nil {
+			return 0, err
+		}
+		retryAfter = max(retryAfter, state.RetryAfter(now))
+	}
+
+	return retryAfter, nil
+}
+
+// Fail учитывает неудачную попытку и возвращает срок блокировки, если она наступила
+func (g *Guard) Fail(ctx context.Context, username string, ip string) (time.Duration, error) {
+	now := g.now()
+
+	var retryAfter time.Duration
+	for _, key := range g.keys(username, ip) {
+		state, err := g.store.Update(ctx, key.name, func(state LockoutState) LockoutState {
+			return key.policy.Fail(state, now)
+		})
+		if err != nil {
+			return 0, err
+		}
+		retryAfter = max(retryAfter, state.RetryAfter(now))
+	}
+
+	return retryAfter, nil
+}
+
+// Succeed сбрасывает счетчик неудач пользователя после успешного входа. Счетчик IP не сбрасывается,
+// иначе перебор паролей чужих учетных записей можно было бы перемежать входом в свою
+func (g *Guard) Succeed(ctx context.Context, username string) error {
+	if !g.username.Enabled() {
+		return nil
+	}
+	return g.store.Delete(ctx, usernameKey(username))
+}
+
+// Purge удаляет из хранилища ключи, состояние которых уже ни на что не влияет
+func (g *Guard) Purge(ctx context.Context) (int, error) {
+	retention := max(g.username.retention(), g.ip.retention())
+	return g.store.Purge(ctx, g.now().Add(-retention))
+}
+
+type guardKey struct {
+	name   string
+	policy LockoutPolicy
+}
+
+func (g *Guard) keys(username string, ip string) []guardKey {
+	keys := make([]guardKey, 0, 2)
+	if g.username.Enabled() && username != "" {
+		keys = append(keys, guardKey{name: usernameKey(username), policy: g.username})
+	}
+	if g.ip.Enabled() && ip != "" {
+		keys = append(keys, guardKey{name: "ip:" + ip, policy: g.ip})
+	}
+	return keys
+}
+
+func usernameKey(username string) string {
+	return "user:" + username
+}
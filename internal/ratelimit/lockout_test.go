@@ -0,0 +1,131 @@
+//go:build unit
+// +build unit
+
+package ratelimit
+
+import (
+	"avito-shop/internal/erorrs"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+var testPolicy = LockoutPolicy{MaxFailures: 3, Window: 10 * time.Minute, BaseLockout: time.Minute, MaxLockout: 8 * time.Minute}
+
+func TestLockoutPolicy_Fail(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	var state LockoutState
+	for i := 0; i < 2; i++ {
+		state = testPolicy.Fail(state, now)
+		assert.Zero(t, state.RetryAfter(now))
+	}
+
+	// третья неудача блокирует на BaseLockout
+	state = testPolicy.Fail(state, now)
+	assert.Equal(t, time.Minute, state.RetryAfter(now))
+
+	// следующие серии блокируют вдвое дольше, но не дольше MaxLockout
+	for _, expected := range []time.Duration{2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 8 * time.Minute} {
+		now = state.LockedUntil
+		for i := 0; i < 3; i++ {
+			state = testPolicy.Fail(state, now)
+		}
+		assert.Equal(t, expected, state.RetryAfter(now))
+	}
+
+	// после долгой паузы блокировка снова начинается с BaseLockout
+	now = state.LockedUntil.Add(testPolicy.MaxLockout + time.Second)
+	for i := 0; i < 3; i++ {
+		state = testPolicy.Fail(state, now)
+	}
+	assert.Equal(t, time.Minute, state.RetryAfter(now))
+}
+
+func TestLockoutPolicy_WindowResetsFailures(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	state := testPolicy.Fail(LockoutState{}, now)
+	state = testPolicy.Fail(state, now)
+	state = testPolicy.Fail(state, now.Add(testPolicy.Window+time.Second))
+
+	assert.Equal(t, 1, state.Failures)
+	assert.Zero(t, state.RetryAfter(now))
+}
+
+func TestGuard(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	ipPolicy := LockoutPolicy{MaxFailures: 5, Window: 10 * time.Minute, BaseLockout: 5 * time.Minute, MaxLockout: time.Hour}
+
+	guard := NewGuard(NewMemoryStore(), testPolicy, ipPolicy)
+	guard.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		retryAfter, err := guard.Fail(ctx, "alice", "10.0.0.1")
+		require.NoError(t, err)
+		assert.Zero(t, retryAfter)
+	}
+
+	// успешный вход сбрасывает счетчик имени, но не IP
+	require.NoError(t, guard.Succeed(ctx, "alice"))
+	for i := 0; i < 2; i++ {
+		_, err := guard.Fail(ctx, "alice", "10.0.0.1")
+		require.NoError(t, err)
+	}
+	retryAfter, err := guard.Check(ctx, "alice", "10.0.0.1")
+	require.NoError(t, err)
+	assert.Zero(t, retryAfter)
+
+	// пятая неудача с одного IP блокирует IP для любых имен
+	retryAfter, err = guard.Fail(ctx, "bob", "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, retryAfter)
+
+	retryAfter, err = guard.Check(ctx, "carol", "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, retryAfter)
+
+	retryAfter, err = guard.Check(ctx, "carol", "10.0.0.2")
+	require.NoError(t, err)
+	assert.Zero(t, retryAfter)
+}
+
+func TestGuard_Disabled(t *testing.T) {
+	guard := NewGuard(NewMemoryStore(), LockoutPolicy{}, LockoutPolicy{})
+
+	for i := 0; i < 100; i++ {
+		retryAfter, err := guard.Fail(context.Background(), "alice", "10.0.0.1")
+		require.NoError(t, err)
+		assert.Zero(t, retryAfter)
+	}
+}
+
+func TestMemoryStore_Purge(t *testing.T) {
+	store := NewMemoryStore()
+	_, err := store.Update(context.Background(), "user:alice", func(s LockoutState) LockoutState {
+		s.Failures++
+		return s
+	})
+	require.NoError(t, err)
+
+	purged, err := store.Purge(context.Background(), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	purged, err = store.Purge(context.Background(), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+}
+
+func TestRetryAfterError(t *testing.T) {
+	err := error(&RetryAfterError{RetryAfter: 1500 * time.Millisecond})
+
+	assert.True(t, errors.Is(err, erorrs.ErrTooManyAttempts))
+	var retryErr *RetryAfterError
+	require.True(t, errors.As(err, &retryErr))
+	assert.Equal(t, 2, retryErr.RetryAfterSeconds())
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore хранит состояние в памяти процесса. Подходит для одного экземпляра сервиса:
+// реплики не видят блокировки друг друга
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	state     LockoutState
+	updatedAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry)}
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (LockoutState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.entries[key].state, nil
+}
+
+func (s *MemoryStore) Update(_ context.Context, key string, fn func(LockoutState) LockoutState) (LockoutState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := fn(s.entries[key].state)
+	s.entries[key] = memoryEntry{state: state, updatedAt: time.Now()}
+	return state, nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *MemoryStore) Purge(_ context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int
+	for key, entry := range s.entries {
+		if entry.updatedAt.Before(before) {
+			delete(s.entries, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	"avito-shop/internal/events"
+	"avito-shop/internal/logger"
+	"avito-shop/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"go.uber.org/zap"
+)
+
+type AdminRepo struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewAdminRepo(db *sql.DB, logger logger.Logger) *AdminRepo {
+	return &AdminRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// log возвращает логгер запроса из ctx, а если его нет - логгер репозитория
+func (r *AdminRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, r.logger)
+}
+
+func (r *AdminRepo) GetUserRole(ctx context.Context, userID int) (string, error) {
+	var role string
+
+	err := r.db.QueryRowContext(ctx, `SELECT role FROM users WHERE id = $1`, userID).Scan(&role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.log(ctx).Error("sql.Admin.GetUserRole: user not found", zap.Error(err))
+			return "", erorrs.ErrNotFound
+		}
+		r.log(ctx).Error("sql.Admin.GetUserRole: error query", zap.Error(err))
+		return "", err
+	}
+
+	return role, nil
+}
+
+func (r *AdminRepo) GetUserByName(ctx context.Context, username string) (int, error) {
+	var id int
+
+	err := r.db.QueryRowContext(ctx, `SELECT id FROM users WHERE username = $1`, username).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.log(ctx).Error("sql.Admin.GetUserByName: user not found", zap.Error(err))
+			return 0, erorrs.ErrNotFound
+		}
+		r.log(ctx).Error("sql.Admin.GetUserByName: error query", zap.Error(err))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *AdminRepo) GetGroupMembers(ctx context.Context, group string) ([]int, error) {
+	var groupID int
+
+	err := r.db.QueryRowContext(ctx, `SELECT id FROM user_groups WHERE name = $1`, group).Scan(&groupID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.log(ctx).Error("sql.Admin.GetGroupMembers: group not found", zap.Error(err))
+			return nil, erorrs.ErrGroupNotFound
+		}
+		r.log(ctx).Error("sql.Admin.GetGroupMembers: error query group", zap.Error(err))
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT user_id FROM user_group_members WHERE group_id = $1 ORDER BY user_id`,
+		groupID,
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.Admin.GetGroupMembers: error query members", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			r.log(ctx).Error("sql.Admin.GetGroupMembers: error scan", zap.Error(err))
+			return nil, err
+		}
+		members = append(members, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log(ctx).Error("sql.Admin.GetGroupMembers: rows error", zap.Error(err))
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (r *AdminRepo) AddGroupMember(ctx context.Context, group string, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.log(ctx).Error("sql.Admin.AddGroupMember: error begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	var groupID int
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO user_groups (name) VALUES ($1)
+         ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+         RETURNING id`,
+		group,
+	).Scan(&groupID)
+	if err != nil {
+		r.log(ctx).Error("sql.Admin.AddGroupMember: save group", zap.Error(err))
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO user_group_members (group_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		groupID, userID,
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.Admin.AddGroupMember: save member", zap.Error(err))
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *AdminRepo) RemoveGroupMember(ctx context.Context, group string, userID int) error {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM user_group_members m
+         USING user_groups g
+         WHERE m.group_id = g.id AND g.name = $1 AND m.user_id = $2`,
+		group, userID,
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.Admin.RemoveGroupMember: error exec", zap.Error(err))
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.log(ctx).Error("sql.Admin.RemoveGroupMember: error rows affected", zap.Error(err))
+		return err
+	}
+	if rows == 0 {
+		return erorrs.ErrNotGroupMember
+	}
+
+	return nil
+}
+
+// ApplyCoinOperation изменяет баланс кошельков всех пользователей на op.Amount в одной транзакции,
+// записывает операцию в историю каждого из них и сообщает им об изменении баланса
+func (r *AdminRepo) ApplyCoinOperation(ctx context.Context, userIDs []int, op domain.CoinOperation) error {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	if err != nil {
+		r.log(ctx).Error("sql.Admin.ApplyCoinOperation: error begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = ensureCurrency(ctx, tx, op.Currency); err != nil {
+		r.log(ctx).Error("sql.Admin.ApplyCoinOperation: check currency", zap.Error(err))
+		return err
+	}
+
+	for _, userID := range userIDs {
+		if err = lockUser(ctx, tx, userID); err != nil {
+			r.log(ctx).Error("sql.Admin.ApplyCoinOperation: lock user", zap.Int("userID", userID), zap.Error(err))
+			return err
+		}
+
+		if op.Amount > 0 {
+			err = creditWallet(ctx, tx, userID, op.Currency, op.Amount)
+			if err == nil {
+				err = addLot(ctx, tx, userID, op.Currency, op.Amount, nullTime(op.ExpiresAt))
+			}
+		} else {
+			err = debitWallet(ctx, tx, userID, op.Currency, -op.Amount)
+			if err == nil {
+				_, err = consumeLots(ctx, tx, userID, op.Currency, -op.Amount)
+			}
+		}
+		if err != nil {
+			r.log(ctx).Error("sql.Admin.ApplyCoinOperation: update balance", zap.Int("userID", userID), zap.Error(err))
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO coin_operations (user_id, operation, amount, currency, reason, actor_id) VALUES ($1, $2, $3, $4, $5, $6)`,
+			userID, op.Type, op.Amount, op.Currency, op.Reason, op.ActorID,
+		)
+		if err != nil {
+			r.log(ctx).Error("sql.Admin.ApplyCoinOperation: save operation", zap.Error(err))
+			return err
+		}
+	}
+
+	// события пишутся после того, как заблокированы все пользователи: блокировка outbox держится
+	// до фиксации, и ожидание строки под ней задерживало бы все остальные записи в outbox
+	for _, userID := range userIDs {
+		err = writeOutbox(ctx, tx, events.TypeBalanceChanged, userID, model.BalanceChangedEventDTO{
+			Currency: op.Currency,
+			Delta:    op.Amount,
+		})
+		if err != nil {
+			r.log(ctx).Error("sql.Admin.ApplyCoinOperation: write outbox", zap.Error(err))
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	"avito-shop/internal/logger"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"time"
+)
+
+// lastUsedPrecision - с какой точностью хранится время последнего использования ключа.
+// Без нее каждый запрос бота превращался бы в запись в бд
+const lastUsedPrecision = time.Minute
+
+type APIKeyRepo struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewAPIKeyRepo(db *sql.DB, logger logger.Logger) *APIKeyRepo {
+	return &APIKeyRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// log возвращает логгер запроса из ctx, а если его нет - логгер репозитория
+func (r *APIKeyRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, r.logger)
+}
+
+// CreateKey сохраняет ключ пользователя key.Username
+func (r *APIKeyRepo) CreateKey(ctx context.Context, key domain.APIKey) (domain.APIKey, error) {
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, created_by, expires_at)
+         SELECT id, $2, $3, $4, $5, $6, $7 FROM users WHERE username = $1
+         RETURNING id, user_id, created_at`,
+		key.Username, key.Name, key.Prefix, key.Hash, pq.Array(key.Scopes), key.CreatedBy, key.ExpiresAt,
+	).Scan(&key.ID, &key.UserID, &key.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.log(ctx).Info("sql.APIKey.CreateKey: user not found", zap.String("username", key.Username))
+			return domain.APIKey{}, erorrs.ErrNotFound
+		}
+		r.log(ctx).Error("sql.APIKey.CreateKey: error insert", zap.Error(err))
+		return domain.APIKey{}, err
+	}
+
+	return key, nil
+}
+
+func (r *APIKeyRepo) ListKeys(ctx context.Context) ([]domain.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT k.id, k.user_id, u.username, k.name, k.prefix, k.scopes, COALESCE(k.created_by, 0),
+                k.created_at, k.expires_at, k.last_used_at, k.revoked_at
+         FROM api_keys k
+         JOIN users u ON u.id = k.user_id
+         ORDER BY k.id`,
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.APIKey.ListKeys: error query", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []domain.APIKey{}
+	for rows.Next() {
+		var key domain.APIKey
+		err := rows.Scan(&key.ID, &key.UserID, &key.Username, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.CreatedBy,
+			&key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt)
+		if err != nil {
+			r.log(ctx).Error("sql.APIKey.ListKeys: error scan", zap.Error(err))
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log(ctx).Error("sql.APIKey.ListKeys: rows error", zap.Error(err))
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepo) GetKeyByHash(ctx context.Context, hash string) (domain.APIKey, error) {
+	key := domain.APIKey{Hash: hash}
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT k.id, k.user_id, u.username, k.name, k.prefix, k.scopes, k.expires_at, k.revoked_at
+         FROM api_keys k
+         JOIN users u ON u.id = k.user_id
+         WHERE k.key_hash = $1`,
+		hash,
+	).Scan(&key.ID, &key.UserID, &key.Username, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.ExpiresAt, &key.RevokedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.APIKey{}, erorrs.ErrAPIKeyNotFound
+		}
+		r.log(ctx).Error("sql.APIKey.GetKeyByHash: error query", zap.Error(err))
+		return domain.APIKey{}, err
+	}
+
+	return key, nil
+}
+
+// RevokeKey отзывает ключ. Повторный отзыв не меняет время первого
+func (r *APIKeyRepo) RevokeKey(ctx context.Context, id int, at time.Time) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1`,
+		id, at,
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.APIKey.RevokeKey: error exec", zap.Error(err))
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.log(ctx).Error("sql.APIKey.RevokeKey: error rows affected", zap.Error(err))
+		return err
+	}
+	if rows == 0 {
+		return erorrs.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// TouchKey запоминает время использования ключа, если прежнее отстает больше чем на lastUsedPrecision
+func (r *APIKeyRepo) TouchKey(ctx context.Context, id int, at time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE api_keys SET last_used_at = $2
+         WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $3)`,
+		id, at, at.Add(-lastUsedPrecision),
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.APIKey.TouchKey: error exec", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"avito-shop/internal/audit"
+	"avito-shop/internal/domain"
+	"avito-shop/internal/logger"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+// auditLockKey - ключ advisory-блокировки журнала аудита. Записи добавляются по одной,
+// иначе две транзакции прочитают один и тот же последний хеш и цепочка разветвится
+const auditLockKey = 0x6175646974
+
+type AuditRepo struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewAuditRepo(db *sql.DB, logger logger.Logger) *AuditRepo {
+	return &AuditRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// log возвращает логгер запроса из ctx, а если его нет - логгер репозитория
+func (r *AuditRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, r.logger)
+}
+
+// Append дописывает запись в конец цепочки и возвращает ее с ID и хешами
+func (r *AuditRepo) Append(ctx context.Context, entry domain.AuditEntry) (domain.AuditEntry, error) {
+	details, err := json.Marshal(entry.Details)
+	if err != nil {
+		return domain.AuditEntry{}, err
+	}
+	if entry.Details == nil {
+		details = []byte("{}")
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.log(ctx).Error("sql.Audit.Append: error begin transaction", zap.Error(err))
+		return domain.AuditEntry{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, auditLockKey); err != nil {
+		r.log(ctx).Error("sql.Audit.Append: error lock", zap.Error(err))
+		return domain.AuditEntry{}, err
+	}
+
+	err = tx.QueryRowContext(ctx, `SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1`).Scan(&entry.PrevHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		r.log(ctx).Error("sql.Audit.Append: error get last hash", zap.Error(err))
+		return domain.AuditEntry{}, err
+	}
+
+	entry.OccurredAt = audit.Timestamp(entry.OccurredAt)
+	entry.Hash = audit.Hash(entry.PrevHash, entry)
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO audit_log (occurred_at, actor_id, action, target, ip, user_agent, request_id, details, prev_hash, hash)
+         VALUES ($1, NULLIF($2, 0), $3, $4, $5, $6, $7, $8, $9, $10)
+         RETURNING id`,
+		entry.OccurredAt, entry.ActorID, entry.Action, entry.Target, entry.IP, entry.UserAgent, entry.RequestID,
+		string(details), entry.PrevHash, entry.Hash,
+	).Scan(&entry.ID)
+	if err != nil {
+		r.log(ctx).Error("sql.Audit.Append: error insert", zap.Error(err))
+		return domain.AuditEntry{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.log(ctx).Error("sql.Audit.Append: error commit", zap.Error(err))
+		return domain.AuditEntry{}, err
+	}
+
+	return entry, nil
+}
+
+// Query возвращает записи, подходящие под фильтр, от новых к старым
+func (r *AuditRepo) Query(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, occurred_at, COALESCE(actor_id, 0), action, target, ip, user_agent, request_id, details, prev_hash, hash
+         FROM audit_log
+         WHERE ($1 = 0 OR actor_id = $1)
+           AND ($2 = '' OR action = $2)
+           AND ($3 = '' OR target = $3)
+           AND ($4::timestamptz IS NULL OR occurred_at >= $4)
+           AND ($5::timestamptz IS NULL OR occurred_at < $5)
+           AND ($6::bigint = 0 OR id < $6)
+         ORDER BY id DESC
+         LIMIT $7`,
+		filter.ActorID, filter.Action, filter.Target, nullTime(optionalTime(filter.From)), nullTime(optionalTime(filter.To)),
+		filter.BeforeID, filter.Limit,
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.Audit.Query: error query", zap.Error(err))
+		return nil, err
+	}
+
+	return r.scanEntries(ctx, rows)
+}
+
+// ListAfter возвращает до limit записей с ID больше afterID в порядке цепочки
+func (r *AuditRepo) ListAfter(ctx context.Context, afterID int64, limit int) ([]domain.AuditEntry, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, occurred_at, COALESCE(actor_id, 0), action, target, ip, user_agent, request_id, details, prev_hash, hash
+         FROM audit_log
+         WHERE id > $1
+         ORDER BY id
+         LIMIT $2`,
+		afterID, limit,
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.Audit.ListAfter: error query", zap.Error(err))
+		return nil, err
+	}
+
+	return r.scanEntries(ctx, rows)
+}
+
+func (r *AuditRepo) scanEntries(ctx context.Context, rows *sql.Rows) ([]domain.AuditEntry, error) {
+	defer rows.Close()
+
+	entries := []domain.AuditEntry{}
+	for rows.Next() {
+		var (
+			entry   domain.AuditEntry
+			details []byte
+		)
+		err := rows.Scan(&entry.ID, &entry.OccurredAt, &entry.ActorID, &entry.Action, &entry.Target, &entry.IP,
+			&entry.UserAgent, &entry.RequestID, &details, &entry.PrevHash, &entry.Hash)
+		if err != nil {
+			r.log(ctx).Error("sql.Audit.scanEntries: error scan", zap.Error(err))
+			return nil, err
+		}
+		if err := json.Unmarshal(details, &entry.Details); err != nil {
+			r.log(ctx).Error("sql.Audit.scanEntries: error decode details", zap.Error(err))
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log(ctx).Error("sql.Audit.scanEntries: rows error", zap.Error(err))
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -3,7 +3,10 @@ package repository
 import (
 	"avito-shop/internal/domain"
 	"avito-shop/internal/erorrs"
+	"avito-shop/internal/events"
 	"avito-shop/internal/logger"
+	"avito-shop/internal/model"
+	"avito-shop/internal/tracing"
 	"context"
 	"database/sql"
 	"errors"
@@ -23,51 +26,112 @@ func NewAuthRepo(db *sql.DB, logger logger.Logger) *AuthRepo {
 	}
 }
 
+// log возвращает логгер запроса из ctx, а если его нет - логгер репозитория
+func (r *AuthRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, r.logger)
+}
+
 func (r *AuthRepo) GetUser(ctx context.Context, username string, password string) (domain.User, error) {
+	ctx, span := tracing.Start(ctx, "sql.Auth.GetUser")
+	defer span.End()
+
 	var user domain.User
 
 	query := `
-        SELECT id, username, password_hash, balance 
-        FROM users 
-        WHERE username = $1 AND password_hash = $2
+        SELECT u.id, u.username, u.password_hash, COALESCE(w.balance, 0), u.token_version,
+               m.confirmed_at IS NOT NULL
+        FROM users u
+        LEFT JOIN wallets w ON w.user_id = u.id AND w.currency = $3
+        LEFT JOIN user_mfa m ON m.user_id = u.id
+        WHERE u.username = $1 AND u.password_hash = $2
     `
 
-	err := r.db.QueryRowContext(ctx, query, username, password).Scan(
+	err := r.db.QueryRowContext(ctx, query, username, password, domain.DefaultCurrency).Scan(
 		&user.ID,
 		&user.Username,
 		&user.PasswordHash,
 		&user.Coins,
+		&user.TokenVersion,
+		&user.MFAEnabled,
 	)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			r.logger.Error("sql.Auth.GetUser: No rows in sql for user", zap.Error(err))
+			r.log(ctx).Error("sql.Auth.GetUser: No rows in sql for user", zap.Error(err))
 			return domain.User{}, nil
 		}
-		r.logger.Error("sql.Auth.GetUser: Database error", zap.Error(err))
+		r.log(ctx).Error("sql.Auth.GetUser: Database error", zap.Error(err))
 		return domain.User{}, err
 	}
 
 	return user, nil
 }
 
-func (r *AuthRepo) CreateUser(ctx context.Context, user domain.User) (int, error) {
-	query := `INSERT INTO users (username, password_hash, balance) 
-              VALUES ($1, $2, $3) 
+// CreateUser создает пользователя и начисляет ему приветственные монеты grant
+func (r *AuthRepo) CreateUser(ctx context.Context, user domain.User, grant domain.CoinOperation) (int, error) {
+	ctx, span := tracing.Start(ctx, "sql.Auth.CreateUser")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.log(ctx).Error("sql.Auth.CreateUser: error begin transaction", zap.Error(err))
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO users (username, password_hash) 
+              VALUES ($1, $2) 
               RETURNING id`
 
 	var id int
-	err := r.db.QueryRowContext(ctx, query,
+	err = tx.QueryRowContext(ctx, query,
 		user.Username,
 		user.PasswordHash,
-		1000,
 	).Scan(&id)
 
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
 			return 0, erorrs.ErrUserExist
 		}
-		r.logger.Error("sql.Auth.CreateUser: Database error", zap.Error(err))
+		r.log(ctx).Error("sql.Auth.CreateUser: Database error", zap.Error(err))
+		return 0, err
+	}
+
+	if err = creditWallet(ctx, tx, id, grant.Currency, grant.Amount); err != nil {
+		r.log(ctx).Error("sql.Auth.CreateUser: create wallet", zap.Error(err))
+		return 0, err
+	}
+
+	if grant.Amount > 0 {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO coin_operations (user_id, operation, amount, currency, reason) VALUES ($1, $2, $3, $4, $5)`,
+			id, grant.Type, grant.Amount, grant.Currency, grant.Reason,
+		)
+		if err != nil {
+			r.log(ctx).Error("sql.Auth.CreateUser: save welcome grant", zap.Error(err))
+			return 0, err
+		}
+
+		if err = addLot(ctx, tx, id, grant.Currency, grant.Amount, nullTime(grant.ExpiresAt)); err != nil {
+			r.log(ctx).Error("sql.Auth.CreateUser: save welcome lot", zap.Error(err))
+			return 0, err
+		}
+	}
+
+	err = writeOutbox(ctx, tx, events.TypeUserRegistered, id, model.UserRegisteredEventDTO{Username: user.Username})
+	if err == nil && grant.Amount > 0 {
+		err = writeOutbox(ctx, tx, events.TypeBalanceChanged, id, model.BalanceChangedEventDTO{
+			Currency: grant.Currency,
+			Delta:    grant.Amount,
+		})
+	}
+	if err != nil {
+		r.log(ctx).Error("sql.Auth.CreateUser: write outbox", zap.Error(err))
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.log(ctx).Error("sql.Auth.CreateUser: error commit", zap.Error(err))
 		return 0, err
 	}
 
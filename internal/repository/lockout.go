@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"avito-shop/internal/logger"
+	"avito-shop/internal/ratelimit"
+	"context"
+	"database/sql"
+	"errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+// LockoutRepo - хранилище блокировок входа в Postgres, общее для всех реплик
+type LockoutRepo struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewLockoutRepo(db *sql.DB, logger logger.Logger) *LockoutRepo {
+	return &LockoutRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// log возвращает логгер запроса из ctx, а если его нет - логгер репозитория
+func (r *LockoutRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, r.logger)
+}
+
+func (r *LockoutRepo) Get(ctx context.Context, key string) (ratelimit.LockoutState, error) {
+	state, err := scanLockout(r.db.QueryRowContext(ctx,
+		`SELECT failures, window_start, lockouts, locked_until FROM auth_lockouts WHERE key = $1`, key,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ratelimit.LockoutState{}, nil
+		}
+		r.log(ctx).Error("sql.Lockout.Get: error query", zap.Error(err))
+		return ratelimit.LockoutState{}, err
+	}
+
+	return state, nil
+}
+
+// Update блокирует строку ключа на время транзакции, поэтому одновременные неудачи
+// на разных репликах не теряются
+func (r *LockoutRepo) Update(ctx context.Context, key string, fn func(ratelimit.LockoutState) ratelimit.LockoutState) (ratelimit.LockoutState, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.log(ctx).Error("sql.Lockout.Update: error begin transaction", zap.Error(err))
+		return ratelimit.LockoutState{}, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO auth_lockouts (key) VALUES ($1) ON CONFLICT (key) DO NOTHING`, key)
+	if err != nil {
+		r.log(ctx).Error("sql.Lockout.Update: error insert", zap.Error(err))
+		return ratelimit.LockoutState{}, err
+	}
+
+	state, err := scanLockout(tx.QueryRowContext(ctx,
+		`SELECT failures, window_start, lockouts, locked_until FROM auth_lockouts WHERE key = $1 FOR UPDATE`, key,
+	))
+	if err != nil {
+		r.log(ctx).Error("sql.Lockout.Update: error select", zap.Error(err))
+		return ratelimit.LockoutState{}, err
+	}
+
+	state = fn(state)
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE auth_lockouts
+         SET failures = $2, window_start = $3, lockouts = $4, locked_until = $5, updated_at = CURRENT_TIMESTAMP
+         WHERE key = $1`,
+		key, state.Failures, nullTime(optionalTime(state.WindowStart)), state.Lockouts, nullTime(optionalTime(state.LockedUntil)),
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.Lockout.Update: error update", zap.Error(err))
+		return ratelimit.LockoutState{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.log(ctx).Error("sql.Lockout.Update: error commit", zap.Error(err))
+		return ratelimit.LockoutState{}, err
+	}
+
+	return state, nil
+}
+
+func (r *LockoutRepo) Delete(ctx context.Context, key string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM auth_lockouts WHERE key = $1`, key); err != nil {
+		r.log(ctx).Error("sql.Lockout.Delete: error exec", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *LockoutRepo) Purge(ctx context.Context, before time.Time) (int, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM auth_lockouts WHERE updated_at < $1`, before)
+	if err != nil {
+		r.log(ctx).Error("sql.Lockout.Purge: error exec", zap.Error(err))
+		return 0, err
+	}
+
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(purged), nil
+}
+
+func scanLockout(row *sql.Row) (ratelimit.LockoutState, error) {
+	var (
+		state       ratelimit.LockoutState
+		windowStart sql.NullTime
+		lockedUntil sql.NullTime
+	)
+	if err := row.Scan(&state.Failures, &windowStart, &state.Lockouts, &lockedUntil); err != nil {
+		return ratelimit.LockoutState{}, err
+	}
+
+	state.WindowStart = windowStart.Time
+	state.LockedUntil = lockedUntil.Time
+	return state, nil
+}
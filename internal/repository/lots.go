@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// lotPortion - часть партии монет, списанная при переводе или покупке
+type lotPortion struct {
+	currency  string
+	amount    domain.Money
+	expiresAt sql.NullTime
+}
+
+// addLot создает новую партию монет пользователя в валюте currency
+func addLot(ctx context.Context, tx *sql.Tx, userID int, currency string, amount domain.Money, expiresAt sql.NullTime) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO coin_lots (user_id, currency, amount, remaining, expires_at) VALUES ($1, $2, $3, $3, $4)`,
+		userID, currency, amount, expiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("add lot: %w", err)
+	}
+
+	return nil
+}
+
+// addLots зачисляет пользователю списанные у другого пользователя части партий с сохранением срока их жизни
+func addLots(ctx context.Context, tx *sql.Tx, userID int, portions []lotPortion) error {
+	for _, portion := range portions {
+		if err := addLot(ctx, tx, userID, portion.currency, portion.amount, portion.expiresAt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// consumeLots списывает amount из действующих партий пользователя в валюте currency: сначала те, что сгорят
+// раньше, бессрочные - последними, при равном сроке - в порядке начисления. Срок берется из партии,
+// а не из даты начисления, потому что полученные переводом монеты сохраняют срок отправителя
+func consumeLots(ctx context.Context, tx *sql.Tx, userID int, currency string, amount domain.Money) ([]lotPortion, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, remaining, expires_at
+         FROM coin_lots
+         WHERE user_id = $1 AND currency = $2 AND remaining > 0 AND (expires_at IS NULL OR expires_at > now())
+         ORDER BY expires_at NULLS LAST, granted_at, id
+         FOR UPDATE`,
+		userID, currency,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("select lots: %w", err)
+	}
+
+	type lot struct {
+		id        int
+		remaining domain.Money
+		expiresAt sql.NullTime
+	}
+
+	var lots []lot
+	for rows.Next() {
+		var l lot
+		if err := rows.Scan(&l.id, &l.remaining, &l.expiresAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan lot: %w", err)
+		}
+		lots = append(lots, l)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("lots rows: %w", err)
+	}
+
+	var portions []lotPortion
+	left := amount
+	for _, l := range lots {
+		if left == 0 {
+			break
+		}
+
+		take := min(l.remaining, left)
+		_, err := tx.ExecContext(ctx,
+			`UPDATE coin_lots SET remaining = remaining - $1 WHERE id = $2`,
+			take, l.id,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("consume lot: %w", err)
+		}
+
+		portions = append(portions, lotPortion{currency: currency, amount: take, expiresAt: l.expiresAt})
+		left -= take
+	}
+
+	if left > 0 {
+		return nil, erorrs.ErrInsufficientFunds
+	}
+
+	return portions, nil
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+
+	return sql.NullTime{Time: *t, Valid: true}
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	"context"
+	"database/sql"
+	"errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+// GetMFA возвращает второй фактор пользователя, в том числе неподтвержденный
+func (r *AuthRepo) GetMFA(ctx context.Context, userID int) (domain.MFA, error) {
+	mfa := domain.MFA{UserID: userID}
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT secret, confirmed_at, last_step FROM user_mfa WHERE user_id = $1`, userID,
+	).Scan(&mfa.Secret, &mfa.ConfirmedAt, &mfa.LastStep)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.MFA{}, erorrs.ErrMFANotEnrolled
+		}
+		r.log(ctx).Error("sql.Auth.GetMFA: error query", zap.Error(err))
+		return domain.MFA{}, err
+	}
+
+	return mfa, nil
+}
+
+// SaveMFASecret начинает подключение второго фактора заново с новым секретом.
+// Подтвержденный второй фактор не перезаписывается
+func (r *AuthRepo) SaveMFASecret(ctx context.Context, userID int, secret string) error {
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO user_mfa (user_id, secret) VALUES ($1, $2)
+         ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, created_at = CURRENT_TIMESTAMP, last_step = 0
+         WHERE user_mfa.confirmed_at IS NULL`,
+		userID, secret,
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.Auth.SaveMFASecret: error exec", zap.Error(err))
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.log(ctx).Error("sql.Auth.SaveMFASecret: error rows affected", zap.Error(err))
+		return err
+	}
+	if rows == 0 {
+		return erorrs.ErrMFAAlreadyEnabled
+	}
+
+	return nil
+}
+
+// EnableMFA подтверждает второй фактор, запоминает шаг кода подтверждения и заменяет коды восстановления
+func (r *AuthRepo) EnableMFA(ctx context.Context, userID int, step int64, recoveryHashes []string, at time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.log(ctx).Error("sql.Auth.EnableMFA: error begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE user_mfa SET confirmed_at = $2, last_step = $3 WHERE user_id = $1 AND confirmed_at IS NULL`,
+		userID, at, step,
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.Auth.EnableMFA: error update", zap.Error(err))
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.log(ctx).Error("sql.Auth.EnableMFA: error rows affected", zap.Error(err))
+		return err
+	}
+	if rows == 0 {
+		return erorrs.ErrMFAAlreadyEnabled
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		r.log(ctx).Error("sql.Auth.EnableMFA: error delete recovery codes", zap.Error(err))
+		return err
+	}
+
+	for _, hash := range recoveryHashes {
+		_, err = tx.ExecContext(ctx, `INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash)
+		if err != nil {
+			r.log(ctx).Error("sql.Auth.EnableMFA: error insert recovery code", zap.Error(err))
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.log(ctx).Error("sql.Auth.EnableMFA: error commit", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// UseTOTPStep запоминает принятый шаг TOTP. Возвращает false, если этот или более поздний шаг
+// уже был принят - то есть код переигрывается
+func (r *AuthRepo) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE user_mfa SET last_step = $2 WHERE user_id = $1 AND last_step < $2`,
+		userID, step,
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.Auth.UseTOTPStep: error exec", zap.Error(err))
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.log(ctx).Error("sql.Auth.UseTOTPStep: error rows affected", zap.Error(err))
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+// UseRecoveryCode гасит неиспользованный код восстановления. Возвращает false, если такого кода нет
+func (r *AuthRepo) UseRecoveryCode(ctx context.Context, userID int, hash string, at time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE mfa_recovery_codes SET used_at = $3 WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`,
+		userID, hash, at,
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.Auth.UseRecoveryCode: error exec", zap.Error(err))
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.log(ctx).Error("sql.Auth.UseRecoveryCode: error rows affected", zap.Error(err))
+		return false, err
+	}
+
+	return rows == 1, nil
+}
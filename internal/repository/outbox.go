@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"avito-shop/internal/events"
+	"avito-shop/internal/logger"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// outboxLockKey - ключ advisory-блокировки релея. Пока ее держит одна реплика, остальные
+// пропускают цикл, поэтому одну пачку не публикуют дважды
+const outboxLockKey = 0x6f7574626f78
+
+// outboxWriteLockKey - ключ advisory-блокировки записи в outbox. Транзакция берет ее перед
+// первой вставкой и держит до фиксации, поэтому id выдаются в порядке фиксации: пока событие
+// не зафиксировано, событий с большим id нет, и релей, читающий по возрастанию id, не обгонит его
+const outboxWriteLockKey = 0x6f7574626f79
+
+type OutboxRepo struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewOutboxRepo(db *sql.DB, logger logger.Logger) *OutboxRepo {
+	return &OutboxRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// log возвращает логгер запроса из ctx, а если его нет - логгер репозитория
+func (r *OutboxRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, r.logger)
+}
+
+// ProcessBatch передает в handle до limit неопубликованных событий по возрастанию id и отмечает
+// опубликованными первые n из них, где n - результат handle. Возвращает n, или 0, если
+// пачку в это время обрабатывает другая реплика
+func (r *OutboxRepo) ProcessBatch(ctx context.Context, limit int, handle func(ctx context.Context, batch []events.Event) int) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.log(ctx).Error("sql.Outbox.ProcessBatch: error begin transaction", zap.Error(err))
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err = tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, outboxLockKey).Scan(&locked); err != nil {
+		r.log(ctx).Error("sql.Outbox.ProcessBatch: error lock", zap.Error(err))
+		return 0, err
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, event_type, user_id, payload, created_at
+         FROM outbox
+         WHERE published_at IS NULL
+         ORDER BY id
+         LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.Outbox.ProcessBatch: error query", zap.Error(err))
+		return 0, err
+	}
+	defer rows.Close()
+
+	var batch []events.Event
+	for rows.Next() {
+		var (
+			event events.Event
+			data  []byte
+		)
+		if err := rows.Scan(&event.ID, &event.Type, &event.UserID, &data, &event.OccurredAt); err != nil {
+			r.log(ctx).Error("sql.Outbox.ProcessBatch: error scan", zap.Error(err))
+			return 0, err
+		}
+		event.Data = data
+		batch = append(batch, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log(ctx).Error("sql.Outbox.ProcessBatch: rows error", zap.Error(err))
+		return 0, err
+	}
+
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	published := handle(ctx, batch)
+	if published == 0 {
+		return 0, nil
+	}
+
+	ids := make([]int64, 0, published)
+	for _, event := range batch[:published] {
+		ids = append(ids, event.ID)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE outbox SET published_at = CURRENT_TIMESTAMP WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		r.log(ctx).Error("sql.Outbox.ProcessBatch: error mark published", zap.Error(err))
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.log(ctx).Error("sql.Outbox.ProcessBatch: error commit", zap.Error(err))
+		return 0, err
+	}
+
+	return published, nil
+}
+
+// writeOutbox записывает событие в outbox в транзакции tx, чтобы оно появилось тогда и только тогда,
+// когда транзакция зафиксирована. Блокировка записи держится до конца транзакции, поэтому
+// события пишутся последним шагом, когда все строки уже заблокированы
+func writeOutbox(ctx context.Context, tx *sql.Tx, eventType string, userID int, payload any) error {
+	event, err := events.New(eventType, userID, payload)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, outboxWriteLockKey); err != nil {
+		return fmt.Errorf("lock outbox: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO outbox (event_type, user_id, payload) VALUES ($1, $2, $3)`,
+		event.Type, event.UserID, string(event.Data),
+	)
+	return err
+}
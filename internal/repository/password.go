@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	"context"
+	"database/sql"
+	"errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+func (r *AuthRepo) UserExists(ctx context.Context, username string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE username = $1)`, username).Scan(&exists)
+	if err != nil {
+		r.log(ctx).Error("sql.Auth.UserExists: error query", zap.Error(err))
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// GetUserByID возвращает учетные данные пользователя без баланса
+func (r *AuthRepo) GetUserByID(ctx context.Context, userID int) (domain.User, error) {
+	user := domain.User{ID: userID}
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT u.username, u.password_hash, u.role, u.token_version, m.confirmed_at IS NOT NULL
+         FROM users u
+         LEFT JOIN user_mfa m ON m.user_id = u.id
+         WHERE u.id = $1`, userID,
+	).Scan(&user.Username, &user.PasswordHash, &user.Role, &user.TokenVersion, &user.MFAEnabled)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.User{}, erorrs.ErrNotFound
+		}
+		r.log(ctx).Error("sql.Auth.GetUserByID: error query", zap.Error(err))
+		return domain.User{}, err
+	}
+
+	return user, nil
+}
+
+func (r *AuthRepo) GetTokenVersion(ctx context.Context, userID int) (int, error) {
+	var version int
+	err := r.db.QueryRowContext(ctx, `SELECT token_version FROM users WHERE id = $1`, userID).Scan(&version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, erorrs.ErrNotFound
+		}
+		r.log(ctx).Error("sql.Auth.GetTokenVersion: error query", zap.Error(err))
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// UpdatePassword меняет пароль и версию токенов пользователя, гасит его неиспользованные
+// токены сброса пароля. Возвращает новую версию токенов
+func (r *AuthRepo) UpdatePassword(ctx context.Context, userID int, passwordHash string) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.log(ctx).Error("sql.Auth.UpdatePassword: error begin transaction", zap.Error(err))
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	version, err := setPassword(ctx, tx, userID, passwordHash, time.Now())
+	if err != nil {
+		if !errors.Is(err, erorrs.ErrNotFound) {
+			r.log(ctx).Error("sql.Auth.UpdatePassword: error update", zap.Error(err))
+		}
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.log(ctx).Error("sql.Auth.UpdatePassword: error commit", zap.Error(err))
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// CreateResetToken сохраняет токен сброса пароля пользователя token.Username
+func (r *AuthRepo) CreateResetToken(ctx context.Context, token domain.PasswordResetToken) (domain.PasswordResetToken, error) {
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO password_reset_tokens (user_id, token_hash, created_by, expires_at)
+         SELECT id, $2, $3, $4 FROM users WHERE username = $1
+         RETURNING id, user_id, created_at`,
+		token.Username, token.Hash, token.CreatedBy, token.ExpiresAt,
+	).Scan(&token.ID, &token.UserID, &token.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.log(ctx).Info("sql.Auth.CreateResetToken: user not found", zap.String("username", token.Username))
+			return domain.PasswordResetToken{}, erorrs.ErrNotFound
+		}
+		r.log(ctx).Error("sql.Auth.CreateResetToken: error insert", zap.Error(err))
+		return domain.PasswordResetToken{}, err
+	}
+
+	return token, nil
+}
+
+// ResetPassword задает пароль по неиспользованному и непросроченному токену сброса. Строка токена
+// блокируется до конца транзакции, поэтому одним токеном нельзя воспользоваться дважды
+func (r *AuthRepo) ResetPassword(ctx context.Context, tokenHash string, passwordHash string, at time.Time) (domain.User, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.log(ctx).Error("sql.Auth.ResetPassword: error begin transaction", zap.Error(err))
+		return domain.User{}, err
+	}
+	defer tx.Rollback()
+
+	var user domain.User
+	err = tx.QueryRowContext(ctx,
+		`SELECT t.user_id, u.username
+         FROM password_reset_tokens t
+         JOIN users u ON u.id = t.user_id
+         WHERE t.token_hash = $1 AND t.used_at IS NULL AND t.expires_at > $2
+         FOR UPDATE OF t`,
+		tokenHash, at,
+	).Scan(&user.ID, &user.Username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.User{}, erorrs.ErrResetTokenInvalid
+		}
+		r.log(ctx).Error("sql.Auth.ResetPassword: error select", zap.Error(err))
+		return domain.User{}, err
+	}
+
+	user.TokenVersion, err = setPassword(ctx, tx, user.ID, passwordHash, at)
+	if err != nil {
+		r.log(ctx).Error("sql.Auth.ResetPassword: error update", zap.Error(err))
+		return domain.User{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.log(ctx).Error("sql.Auth.ResetPassword: error commit", zap.Error(err))
+		return domain.User{}, err
+	}
+
+	return user, nil
+}
+
+// setPassword меняет пароль, увеличивает версию токенов и гасит неиспользованные токены сброса
+func setPassword(ctx context.Context, tx *sql.Tx, userID int, passwordHash string, at time.Time) (int, error) {
+	var version int
+	err := tx.QueryRowContext(ctx,
+		`UPDATE users SET password_hash = $2, token_version = token_version + 1 WHERE id = $1 RETURNING token_version`,
+		userID, passwordHash,
+	).Scan(&version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, erorrs.ErrNotFound
+		}
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE password_reset_tokens SET used_at = $2 WHERE user_id = $1 AND used_at IS NULL`,
+		userID, at,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
@@ -3,13 +3,16 @@ package repository
 import (
 	"avito-shop/internal/domain"
 	"avito-shop/internal/erorrs"
+	"avito-shop/internal/events"
 	"avito-shop/internal/logger"
 	"avito-shop/internal/model"
+	"avito-shop/internal/tracing"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"go.uber.org/zap"
+	"time"
 )
 
 type UserRepo struct {
@@ -24,110 +27,177 @@ func NewUserRepo(db *sql.DB, logger logger.Logger) *UserRepo {
 	}
 }
 
-func (r *UserRepo) SendCoins(ctx context.Context, fromUserID int, toUserID int, amount int) error {
+// log возвращает логгер запроса из ctx, а если его нет - логгер репозитория
+func (r *UserRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, r.logger)
+}
+
+// SendCoins переводит transfer.Amount получателю и transfer.Fee на счет казначейства в одной транзакции
+func (r *UserRepo) SendCoins(ctx context.Context, transfer domain.Transfer) error {
+	ctx, span := tracing.Start(ctx, "sql.User.SendCoins")
+	defer span.End()
+
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
 		Isolation: sql.LevelSerializable,
 	})
 	if err != nil {
-		r.logger.Error("sql.User.SendCoin: error begin transaction", zap.Error(err))
+		r.log(ctx).Error("sql.User.SendCoin: error begin transaction", zap.Error(err))
 		return err
 	}
 	defer tx.Rollback()
 
-	var currentBalance int
-	err = tx.QueryRowContext(ctx,
-		"SELECT balance FROM users WHERE id = $1 FOR UPDATE",
-		fromUserID,
-	).Scan(&currentBalance)
+	if err = ensureCurrency(ctx, tx, transfer.Currency); err != nil {
+		r.log(ctx).Error("sql.User.SendCoin: check currency", zap.Error(err))
+		return err
+	}
 
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			r.logger.Error("sql.User.SendCoin: no rows", zap.Error(err))
-			return erorrs.ErrNotFound
-		}
+	if err = lockUser(ctx, tx, transfer.FromUserID); err != nil {
+		r.log(ctx).Error("sql.User.SendCoin: lock sender", zap.Error(err))
 		return err
 	}
 
-	if currentBalance < amount {
-		r.logger.Error("sql.User.SendCoin: insufficient balance", zap.Error(err))
-		return erorrs.ErrInsufficientFunds
+	if err = debitWallet(ctx, tx, transfer.FromUserID, transfer.Currency, transfer.Amount+transfer.Fee); err != nil {
+		r.log(ctx).Error("sql.User.SendCoin: subtract balance", zap.Error(err))
+		return err
 	}
 
-	_, err = tx.ExecContext(ctx,
-		"UPDATE users SET balance = balance - $1 WHERE id = $2",
-		amount, fromUserID,
-	)
-	if err != nil {
-		r.logger.Error("sql.User.SendCoin: subtract balance", zap.Error(err))
+	if err = creditWallet(ctx, tx, transfer.ToUserID, transfer.Currency, transfer.Amount); err != nil {
+		r.log(ctx).Error("sql.User.SendCoin: add balance", zap.Error(err))
 		return err
 	}
 
-	_, err = tx.ExecContext(ctx,
-		"UPDATE users SET balance = balance + $1 WHERE id = $2",
-		amount, toUserID,
-	)
+	portions, err := consumeLots(ctx, tx, transfer.FromUserID, transfer.Currency, transfer.Amount)
 	if err != nil {
-		r.logger.Error("sql.User.SendCoin: add balance", zap.Error(err))
+		r.log(ctx).Error("sql.User.SendCoin: consume lots", zap.Error(err))
+		return err
+	}
+
+	if err = addLots(ctx, tx, transfer.ToUserID, portions); err != nil {
+		r.log(ctx).Error("sql.User.SendCoin: add lots", zap.Error(err))
 		return err
 	}
 
+	if transfer.Fee > 0 {
+		if err = r.collectFee(ctx, tx, transfer); err != nil {
+			r.log(ctx).Error("sql.User.SendCoin: collect fee", zap.Error(err))
+			return err
+		}
+	}
+
 	_, err = tx.ExecContext(ctx,
-		"INSERT INTO transfers (from_user_id, to_user_id, amount) VALUES ($1, $2, $3)",
-		fromUserID, toUserID, amount,
+		"INSERT INTO transfers (from_user_id, to_user_id, amount, fee, currency) VALUES ($1, $2, $3, $4, $5)",
+		transfer.FromUserID, transfer.ToUserID, transfer.Amount, transfer.Fee, transfer.Currency,
 	)
 	if err != nil {
-		r.logger.Error("sql.User.SendCoin: save transfer", zap.Error(err))
+		r.log(ctx).Error("sql.User.SendCoin: save transfer", zap.Error(err))
+		return err
+	}
+
+	if err = r.writeTransferEvents(ctx, tx, transfer); err != nil {
+		r.log(ctx).Error("sql.User.SendCoin: write outbox", zap.Error(err))
 		return err
 	}
 
 	return tx.Commit()
 }
 
+// writeTransferEvents сообщает участникам перевода об изменении балансов, а получателю - о поступлении монет
+func (r *UserRepo) writeTransferEvents(ctx context.Context, tx *sql.Tx, transfer domain.Transfer) error {
+	var sender string
+	if err := tx.QueryRowContext(ctx, `SELECT username FROM users WHERE id = $1`, transfer.FromUserID).Scan(&sender); err != nil {
+		return fmt.Errorf("select sender: %w", err)
+	}
+
+	err := writeOutbox(ctx, tx, events.TypeBalanceChanged, transfer.FromUserID, model.BalanceChangedEventDTO{
+		Currency: transfer.Currency,
+		Delta:    -(transfer.Amount + transfer.Fee),
+	})
+	if err == nil {
+		err = writeOutbox(ctx, tx, events.TypeCoinsReceived, transfer.ToUserID, model.CoinsReceivedEventDTO{
+			FromUser: sender,
+			Amount:   transfer.Amount,
+			Currency: transfer.Currency,
+		})
+	}
+	if err == nil {
+		err = writeOutbox(ctx, tx, events.TypeBalanceChanged, transfer.ToUserID, model.BalanceChangedEventDTO{
+			Currency: transfer.Currency,
+			Delta:    transfer.Amount,
+		})
+	}
+
+	return err
+}
+
+// collectFee списывает комиссию из партий отправителя и зачисляет ее на бессрочный счет казначейства
+func (r *UserRepo) collectFee(ctx context.Context, tx *sql.Tx, transfer domain.Transfer) error {
+	var treasuryID int
+	err := tx.QueryRowContext(ctx,
+		`SELECT id FROM users WHERE username = $1 AND role = $2`,
+		domain.TreasuryUsername, domain.RoleSystem,
+	).Scan(&treasuryID)
+	if err != nil {
+		return fmt.Errorf("select treasury: %w", err)
+	}
+
+	if _, err = consumeLots(ctx, tx, transfer.FromUserID, transfer.Currency, transfer.Fee); err != nil {
+		return err
+	}
+
+	if err = creditWallet(ctx, tx, treasuryID, transfer.Currency, transfer.Fee); err != nil {
+		return err
+	}
+
+	return addLot(ctx, tx, treasuryID, transfer.Currency, transfer.Fee, sql.NullTime{})
+}
+
 func (r *UserRepo) GetItem(ctx context.Context, itemName string) (domain.Item, error) {
+	ctx, span := tracing.Start(ctx, "sql.User.GetItem")
+	defer span.End()
+
 	var item domain.Item
 
 	err := r.db.QueryRowContext(ctx,
-		`SELECT id, name, price FROM items WHERE name = $1`,
+		`SELECT id, name, price, currency FROM items WHERE name = $1`,
 		itemName,
-	).Scan(&item.ID, &item.Name, &item.Price)
+	).Scan(&item.ID, &item.Name, &item.Price, &item.Currency)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			r.logger.Error("sql.User.GetItem: no rows", zap.Error(err))
+			r.log(ctx).Error("sql.User.GetItem: no rows", zap.Error(err))
 			return item, erorrs.ErrItemNotFound
 		}
-		r.logger.Error("sql.User.GetItem: error query row", zap.Error(err))
+		r.log(ctx).Error("sql.User.GetItem: error query row", zap.Error(err))
 		return item, err
 	}
 	return item, nil
 }
 
 func (r *UserRepo) BuyItem(ctx context.Context, user domain.User, item domain.Item) error {
+	ctx, span := tracing.Start(ctx, "sql.User.BuyItem")
+	defer span.End()
+
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
 		Isolation: sql.LevelSerializable,
 	})
 	if err != nil {
-		r.logger.Error("sql.User.ByItem: error begin transaction", zap.Error(err))
+		r.log(ctx).Error("sql.User.ByItem: error begin transaction", zap.Error(err))
 		return err
 	}
 	defer tx.Rollback()
 
-	res, err := tx.ExecContext(ctx,
-		`UPDATE users SET balance = balance - $1 WHERE id = $2`,
-		item.Price, user.ID,
-	)
-	if err != nil {
-		r.logger.Error("sql.User.ByItem: error exec", zap.Error(err))
+	if err = lockUser(ctx, tx, user.ID); err != nil {
+		r.log(ctx).Error("sql.User.ByItem: lock user", zap.Error(err))
 		return err
 	}
 
-	rows, err := res.RowsAffected()
-	if err != nil {
-		r.logger.Error("sql.User.ByItem: error rows affected", zap.Error(err))
+	if err = debitWallet(ctx, tx, user.ID, item.Currency, item.Price); err != nil {
+		r.log(ctx).Error("sql.User.ByItem: subtract balance", zap.Error(err))
 		return err
 	}
-	if rows == 0 {
-		r.logger.Error("sql.User.ByItem: no rows", zap.Error(err))
-		return erorrs.ErrNotFound
+
+	if _, err = consumeLots(ctx, tx, user.ID, item.Currency, item.Price); err != nil {
+		r.log(ctx).Error("sql.User.ByItem: consume lots", zap.Error(err))
+		return err
 	}
 
 	_, err = tx.ExecContext(ctx,
@@ -138,20 +208,39 @@ func (r *UserRepo) BuyItem(ctx context.Context, user domain.User, item domain.It
 		user.ID, item.ID, item.Name,
 	)
 	if err != nil {
-		r.logger.Error("sql.User.ByItem: error exec", zap.Error(err))
+		r.log(ctx).Error("sql.User.ByItem: error exec", zap.Error(err))
 		return fmt.Errorf("update purchases: %w", err)
 	}
 
+	err = writeOutbox(ctx, tx, events.TypePurchaseCompleted, user.ID, model.PurchaseCompletedEventDTO{
+		Item:     item.Name,
+		Price:    item.Price,
+		Currency: item.Currency,
+	})
+	if err == nil {
+		err = writeOutbox(ctx, tx, events.TypeBalanceChanged, user.ID, model.BalanceChangedEventDTO{
+			Currency: item.Currency,
+			Delta:    -item.Price,
+		})
+	}
+	if err != nil {
+		r.log(ctx).Error("sql.User.ByItem: write outbox", zap.Error(err))
+		return err
+	}
+
 	return tx.Commit()
 }
 
 func (r *UserRepo) GetPurchasedItems(ctx context.Context, userID int) ([]model.ItemDTO, error) {
+	ctx, span := tracing.Start(ctx, "sql.User.GetPurchasedItems")
+	defer span.End()
+
 	rows, err := r.db.QueryContext(ctx,
 		`SELECT item_name, quantity FROM purchases WHERE user_id = $1`,
 		userID,
 	)
 	if err != nil {
-		r.logger.Error("sql.User.GetPurchasedItems: error query", zap.Error(err))
+		r.log(ctx).Error("sql.User.GetPurchasedItems: error query", zap.Error(err))
 		return nil, err
 	}
 	defer rows.Close()
@@ -161,17 +250,17 @@ func (r *UserRepo) GetPurchasedItems(ctx context.Context, userID int) ([]model.I
 		var item model.ItemDTO
 		if err := rows.Scan(&item.Type, &item.Quantity); err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
-				r.logger.Error("sql.User.GetPurchasedItems: no rows", zap.Error(err))
+				r.log(ctx).Error("sql.User.GetPurchasedItems: no rows", zap.Error(err))
 				return items, sql.ErrNoRows
 			}
-			r.logger.Error("sql.User.GetPurchasedItems: error scan", zap.Error(err))
+			r.log(ctx).Error("sql.User.GetPurchasedItems: error scan", zap.Error(err))
 			return nil, err
 		}
 		items = append(items, item)
 	}
 
 	if err := rows.Err(); err != nil {
-		r.logger.Error("sql.User.GetPurchasedItems: rows error", zap.Error(err))
+		r.log(ctx).Error("sql.User.GetPurchasedItems: rows error", zap.Error(err))
 		return nil, err
 	}
 
@@ -179,18 +268,25 @@ func (r *UserRepo) GetPurchasedItems(ctx context.Context, userID int) ([]model.I
 }
 
 func (r *UserRepo) GetUser(ctx context.Context, userID int) (domain.User, error) {
+	ctx, span := tracing.Start(ctx, "sql.User.GetUser")
+	defer span.End()
+
 	var user domain.User
 
-	err := r.db.QueryRowContext(ctx, `SELECT id, username, balance FROM users WHERE id = $1`,
-		userID,
+	err := r.db.QueryRowContext(ctx,
+		`SELECT u.id, u.username, COALESCE(`+spendableBalance+`, 0)
+         FROM users u
+         LEFT JOIN wallets w ON w.user_id = u.id AND w.currency = $2
+         WHERE u.id = $1`,
+		userID, domain.DefaultCurrency,
 	).Scan(&user.ID, &user.Username, &user.Coins)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			r.logger.Error("sql.User.GetUser: user not found", zap.Error(err))
+			r.log(ctx).Error("sql.User.GetUser: user not found", zap.Error(err))
 			return domain.User{}, erorrs.ErrNotFound
 		}
-		r.logger.Error("sql.User.GetUser: error query", zap.Error(err))
+		r.log(ctx).Error("sql.User.GetUser: error query", zap.Error(err))
 		return user, err
 	}
 
@@ -198,11 +294,16 @@ func (r *UserRepo) GetUser(ctx context.Context, userID int) (domain.User, error)
 }
 
 func (r *UserRepo) GetCoinHistory(ctx context.Context, userID int, currentUsername string) (model.CoinHistoryDTO, error) {
+	ctx, span := tracing.Start(ctx, "sql.User.GetCoinHistory")
+	defer span.End()
+
 	rows, err := r.db.QueryContext(ctx,
 		`SELECT
             fu.username AS from_user,
             tu.username AS to_user,
-            t.amount
+            t.amount,
+            t.fee,
+            t.currency
          FROM transfers t
          JOIN users fu ON t.from_user_id = fu.id
          JOIN users tu ON t.to_user_id = tu.id
@@ -210,7 +311,7 @@ func (r *UserRepo) GetCoinHistory(ctx context.Context, userID int, currentUserna
 		userID,
 	)
 	if err != nil {
-		r.logger.Error("sql.User.GetCoinHistory: error query", zap.Error(err))
+		r.log(ctx).Error("sql.User.GetCoinHistory: error query", zap.Error(err))
 		return model.CoinHistoryDTO{}, err
 	}
 	defer rows.Close()
@@ -219,11 +320,11 @@ func (r *UserRepo) GetCoinHistory(ctx context.Context, userID int, currentUserna
 	var sent []model.TransactionHistoryDTO
 
 	for rows.Next() {
-		var fromUser, toUser string
-		var amount int
+		var fromUser, toUser, currency string
+		var amount, fee domain.Money
 
-		if err := rows.Scan(&fromUser, &toUser, &amount); err != nil {
-			r.logger.Error("sql.User.GetCoinHistory: error scan", zap.Error(err))
+		if err := rows.Scan(&fromUser, &toUser, &amount, &fee, &currency); err != nil {
+			r.log(ctx).Error("sql.User.GetCoinHistory: error scan", zap.Error(err))
 			return model.CoinHistoryDTO{}, err
 		}
 
@@ -231,27 +332,68 @@ func (r *UserRepo) GetCoinHistory(ctx context.Context, userID int, currentUserna
 			received = append(received, model.TransactionHistoryDTO{
 				FromUser: fromUser,
 				Amount:   amount,
+				Currency: currency,
 			})
 		} else if fromUser == currentUsername {
 			sent = append(sent, model.TransactionHistoryDTO{
-				ToUser: toUser,
-				Amount: amount,
+				ToUser:   toUser,
+				Amount:   amount,
+				Fee:      fee,
+				Currency: currency,
 			})
 		}
 	}
 
 	if err := rows.Err(); err != nil {
-		r.logger.Error("sql.User.GetCoinHistory: rows error", zap.Error(err))
+		r.log(ctx).Error("sql.User.GetCoinHistory: rows error", zap.Error(err))
+		return model.CoinHistoryDTO{}, err
+	}
+
+	operations, err := r.getOperationHistory(ctx, userID)
+	if err != nil {
 		return model.CoinHistoryDTO{}, err
 	}
 
 	return model.CoinHistoryDTO{
-		Received: received,
-		Sent:     sent,
+		Received:   received,
+		Sent:       sent,
+		Operations: operations,
 	}, nil
 }
 
+func (r *UserRepo) getOperationHistory(ctx context.Context, userID int) ([]model.OperationHistoryDTO, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT operation, amount, currency, reason FROM coin_operations WHERE user_id = $1 ORDER BY id`,
+		userID,
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.User.getOperationHistory: error query", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var operations []model.OperationHistoryDTO
+	for rows.Next() {
+		var operation model.OperationHistoryDTO
+		if err := rows.Scan(&operation.Type, &operation.Amount, &operation.Currency, &operation.Reason); err != nil {
+			r.log(ctx).Error("sql.User.getOperationHistory: error scan", zap.Error(err))
+			return nil, err
+		}
+		operations = append(operations, operation)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log(ctx).Error("sql.User.getOperationHistory: rows error", zap.Error(err))
+		return nil, err
+	}
+
+	return operations, nil
+}
+
 func (r *UserRepo) GetUserByName(ctx context.Context, toUser string) (int, error) {
+	ctx, span := tracing.Start(ctx, "sql.User.GetUserByName")
+	defer span.End()
+
 	var id int
 
 	err := r.db.QueryRowContext(ctx, `SELECT id FROM users WHERE username = $1`,
@@ -259,9 +401,201 @@ func (r *UserRepo) GetUserByName(ctx context.Context, toUser string) (int, error
 	).Scan(&id)
 
 	if err != nil {
-		r.logger.Error("sql.User.GetUserByName: error query", zap.Error(err))
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, erorrs.ErrNotFound
+		}
+		r.log(ctx).Error("sql.User.GetUserByName: error query", zap.Error(err))
 		return 0, err
 	}
 
 	return id, nil
 }
+
+func (r *UserRepo) GetExpiringCoins(ctx context.Context, userID int, before time.Time) ([]model.ExpiringCoinsDTO, error) {
+	ctx, span := tracing.Start(ctx, "sql.User.GetExpiringCoins")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT SUM(remaining), currency, expires_at
+         FROM coin_lots
+         WHERE user_id = $1 AND remaining > 0 AND expires_at > now() AND expires_at <= $2
+         GROUP BY currency, expires_at
+         ORDER BY expires_at, currency`,
+		userID, before,
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.User.GetExpiringCoins: error query", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expiring []model.ExpiringCoinsDTO
+	for rows.Next() {
+		var coins model.ExpiringCoinsDTO
+		if err := rows.Scan(&coins.Amount, &coins.Currency, &coins.ExpiresAt); err != nil {
+			r.log(ctx).Error("sql.User.GetExpiringCoins: error scan", zap.Error(err))
+			return nil, err
+		}
+		expiring = append(expiring, coins)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log(ctx).Error("sql.User.GetExpiringCoins: rows error", zap.Error(err))
+		return nil, err
+	}
+
+	return expiring, nil
+}
+
+// ExpireCoins обнуляет партии, срок жизни которых истек к моменту now, списывает их с кошельков
+// и записывает сгорание в историю, а владельцам - событие об изменении баланса. Возвращает количество
+// затронутых кошельков
+func (r *UserRepo) ExpireCoins(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := tracing.Start(ctx, "sql.User.ExpireCoins")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.log(ctx).Error("sql.User.ExpireCoins: error begin transaction", zap.Error(err))
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx,
+		`WITH expired AS (
+            UPDATE coin_lots l SET remaining = 0
+            FROM (
+                SELECT id, remaining FROM coin_lots
+                WHERE remaining > 0 AND expires_at <= $1
+                FOR UPDATE
+            ) old
+            WHERE l.id = old.id
+            RETURNING l.user_id, l.currency, old.remaining
+         ), per_wallet AS (
+            SELECT user_id, currency, SUM(remaining) AS amount FROM expired GROUP BY user_id, currency
+         ), balances AS (
+            UPDATE wallets w SET balance = w.balance - p.amount
+            FROM per_wallet p
+            WHERE w.user_id = p.user_id AND w.currency = p.currency
+         )
+         INSERT INTO coin_operations (user_id, operation, amount, currency, reason)
+         SELECT user_id, $2, -amount, currency, $3 FROM per_wallet
+         RETURNING user_id, currency, amount`,
+		now, domain.OperationExpire, "coins expired",
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.User.ExpireCoins: error exec", zap.Error(err))
+		return 0, err
+	}
+
+	type expiredWallet struct {
+		userID   int
+		currency string
+		delta    domain.Money
+	}
+	var expired []expiredWallet
+	for rows.Next() {
+		var wallet expiredWallet
+		if err := rows.Scan(&wallet.userID, &wallet.currency, &wallet.delta); err != nil {
+			rows.Close()
+			r.log(ctx).Error("sql.User.ExpireCoins: error scan", zap.Error(err))
+			return 0, err
+		}
+		expired = append(expired, wallet)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		r.log(ctx).Error("sql.User.ExpireCoins: rows error", zap.Error(err))
+		return 0, err
+	}
+
+	// lib/pq не выполняет запросы в транзакции, пока не дочитан курсор, поэтому события пишутся после
+	for _, wallet := range expired {
+		err = writeOutbox(ctx, tx, events.TypeBalanceChanged, wallet.userID, model.BalanceChangedEventDTO{
+			Currency: wallet.currency,
+			Delta:    wallet.delta,
+		})
+		if err != nil {
+			r.log(ctx).Error("sql.User.ExpireCoins: error write outbox", zap.Error(err))
+			return 0, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.log(ctx).Error("sql.User.ExpireCoins: error commit", zap.Error(err))
+		return 0, err
+	}
+
+	return len(expired), nil
+}
+
+func (r *UserRepo) GetWallets(ctx context.Context, userID int) ([]model.WalletDTO, error) {
+	ctx, span := tracing.Start(ctx, "sql.User.GetWallets")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT w.currency, `+spendableBalance+` FROM wallets w WHERE w.user_id = $1 ORDER BY w.currency`,
+		userID,
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.User.GetWallets: error query", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var wallets []model.WalletDTO
+	for rows.Next() {
+		var wallet model.WalletDTO
+		if err := rows.Scan(&wallet.Currency, &wallet.Balance); err != nil {
+			r.log(ctx).Error("sql.User.GetWallets: error scan", zap.Error(err))
+			return nil, err
+		}
+		wallets = append(wallets, wallet)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log(ctx).Error("sql.User.GetWallets: rows error", zap.Error(err))
+		return nil, err
+	}
+
+	return wallets, nil
+}
+
+// CheckCurrency возвращает ErrCurrencyNotFound, если валюты нет
+func (r *UserRepo) CheckCurrency(ctx context.Context, currency string) error {
+	ctx, span := tracing.Start(ctx, "sql.User.CheckCurrency")
+	defer span.End()
+
+	var code string
+	err := r.db.QueryRowContext(ctx, `SELECT code FROM currencies WHERE code = $1`, currency).Scan(&code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return erorrs.ErrCurrencyNotFound
+		}
+		r.log(ctx).Error("sql.User.CheckCurrency: error query", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *UserRepo) GetBalance(ctx context.Context, userID int, currency string) (domain.Money, error) {
+	ctx, span := tracing.Start(ctx, "sql.User.GetBalance")
+	defer span.End()
+
+	var balance domain.Money
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT `+spendableBalance+` FROM wallets w WHERE w.user_id = $1 AND w.currency = $2`,
+		userID, currency,
+	).Scan(&balance)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		r.log(ctx).Error("sql.User.GetBalance: error query", zap.Error(err))
+		return 0, err
+	}
+
+	return balance, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+)
+
+// spendableBalance - баланс кошелька w без партий, срок жизни которых истек, но которые еще
+// не обнулило задание сгорания: потратить их уже нельзя, поэтому они не учитываются ни при
+// списании, ни в отчетах
+const spendableBalance = `(w.balance - COALESCE((
+    SELECT SUM(l.remaining) FROM coin_lots l
+    WHERE l.user_id = w.user_id AND l.currency = w.currency AND l.remaining > 0 AND l.expires_at <= now()
+), 0))`
+
+// lockUser блокирует строку пользователя до конца транзакции, чтобы операции над его кошельками шли последовательно
+func lockUser(ctx context.Context, tx *sql.Tx, userID int) error {
+	var id int
+	err := tx.QueryRowContext(ctx, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return erorrs.ErrNotFound
+		}
+		return fmt.Errorf("lock user: %w", err)
+	}
+
+	return nil
+}
+
+// ensureCurrency проверяет, что валюта существует
+func ensureCurrency(ctx context.Context, tx *sql.Tx, currency string) error {
+	var code string
+	err := tx.QueryRowContext(ctx, `SELECT code FROM currencies WHERE code = $1`, currency).Scan(&code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return erorrs.ErrCurrencyNotFound
+		}
+		return fmt.Errorf("select currency: %w", err)
+	}
+
+	return nil
+}
+
+// creditWallet зачисляет amount на кошелек пользователя, создавая кошелек при необходимости
+func creditWallet(ctx context.Context, tx *sql.Tx, userID int, currency string, amount domain.Money) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO wallets (user_id, currency, balance) VALUES ($1, $2, $3)
+         ON CONFLICT (user_id, currency) DO UPDATE SET balance = wallets.balance + EXCLUDED.balance`,
+		userID, currency, amount,
+	)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return erorrs.ErrNotFound
+		}
+		return fmt.Errorf("credit wallet: %w", err)
+	}
+
+	return nil
+}
+
+// debitWallet списывает amount с кошелька пользователя, если на нем достаточно действующих средств
+func debitWallet(ctx context.Context, tx *sql.Tx, userID int, currency string, amount domain.Money) error {
+	res, err := tx.ExecContext(ctx,
+		`UPDATE wallets w SET balance = w.balance - $1
+         WHERE w.user_id = $2 AND w.currency = $3 AND `+spendableBalance+` >= $1`,
+		amount, userID, currency,
+	)
+	if err != nil {
+		return fmt.Errorf("debit wallet: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("debit wallet rows affected: %w", err)
+	}
+	if rows == 0 {
+		return erorrs.ErrInsufficientFunds
+	}
+
+	return nil
+}
@@ -0,0 +1,311 @@
+package repository
+
+import (
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	"avito-shop/internal/logger"
+	"avito-shop/internal/model"
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"time"
+)
+
+type WebhookRepo struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewWebhookRepo(db *sql.DB, logger logger.Logger) *WebhookRepo {
+	return &WebhookRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// log возвращает логгер запроса из ctx, а если его нет - логгер репозитория
+func (r *WebhookRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, r.logger)
+}
+
+func (r *WebhookRepo) CreateSubscription(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO webhook_subscriptions (url, secret, event_types, created_by)
+         VALUES ($1, $2, $3, $4)
+         RETURNING id, created_at`,
+		sub.URL, sub.Secret, pq.Array(sub.EventTypes), sub.CreatedBy,
+	).Scan(&sub.ID, &sub.CreatedAt)
+	if err != nil {
+		r.log(ctx).Error("sql.Webhook.CreateSubscription: error insert", zap.Error(err))
+		return domain.WebhookSubscription{}, err
+	}
+
+	return sub, nil
+}
+
+func (r *WebhookRepo) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, url, event_types, COALESCE(created_by, 0), created_at FROM webhook_subscriptions ORDER BY id`,
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.Webhook.ListSubscriptions: error query", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []domain.WebhookSubscription{}
+	for rows.Next() {
+		var sub domain.WebhookSubscription
+		if err := rows.Scan(&sub.ID, &sub.URL, pq.Array(&sub.EventTypes), &sub.CreatedBy, &sub.CreatedAt); err != nil {
+			r.log(ctx).Error("sql.Webhook.ListSubscriptions: error scan", zap.Error(err))
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log(ctx).Error("sql.Webhook.ListSubscriptions: rows error", zap.Error(err))
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+func (r *WebhookRepo) DeleteSubscription(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		r.log(ctx).Error("sql.Webhook.DeleteSubscription: error exec", zap.Error(err))
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.log(ctx).Error("sql.Webhook.DeleteSubscription: error rows affected", zap.Error(err))
+		return err
+	}
+	if rows == 0 {
+		return erorrs.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// EnqueueDeliveries ставит событие в очередь каждой подписке, ожидающей этот тип событий
+func (r *WebhookRepo) EnqueueDeliveries(ctx context.Context, eventType string, payload []byte) (int, error) {
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO webhook_deliveries (subscription_id, event_type, payload)
+         SELECT id, $1::text, $2::jsonb FROM webhook_subscriptions WHERE $1::text = ANY(event_types)`,
+		eventType, string(payload),
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.Webhook.EnqueueDeliveries: error insert", zap.Error(err))
+		return 0, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.log(ctx).Error("sql.Webhook.EnqueueDeliveries: error rows affected", zap.Error(err))
+		return 0, err
+	}
+
+	return int(rows), nil
+}
+
+// ClaimDueDeliveries забирает до limit доставок, время которых подошло, и откладывает их на lease,
+// чтобы другие реплики не отправили их одновременно. Если процесс упадет, доставка вернется в работу
+// по истечении lease
+func (r *WebhookRepo) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`UPDATE webhook_deliveries d
+         SET next_attempt_at = $2
+         FROM webhook_subscriptions s
+         WHERE s.id = d.subscription_id
+           AND d.id IN (
+               SELECT id FROM webhook_deliveries
+               WHERE status = 'pending' AND next_attempt_at <= $1
+               ORDER BY next_attempt_at
+               LIMIT $3
+               FOR UPDATE SKIP LOCKED
+           )
+         RETURNING d.id, d.subscription_id, s.url, s.secret, d.event_type, d.payload, d.attempts`,
+		now, now.Add(lease), limit,
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.Webhook.ClaimDueDeliveries: error query", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []domain.WebhookDelivery
+	for rows.Next() {
+		var d domain.WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.URL, &d.Secret, &d.EventType, &d.Payload, &d.Attempts); err != nil {
+			r.log(ctx).Error("sql.Webhook.ClaimDueDeliveries: error scan", zap.Error(err))
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log(ctx).Error("sql.Webhook.ClaimDueDeliveries: rows error", zap.Error(err))
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// RecordAttempt пишет попытку в журнал и переводит доставку в status. Для доставленных
+// nextAttemptAt считается временем доставки
+func (r *WebhookRepo) RecordAttempt(ctx context.Context, attempt domain.WebhookAttempt, status string, nextAttemptAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.log(ctx).Error("sql.Webhook.RecordAttempt: error begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO webhook_delivery_attempts (delivery_id, attempt, status_code, error, duration_ms)
+         VALUES ($1, $2, $3, $4, $5)`,
+		attempt.DeliveryID, attempt.Attempt, attempt.StatusCode, attempt.Error, attempt.Duration.Milliseconds(),
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.Webhook.RecordAttempt: save attempt", zap.Error(err))
+		return err
+	}
+
+	var deliveredAt *time.Time
+	if status == domain.WebhookDelivered {
+		deliveredAt = &nextAttemptAt
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE webhook_deliveries
+         SET status = $2, attempts = $3, next_attempt_at = $4, last_error = $5, delivered_at = $6
+         WHERE id = $1`,
+		attempt.DeliveryID, status, attempt.Attempt, nextAttemptAt, attempt.Error, nullTime(deliveredAt),
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.Webhook.RecordAttempt: update delivery", zap.Error(err))
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RequeueDelivery возвращает доставку из dead в очередь с обнуленным счетчиком попыток
+func (r *WebhookRepo) RequeueDelivery(ctx context.Context, subscriptionID int, deliveryID int64) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE webhook_deliveries
+         SET status = 'pending', attempts = 0, next_attempt_at = CURRENT_TIMESTAMP, last_error = ''
+         WHERE id = $1 AND subscription_id = $2 AND status = 'dead'`,
+		deliveryID, subscriptionID,
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.Webhook.RequeueDelivery: error exec", zap.Error(err))
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.log(ctx).Error("sql.Webhook.RequeueDelivery: error rows affected", zap.Error(err))
+		return err
+	}
+	if rows == 0 {
+		return erorrs.ErrDeliveryNotFound
+	}
+
+	return nil
+}
+
+// GetDeliveries возвращает последние limit доставок подписки вместе с журналом попыток
+func (r *WebhookRepo) GetDeliveries(ctx context.Context, subscriptionID int, limit int) ([]model.WebhookDeliveryDTO, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM webhook_subscriptions WHERE id = $1)`, subscriptionID).Scan(&exists)
+	if err != nil {
+		r.log(ctx).Error("sql.Webhook.GetDeliveries: error query subscription", zap.Error(err))
+		return nil, err
+	}
+	if !exists {
+		return nil, erorrs.ErrWebhookNotFound
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, event_type, status, attempts, next_attempt_at, last_error, created_at, delivered_at
+         FROM webhook_deliveries
+         WHERE subscription_id = $1
+         ORDER BY id DESC
+         LIMIT $2`,
+		subscriptionID, limit,
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.Webhook.GetDeliveries: error query deliveries", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []model.WebhookDeliveryDTO{}
+	index := make(map[int64]int)
+	var ids []int64
+	for rows.Next() {
+		var (
+			d           model.WebhookDeliveryDTO
+			deliveredAt sql.NullTime
+		)
+		if err := rows.Scan(&d.ID, &d.EventType, &d.Status, &d.Attempts, &d.NextAttemptAt, &d.LastError, &d.CreatedAt, &deliveredAt); err != nil {
+			r.log(ctx).Error("sql.Webhook.GetDeliveries: error scan delivery", zap.Error(err))
+			return nil, err
+		}
+		if deliveredAt.Valid {
+			d.DeliveredAt = &deliveredAt.Time
+		}
+		d.Log = []model.WebhookAttemptDTO{}
+
+		index[d.ID] = len(deliveries)
+		ids = append(ids, d.ID)
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log(ctx).Error("sql.Webhook.GetDeliveries: rows error", zap.Error(err))
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return deliveries, nil
+	}
+
+	attempts, err := r.db.QueryContext(ctx,
+		`SELECT delivery_id, attempt, status_code, error, duration_ms, created_at
+         FROM webhook_delivery_attempts
+         WHERE delivery_id = ANY($1)
+         ORDER BY delivery_id, id`,
+		pq.Array(ids),
+	)
+	if err != nil {
+		r.log(ctx).Error("sql.Webhook.GetDeliveries: error query attempts", zap.Error(err))
+		return nil, err
+	}
+	defer attempts.Close()
+
+	for attempts.Next() {
+		var (
+			deliveryID int64
+			a          model.WebhookAttemptDTO
+		)
+		if err := attempts.Scan(&deliveryID, &a.Attempt, &a.StatusCode, &a.Error, &a.DurationMs, &a.CreatedAt); err != nil {
+			r.log(ctx).Error("sql.Webhook.GetDeliveries: error scan attempt", zap.Error(err))
+			return nil, err
+		}
+		i := index[deliveryID]
+		deliveries[i].Log = append(deliveries[i].Log, a)
+	}
+
+	if err := attempts.Err(); err != nil {
+		r.log(ctx).Error("sql.Webhook.GetDeliveries: attempts rows error", zap.Error(err))
+		return nil, err
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	"avito-shop/internal/logger"
+	"avito-shop/internal/model"
+	"context"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=RepoAdminInterface
+type RepoAdminInterface interface {
+	GetUserRole(ctx context.Context, userID int) (string, error)
+	GetUserByName(ctx context.Context, username string) (int, error)
+	GetGroupMembers(ctx context.Context, group string) ([]int, error)
+	AddGroupMember(ctx context.Context, group string, userID int) error
+	RemoveGroupMember(ctx context.Context, group string, userID int) error
+	ApplyCoinOperation(ctx context.Context, userIDs []int, op domain.CoinOperation) error
+}
+
+type AdminService struct {
+	repo   RepoAdminInterface
+	audit  AuditRecorderInterface
+	logger logger.Logger
+	expiry domain.ExpiryPolicy
+}
+
+func NewAdminService(repo RepoAdminInterface, audit AuditRecorderInterface, logger logger.Logger, expiry domain.ExpiryPolicy) *AdminService {
+	return &AdminService{
+		repo:   repo,
+		audit:  audit,
+		logger: logger,
+		expiry: expiry,
+	}
+}
+
+// log возвращает логгер запроса из ctx, а если его нет - логгер сервиса
+func (s *AdminService) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, s.logger)
+}
+
+func (s *AdminService) IsAdmin(ctx context.Context, userID int) (bool, error) {
+	return s.HasRole(ctx, userID, domain.RoleAdmin)
+}
+
+func (s *AdminService) HasRole(ctx context.Context, userID int, role string) (bool, error) {
+	userRole, err := s.repo.GetUserRole(ctx, userID)
+	if err != nil {
+		s.log(ctx).Error("service.Admin.HasRole: error getting role", zap.Error(err))
+		return false, err
+	}
+
+	return userRole == role, nil
+}
+
+func (s *AdminService) MintCoins(ctx context.Context, actorID int, dto model.CoinOperationRequestDTO) (int, error) {
+	return s.applyCoinOperation(ctx, actorID, dto, domain.OperationMint, dto.Amount)
+}
+
+func (s *AdminService) BurnCoins(ctx context.Context, actorID int, dto model.CoinOperationRequestDTO) (int, error) {
+	return s.applyCoinOperation(ctx, actorID, dto, domain.OperationBurn, -dto.Amount)
+}
+
+func (s *AdminService) AddGroupMember(ctx context.Context, actorID int, group string, username string) error {
+	userID, err := s.repo.GetUserByName(ctx, username)
+	if err != nil {
+		s.log(ctx).Error("service.Admin.AddGroupMember: error getting user", zap.Error(err))
+		return err
+	}
+
+	if err := s.repo.AddGroupMember(ctx, group, userID); err != nil {
+		s.log(ctx).Error("service.Admin.AddGroupMember: error adding member", zap.Error(err))
+		return err
+	}
+
+	recordAudit(ctx, s.audit, s.log(ctx), domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditGroupAdd,
+		Target:  groupTarget(group),
+		Details: map[string]string{"username": username},
+	})
+	s.log(ctx).Info("group member added", zap.String("group", group), zap.Int("userID", userID))
+	return nil
+}
+
+func (s *AdminService) RemoveGroupMember(ctx context.Context, actorID int, group string, username string) error {
+	userID, err := s.repo.GetUserByName(ctx, username)
+	if err != nil {
+		s.log(ctx).Error("service.Admin.RemoveGroupMember: error getting user", zap.Error(err))
+		return err
+	}
+
+	if err := s.repo.RemoveGroupMember(ctx, group, userID); err != nil {
+		s.log(ctx).Error("service.Admin.RemoveGroupMember: error removing member", zap.Error(err))
+		return err
+	}
+
+	recordAudit(ctx, s.audit, s.log(ctx), domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditGroupRemove,
+		Target:  groupTarget(group),
+		Details: map[string]string{"username": username},
+	})
+	s.log(ctx).Info("group member removed", zap.String("group", group), zap.Int("userID", userID))
+	return nil
+}
+
+func (s *AdminService) applyCoinOperation(ctx context.Context, actorID int, dto model.CoinOperationRequestDTO, opType string, amount domain.Money) (int, error) {
+	userIDs, err := s.resolveTarget(ctx, dto)
+	if err != nil {
+		s.log(ctx).Error("service.Admin.applyCoinOperation: error resolving target", zap.Error(err))
+		return 0, err
+	}
+
+	if len(userIDs) == 0 {
+		s.log(ctx).Info("service.Admin.applyCoinOperation: group is empty", zap.String("group", dto.Group))
+		return 0, nil
+	}
+
+	op := domain.CoinOperation{
+		Type:     opType,
+		Currency: dto.Currency,
+		Amount:   amount,
+		Reason:   dto.Reason,
+		ActorID:  actorID,
+	}
+	if op.Currency == "" {
+		op.Currency = domain.DefaultCurrency
+	}
+	if amount > 0 {
+		op.ExpiresAt = s.expiry.ExpiresAt(time.Now())
+	}
+
+	if err := s.repo.ApplyCoinOperation(ctx, userIDs, op); err != nil {
+		s.log(ctx).Error("service.Admin.applyCoinOperation: error applying operation", zap.Error(err))
+		return 0, err
+	}
+
+	target := userTarget(dto.ToUser)
+	if dto.Group != "" {
+		target = groupTarget(dto.Group)
+	}
+	action := domain.AuditMint
+	if opType == domain.OperationBurn {
+		action = domain.AuditBurn
+	}
+	recordAudit(ctx, s.audit, s.log(ctx), domain.AuditEntry{
+		ActorID: actorID,
+		Action:  action,
+		Target:  target,
+		Details: map[string]string{
+			"amount":   dto.Amount.String(),
+			"currency": op.Currency,
+			"reason":   dto.Reason,
+			"affected": strconv.Itoa(len(userIDs)),
+		},
+	})
+
+	s.log(ctx).Info("coin operation applied",
+		zap.String("operation", opType),
+		zap.Int("actorID", actorID),
+		zap.Stringer("amount", amount),
+		zap.String("currency", op.Currency),
+		zap.Int("affected", len(userIDs)),
+		zap.String("reason", dto.Reason),
+	)
+
+	return len(userIDs), nil
+}
+
+func (s *AdminService) resolveTarget(ctx context.Context, dto model.CoinOperationRequestDTO) ([]int, error) {
+	switch {
+	case dto.ToUser != "" && dto.Group == "":
+		userID, err := s.repo.GetUserByName(ctx, dto.ToUser)
+		if err != nil {
+			return nil, err
+		}
+		return []int{userID}, nil
+	case dto.Group != "" && dto.ToUser == "":
+		return s.repo.GetGroupMembers(ctx, dto.Group)
+	default:
+		return nil, erorrs.ErrInvalidTarget
+	}
+}
@@ -0,0 +1,126 @@
+//go:build unit
+// +build unit
+
+package service
+
+import (
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	mocks2 "avito-shop/internal/logger/mocks"
+	"avito-shop/internal/model"
+	"avito-shop/internal/service/mocks"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"strconv"
+	"testing"
+)
+
+func TestAdminService_MintCoins(t *testing.T) {
+	tests := []struct {
+		name             string
+		dto              model.CoinOperationRequestDTO
+		mockRepo         func(*mocks.RepoAdminInterface)
+		expectedAffected int
+		expectedTarget   string
+		expectedError    error
+	}{
+		{
+			name: "mint to user",
+			dto:  model.CoinOperationRequestDTO{ToUser: "user1", Amount: 100, Reason: "bonus"},
+			mockRepo: func(m *mocks.RepoAdminInterface) {
+				m.On("GetUserByName", mock.Anything, "user1").Return(1, nil)
+				m.On("ApplyCoinOperation", mock.Anything, []int{1}, domain.CoinOperation{
+					Type: domain.OperationMint, Currency: domain.DefaultCurrency, Amount: 100, Reason: "bonus", ActorID: 10,
+				}).Return(nil)
+			},
+			expectedAffected: 1,
+			expectedTarget:   "user:user1",
+		},
+		{
+			name: "mint to group",
+			dto:  model.CoinOperationRequestDTO{Group: "backend", Amount: 50, Reason: "q3 bonus"},
+			mockRepo: func(m *mocks.RepoAdminInterface) {
+				m.On("GetGroupMembers", mock.Anything, "backend").Return([]int{1, 2, 3}, nil)
+				m.On("ApplyCoinOperation", mock.Anything, []int{1, 2, 3}, domain.CoinOperation{
+					Type: domain.OperationMint, Currency: domain.DefaultCurrency, Amount: 50, Reason: "q3 bonus", ActorID: 10,
+				}).Return(nil)
+			},
+			expectedAffected: 3,
+			expectedTarget:   "group:backend",
+		},
+		{
+			name:          "both user and group",
+			dto:           model.CoinOperationRequestDTO{ToUser: "user1", Group: "backend", Amount: 50, Reason: "bonus"},
+			mockRepo:      func(m *mocks.RepoAdminInterface) {},
+			expectedError: erorrs.ErrInvalidTarget,
+		},
+		{
+			name: "group not found",
+			dto:  model.CoinOperationRequestDTO{Group: "ghosts", Amount: 50, Reason: "bonus"},
+			mockRepo: func(m *mocks.RepoAdminInterface) {
+				m.On("GetGroupMembers", mock.Anything, "ghosts").Return(nil, erorrs.ErrGroupNotFound)
+			},
+			expectedError: erorrs.ErrGroupNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewRepoAdminInterface(t)
+			mockLogger := mocks2.NewLogger(t)
+			mockLogger.On("Info", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+			mockLogger.On("Error", mock.Anything, mock.Anything).Maybe()
+			tt.mockRepo(mockRepo)
+			mockAudit := mocks.NewAuditRecorderInterface(t)
+			if tt.expectedTarget != "" {
+				mockAudit.On("Record", mock.Anything, mock.MatchedBy(func(entry domain.AuditEntry) bool {
+					return entry.ActorID == 10 && entry.Action == domain.AuditMint && entry.Target == tt.expectedTarget &&
+						entry.Details["affected"] == strconv.Itoa(tt.expectedAffected)
+				})).Return(nil).Once()
+			}
+
+			adminService := NewAdminService(mockRepo, mockAudit, mockLogger, domain.ExpiryPolicy{})
+			affected, err := adminService.MintCoins(context.Background(), 10, tt.dto)
+
+			assert.Equal(t, tt.expectedError, err)
+			assert.Equal(t, tt.expectedAffected, affected)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAdminService_BurnCoins(t *testing.T) {
+	mockRepo := mocks.NewRepoAdminInterface(t)
+	mockLogger := mocks2.NewLogger(t)
+	mockLogger.On("Error", mock.Anything, mock.Anything).Maybe()
+
+	mockRepo.On("GetUserByName", mock.Anything, "user1").Return(1, nil)
+	mockRepo.On("ApplyCoinOperation", mock.Anything, []int{1}, domain.CoinOperation{
+		Type: domain.OperationBurn, Currency: domain.DefaultCurrency, Amount: -100, Reason: "correction", ActorID: 10,
+	}).Return(erorrs.ErrInsufficientFunds)
+
+	adminService := NewAdminService(mockRepo, mocks.NewAuditRecorderInterface(t), mockLogger, domain.ExpiryPolicy{})
+	affected, err := adminService.BurnCoins(context.Background(), 10, model.CoinOperationRequestDTO{
+		ToUser: "user1", Amount: 100, Reason: "correction",
+	})
+
+	assert.Equal(t, erorrs.ErrInsufficientFunds, err)
+	assert.Equal(t, 0, affected)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAdminService_HasRole(t *testing.T) {
+	mockRepo := mocks.NewRepoAdminInterface(t)
+	mockRepo.On("GetUserRole", mock.Anything, 7).Return(domain.RoleAuditor, nil)
+
+	adminService := NewAdminService(mockRepo, nil, mocks2.NewLogger(t), domain.ExpiryPolicy{})
+
+	isAuditor, err := adminService.HasRole(context.Background(), 7, domain.RoleAuditor)
+	assert.NoError(t, err)
+	assert.True(t, isAuditor)
+
+	isAdmin, err := adminService.IsAdmin(context.Background(), 7)
+	assert.NoError(t, err)
+	assert.False(t, isAdmin)
+}
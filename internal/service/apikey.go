@@ -0,0 +1,173 @@
+package service
+
+import (
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	"avito-shop/internal/logger"
+	"avito-shop/internal/model"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// apiKeyPrefix отличает ключи API от других секретов, например при поиске утечек в репозиториях
+const apiKeyPrefix = "shk_"
+
+// apiKeyDisplayLength - сколько первых символов ключа хранится открыто, чтобы различать ключи в списке
+const apiKeyDisplayLength = len(apiKeyPrefix) + 8
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=RepoAPIKeyInterface
+type RepoAPIKeyInterface interface {
+	CreateKey(ctx context.Context, key domain.APIKey) (domain.APIKey, error)
+	ListKeys(ctx context.Context) ([]domain.APIKey, error)
+	GetKeyByHash(ctx context.Context, hash string) (domain.APIKey, error)
+	RevokeKey(ctx context.Context, id int, at time.Time) error
+	TouchKey(ctx context.Context, id int, at time.Time) error
+}
+
+type APIKeyService struct {
+	repo   RepoAPIKeyInterface
+	audit  AuditRecorderInterface
+	logger logger.Logger
+}
+
+func NewAPIKeyService(repo RepoAPIKeyInterface, audit AuditRecorderInterface, logger logger.Logger) *APIKeyService {
+	return &APIKeyService{
+		repo:   repo,
+		audit:  audit,
+		logger: logger,
+	}
+}
+
+// log возвращает логгер запроса из ctx, а если его нет - логгер сервиса
+func (s *APIKeyService) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, s.logger)
+}
+
+// CreateKey выпускает ключ для пользователя. Ключ возвращается один раз, в бд хранится только его хеш
+func (s *APIKeyService) CreateKey(ctx context.Context, actorID int, dto model.APIKeyRequestDTO) (model.APIKeyDTO, error) {
+	scopes, unknown := normalizeScopes(dto.Scopes)
+	if unknown != "" {
+		s.log(ctx).Info("service.APIKey.CreateKey: unknown scope", zap.String("scope", unknown))
+		return model.APIKeyDTO{}, erorrs.ErrUnknownScope
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		s.log(ctx).Error("service.APIKey.CreateKey: error generating key", zap.Error(err))
+		return model.APIKeyDTO{}, err
+	}
+	plain := apiKeyPrefix + secret
+
+	key, err := s.repo.CreateKey(ctx, domain.APIKey{
+		Username:  dto.Username,
+		Name:      dto.Name,
+		Prefix:    plain[:apiKeyDisplayLength],
+		Hash:      hashSecret(plain),
+		Scopes:    scopes,
+		CreatedBy: actorID,
+		ExpiresAt: dto.ExpiresAt,
+	})
+	if err != nil {
+		s.log(ctx).Error("service.APIKey.CreateKey: error saving key", zap.Error(err))
+		return model.APIKeyDTO{}, err
+	}
+
+	recordAudit(ctx, s.audit, s.log(ctx), domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditAPIKeyCreate,
+		Target:  apiKeyTarget(key.ID),
+		Details: map[string]string{"username": key.Username, "name": key.Name, "scopes": strings.Join(key.Scopes, ",")},
+	})
+	s.log(ctx).Info("api key created", zap.Int("apiKeyID", key.ID), zap.Int("actorID", actorID))
+
+	result := apiKeyToDTO(key)
+	result.Key = plain
+	return result, nil
+}
+
+func (s *APIKeyService) ListKeys(ctx context.Context) ([]model.APIKeyDTO, error) {
+	keys, err := s.repo.ListKeys(ctx)
+	if err != nil {
+		s.log(ctx).Error("service.APIKey.ListKeys: error getting keys", zap.Error(err))
+		return nil, err
+	}
+
+	result := make([]model.APIKeyDTO, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, apiKeyToDTO(key))
+	}
+
+	return result, nil
+}
+
+func (s *APIKeyService) RevokeKey(ctx context.Context, actorID int, id int) error {
+	if err := s.repo.RevokeKey(ctx, id, time.Now()); err != nil {
+		s.log(ctx).Error("service.APIKey.RevokeKey: error revoking key", zap.Error(err))
+		return err
+	}
+
+	recordAudit(ctx, s.audit, s.log(ctx), domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditAPIKeyRevoke,
+		Target:  apiKeyTarget(id),
+	})
+	s.log(ctx).Info("api key revoked", zap.Int("apiKeyID", id), zap.Int("actorID", actorID))
+
+	return nil
+}
+
+// Authenticate находит действующий ключ и отмечает его использование. Неизвестный, отозванный
+// и просроченный ключи неразличимы для клиента
+func (s *APIKeyService) Authenticate(ctx context.Context, plain string) (domain.APIKey, error) {
+	if !strings.HasPrefix(plain, apiKeyPrefix) {
+		return domain.APIKey{}, erorrs.ErrAPIKeyInvalid
+	}
+
+	key, err := s.repo.GetKeyByHash(ctx, hashSecret(plain))
+	if err != nil {
+		if errors.Is(err, erorrs.ErrAPIKeyNotFound) {
+			return domain.APIKey{}, erorrs.ErrAPIKeyInvalid
+		}
+		s.log(ctx).Error("service.APIKey.Authenticate: error getting key", zap.Error(err))
+		return domain.APIKey{}, err
+	}
+
+	now := time.Now()
+	if !key.Active(now) {
+		s.log(ctx).Info("service.APIKey.Authenticate: inactive key", zap.Int("apiKeyID", key.ID))
+		return domain.APIKey{}, erorrs.ErrAPIKeyInvalid
+	}
+
+	// без отметки об использовании ключ остается рабочим, поэтому ошибка только пишется в лог
+	if err := s.repo.TouchKey(ctx, key.ID, now); err != nil {
+		s.log(ctx).Error("service.APIKey.Authenticate: error touching key", zap.Error(err))
+	}
+
+	return key, nil
+}
+
+// hashSecret хеширует ключ API, токен сброса пароля или код восстановления без соли: секрет случайный и длинный,
+// перебор по хешу бессмыслен, а детерминированный хеш позволяет искать секрет по индексу
+func hashSecret(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+func apiKeyToDTO(key domain.APIKey) model.APIKeyDTO {
+	return model.APIKeyDTO{
+		ID:         key.ID,
+		Username:   key.Username,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
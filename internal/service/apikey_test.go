@@ -0,0 +1,113 @@
+//go:build unit
+// +build unit
+
+package service
+
+import (
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	mocks2 "avito-shop/internal/logger/mocks"
+	"avito-shop/internal/model"
+	"avito-shop/internal/service/mocks"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyService_CreateKey(t *testing.T) {
+	mockRepo := mocks.NewRepoAPIKeyInterface(t)
+	mockAudit := mocks.NewAuditRecorderInterface(t)
+	mockLogger := mocks2.NewLogger(t)
+	mockLogger.On("Info", mock.Anything, mock.Anything, mock.Anything).Maybe()
+	s := NewAPIKeyService(mockRepo, mockAudit, mockLogger)
+
+	var saved domain.APIKey
+	mockRepo.On("CreateKey", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(domain.APIKey) }).
+		Return(func(_ context.Context, key domain.APIKey) domain.APIKey {
+			key.ID = 7
+			key.UserID = 3
+			return key
+		}, nil)
+	mockAudit.On("Record", mock.Anything, domain.AuditEntry{
+		ActorID: 1,
+		Action:  domain.AuditAPIKeyCreate,
+		Target:  "apikey:7",
+		Details: map[string]string{"username": "ci-bot", "name": "merged PRs", "scopes": "coins:send,info:read"},
+	}).Return(nil)
+
+	key, err := s.CreateKey(context.Background(), 1, model.APIKeyRequestDTO{
+		Username: "ci-bot",
+		Name:     "merged PRs",
+		Scopes:   []string{domain.ScopeInfoRead, domain.ScopeCoinsSend, domain.ScopeInfoRead},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(key.Key, apiKeyPrefix))
+	assert.Equal(t, key.Key[:apiKeyDisplayLength], key.Prefix)
+	assert.Equal(t, []string{domain.ScopeCoinsSend, domain.ScopeInfoRead}, key.Scopes)
+	assert.Equal(t, hashSecret(key.Key), saved.Hash)
+	assert.NotContains(t, saved.Hash, key.Key)
+}
+
+func TestAPIKeyService_CreateKey_UnknownScope(t *testing.T) {
+	mockLogger := mocks2.NewLogger(t)
+	mockLogger.On("Info", mock.Anything, mock.Anything).Maybe()
+	s := NewAPIKeyService(mocks.NewRepoAPIKeyInterface(t), nil, mockLogger)
+
+	_, err := s.CreateKey(context.Background(), 1, model.APIKeyRequestDTO{Username: "ci-bot", Name: "bot", Scopes: []string{"coins:mint"}})
+
+	assert.ErrorIs(t, err, erorrs.ErrUnknownScope)
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		plain       string
+		key         domain.APIKey
+		repoErr     error
+		touch       bool
+		expectedErr error
+	}{
+		{name: "active", plain: "shk_active", key: domain.APIKey{ID: 1, UserID: 3, Scopes: []string{domain.ScopeInfoRead}, ExpiresAt: &future}, touch: true},
+		{name: "revoked", plain: "shk_revoked", key: domain.APIKey{ID: 2, RevokedAt: &past}, expectedErr: erorrs.ErrAPIKeyInvalid},
+		{name: "expired", plain: "shk_expired", key: domain.APIKey{ID: 3, ExpiresAt: &past}, expectedErr: erorrs.ErrAPIKeyInvalid},
+		{name: "unknown", plain: "shk_unknown", repoErr: erorrs.ErrAPIKeyNotFound, expectedErr: erorrs.ErrAPIKeyInvalid},
+		{name: "foreign format", plain: "Bearer token", expectedErr: erorrs.ErrAPIKeyInvalid},
+		{name: "store failure", plain: "shk_any", repoErr: errors.New("connection reset"), expectedErr: errors.New("connection reset")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewRepoAPIKeyInterface(t)
+			mockLogger := mocks2.NewLogger(t)
+			mockLogger.On("Info", mock.Anything, mock.Anything).Maybe()
+			mockLogger.On("Error", mock.Anything, mock.Anything).Maybe()
+			s := NewAPIKeyService(mockRepo, nil, mockLogger)
+
+			if strings.HasPrefix(tt.plain, apiKeyPrefix) {
+				mockRepo.On("GetKeyByHash", mock.Anything, hashSecret(tt.plain)).Return(tt.key, tt.repoErr)
+			}
+			if tt.touch {
+				mockRepo.On("TouchKey", mock.Anything, tt.key.ID, mock.Anything).Return(nil)
+			}
+
+			key, err := s.Authenticate(context.Background(), tt.plain)
+
+			if tt.expectedErr != nil {
+				assert.EqualError(t, err, tt.expectedErr.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.key.UserID, key.UserID)
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"avito-shop/internal/audit"
+	"avito-shop/internal/domain"
+	"avito-shop/internal/logger"
+	"avito-shop/internal/metrics"
+	"avito-shop/internal/model"
+	"avito-shop/internal/tracing"
+	"context"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+)
+
+const (
+	auditDefaultLimit = 100
+	// auditVerifyBatch - по сколько записей читается журнал при проверке цепочки
+	auditVerifyBatch = 1000
+)
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=RepoAuditInterface
+type RepoAuditInterface interface {
+	Append(ctx context.Context, entry domain.AuditEntry) (domain.AuditEntry, error)
+	Query(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error)
+	ListAfter(ctx context.Context, afterID int64, limit int) ([]domain.AuditEntry, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=AuditRecorderInterface
+type AuditRecorderInterface interface {
+	Record(ctx context.Context, entry domain.AuditEntry) error
+}
+
+type AuditService struct {
+	repo   RepoAuditInterface
+	logger logger.Logger
+}
+
+func NewAuditService(repo RepoAuditInterface, logger logger.Logger) *AuditService {
+	return &AuditService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// log возвращает логгер запроса из ctx, а если его нет - логгер сервиса
+func (s *AuditService) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, s.logger)
+}
+
+// Record дописывает запись в журнал, дополняя ее временем и сведениями о запросе из ctx
+func (s *AuditService) Record(ctx context.Context, entry domain.AuditEntry) error {
+	ctx, span := tracing.Start(ctx, "service.Audit.Record")
+	defer span.End()
+
+	info := audit.RequestInfoFromContext(ctx)
+	entry.IP = info.IP
+	entry.UserAgent = info.UserAgent
+	entry.RequestID = info.RequestID
+	if entry.OccurredAt.IsZero() {
+		entry.OccurredAt = time.Now()
+	}
+
+	if _, err := s.repo.Append(ctx, entry); err != nil {
+		s.log(ctx).Error("service.Audit.Record: error append", zap.String("action", entry.Action), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s *AuditService) Query(ctx context.Context, dto model.AuditQueryDTO) ([]model.AuditEntryDTO, error) {
+	ctx, span := tracing.Start(ctx, "service.Audit.Query")
+	defer span.End()
+
+	filter := domain.AuditFilter{
+		ActorID:  dto.ActorID,
+		Action:   dto.Action,
+		Target:   dto.Target,
+		From:     dto.From,
+		To:       dto.To,
+		BeforeID: dto.BeforeID,
+		Limit:    dto.Limit,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = auditDefaultLimit
+	}
+
+	entries, err := s.repo.Query(ctx, filter)
+	if err != nil {
+		s.log(ctx).Error("service.Audit.Query: error query", zap.Error(err))
+		return nil, err
+	}
+
+	result := make([]model.AuditEntryDTO, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, model.AuditEntryDTO{
+			ID:         entry.ID,
+			OccurredAt: entry.OccurredAt,
+			ActorID:    entry.ActorID,
+			Action:     entry.Action,
+			Target:     entry.Target,
+			IP:         entry.IP,
+			UserAgent:  entry.UserAgent,
+			RequestID:  entry.RequestID,
+			Details:    entry.Details,
+			PrevHash:   entry.PrevHash,
+			Hash:       entry.Hash,
+		})
+	}
+
+	return result, nil
+}
+
+// Verify проверяет всю цепочку журнала и возвращает число проверенных записей.
+// При нарушении цепочки возвращается *audit.ChainError
+func (s *AuditService) Verify(ctx context.Context) (int, error) {
+	var (
+		checked  int
+		lastID   int64
+		lastHash string
+	)
+
+	for {
+		entries, err := s.repo.ListAfter(ctx, lastID, auditVerifyBatch)
+		if err != nil {
+			s.log(ctx).Error("service.Audit.Verify: error list", zap.Error(err))
+			return checked, err
+		}
+		if len(entries) == 0 {
+			return checked, nil
+		}
+
+		lastHash, err = audit.Verify(lastHash, entries)
+		if err != nil {
+			return checked, err
+		}
+
+		checked += len(entries)
+		lastID = entries[len(entries)-1].ID
+	}
+}
+
+// recordAudit пишет запись в журнал аудита. Действие к этому моменту уже выполнено,
+// поэтому ошибка журнала не возвращается вызывающему, а только логируется и считается в метриках
+func recordAudit(ctx context.Context, recorder AuditRecorderInterface, log logger.Logger, entry domain.AuditEntry) {
+	if err := recorder.Record(ctx, entry); err != nil {
+		log.Error("service.recordAudit: error writing audit log", zap.String("action", entry.Action), zap.Error(err))
+		metrics.AuditWriteFailed(entry.Action)
+	}
+}
+
+// userTarget, groupTarget, webhookTarget и apiKeyTarget задают вид поля target в журнале аудита
+func userTarget(username string) string {
+	return "user:" + username
+}
+
+func groupTarget(group string) string {
+	return "group:" + group
+}
+
+func webhookTarget(id int) string {
+	return "webhook:" + strconv.Itoa(id)
+}
+
+func apiKeyTarget(id int) string {
+	return "apikey:" + strconv.Itoa(id)
+}
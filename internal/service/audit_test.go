@@ -0,0 +1,85 @@
+//go:build unit
+// +build unit
+
+package service
+
+import (
+	"avito-shop/internal/audit"
+	"avito-shop/internal/domain"
+	mocks2 "avito-shop/internal/logger/mocks"
+	"avito-shop/internal/service/mocks"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestAuditService_Record(t *testing.T) {
+	mockRepo := mocks.NewRepoAuditInterface(t)
+	s := NewAuditService(mockRepo, mocks2.NewLogger(t))
+
+	ctx := audit.WithRequestInfo(context.Background(), audit.RequestInfo{IP: "10.0.0.1", UserAgent: "curl/8", RequestID: "req-1"})
+	mockRepo.On("Append", mock.Anything, mock.MatchedBy(func(entry domain.AuditEntry) bool {
+		return entry.Action == domain.AuditLogin && entry.ActorID == 5 &&
+			entry.IP == "10.0.0.1" && entry.UserAgent == "curl/8" && entry.RequestID == "req-1" &&
+			!entry.OccurredAt.IsZero()
+	})).Return(domain.AuditEntry{ID: 1}, nil).Once()
+
+	err := s.Record(ctx, domain.AuditEntry{ActorID: 5, Action: domain.AuditLogin, Target: "user:alice"})
+	assert.NoError(t, err)
+}
+
+func TestAuditService_Verify(t *testing.T) {
+	chain := make([]domain.AuditEntry, 0, auditVerifyBatch+2)
+	prev := ""
+	for i := 1; i <= auditVerifyBatch+2; i++ {
+		entry := domain.AuditEntry{
+			ID:         int64(i),
+			OccurredAt: audit.Timestamp(time.Now()),
+			ActorID:    i,
+			Action:     domain.AuditTransfer,
+			PrevHash:   prev,
+		}
+		entry.Hash = audit.Hash(prev, entry)
+		prev = entry.Hash
+		chain = append(chain, entry)
+	}
+
+	t.Run("intact chain is read in batches", func(t *testing.T) {
+		mockRepo := mocks.NewRepoAuditInterface(t)
+		mockRepo.On("ListAfter", mock.Anything, int64(0), auditVerifyBatch).Return(chain[:auditVerifyBatch], nil).Once()
+		mockRepo.On("ListAfter", mock.Anything, int64(auditVerifyBatch), auditVerifyBatch).Return(chain[auditVerifyBatch:], nil).Once()
+		mockRepo.On("ListAfter", mock.Anything, int64(auditVerifyBatch+2), auditVerifyBatch).Return([]domain.AuditEntry{}, nil).Once()
+
+		checked, err := NewAuditService(mockRepo, mocks2.NewLogger(t)).Verify(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, auditVerifyBatch+2, checked)
+	})
+
+	t.Run("tampered entry in second batch", func(t *testing.T) {
+		tampered := append([]domain.AuditEntry{}, chain[auditVerifyBatch:]...)
+		tampered[1].ActorID = 1
+
+		mockRepo := mocks.NewRepoAuditInterface(t)
+		mockRepo.On("ListAfter", mock.Anything, int64(0), auditVerifyBatch).Return(chain[:auditVerifyBatch], nil).Once()
+		mockRepo.On("ListAfter", mock.Anything, int64(auditVerifyBatch), auditVerifyBatch).Return(tampered, nil).Once()
+
+		checked, err := NewAuditService(mockRepo, mocks2.NewLogger(t)).Verify(context.Background())
+		var chainErr *audit.ChainError
+		require.True(t, errors.As(err, &chainErr))
+		assert.Equal(t, int64(auditVerifyBatch+2), chainErr.EntryID)
+		assert.Equal(t, auditVerifyBatch, checked)
+	})
+}
+
+func TestRecordAudit_FailureIsLogged(t *testing.T) {
+	mockAudit := mocks.NewAuditRecorderInterface(t)
+	mockLogger := mocks2.NewLogger(t)
+	mockAudit.On("Record", mock.Anything, mock.Anything).Return(errors.New("db down")).Once()
+	mockLogger.On("Error", "service.recordAudit: error writing audit log", mock.Anything, mock.Anything).Once()
+
+	recordAudit(context.Background(), mockAudit, mockLogger, domain.AuditEntry{Action: domain.AuditLogin})
+}
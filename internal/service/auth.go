@@ -1,15 +1,20 @@
 package service
 
 import (
+	"avito-shop/internal/audit"
 	"avito-shop/internal/domain"
 	"avito-shop/internal/erorrs"
 	"avito-shop/internal/logger"
+	"avito-shop/internal/metrics"
 	"avito-shop/internal/model"
+	"avito-shop/internal/ratelimit"
+	"avito-shop/internal/tracing"
 	"avito-shop/internal/utils"
 	"context"
 	"errors"
 	"github.com/golang-jwt/jwt"
 	"go.uber.org/zap"
+	"strings"
 	"time"
 )
 
@@ -17,90 +22,267 @@ const tokenTTL = 7 * time.Hour
 
 //go:generate go run github.com/vektra/mockery/v2@latest --name=RepoAuthInterface
 type RepoAuthInterface interface {
-	CreateUser(ctx context.Context, user domain.User) (int, error)
+	CreateUser(ctx context.Context, user domain.User, grant domain.CoinOperation) (int, error)
 	GetUser(ctx context.Context, username string, password string) (domain.User, error)
+	UserExists(ctx context.Context, username string) (bool, error)
+	GetUserByID(ctx context.Context, userID int) (domain.User, error)
+	GetTokenVersion(ctx context.Context, userID int) (int, error)
+	UpdatePassword(ctx context.Context, userID int, passwordHash string) (int, error)
+	CreateResetToken(ctx context.Context, token domain.PasswordResetToken) (domain.PasswordResetToken, error)
+	ResetPassword(ctx context.Context, tokenHash string, passwordHash string, at time.Time) (domain.User, error)
+	GetMFA(ctx context.Context, userID int) (domain.MFA, error)
+	SaveMFASecret(ctx context.Context, userID int, secret string) error
+	EnableMFA(ctx context.Context, userID int, step int64, recoveryHashes []string, at time.Time) error
+	UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID int, hash string, at time.Time) (bool, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=LoginGuardInterface
+type LoginGuardInterface interface {
+	Check(ctx context.Context, username string, ip string) (time.Duration, error)
+	Fail(ctx context.Context, username string, ip string) (time.Duration, error)
+	Succeed(ctx context.Context, username string) error
 }
 
 type AuthService struct {
-	repo   RepoAuthInterface
-	logger logger.Logger
+	repo         RepoAuthInterface
+	audit        AuditRecorderInterface
+	guard        LoginGuardInterface
+	logger       logger.Logger
+	welcomeGrant domain.Money
+	expiry       domain.ExpiryPolicy
+	password     domain.PasswordPolicy
 }
 
-func NewAuthService(repo RepoAuthInterface, logger logger.Logger) *AuthService {
+func NewAuthService(repo RepoAuthInterface, audit AuditRecorderInterface, guard LoginGuardInterface, logger logger.Logger, welcomeGrant domain.Money, expiry domain.ExpiryPolicy, password domain.PasswordPolicy) *AuthService {
 	return &AuthService{
-		repo:   repo,
-		logger: logger,
+		repo:         repo,
+		audit:        audit,
+		guard:        guard,
+		logger:       logger,
+		welcomeGrant: welcomeGrant,
+		expiry:       expiry,
+		password:     password,
 	}
 }
 
+// log возвращает логгер запроса из ctx, а если его нет - логгер сервиса
+func (s *AuthService) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, s.logger)
+}
+
 func (s *AuthService) Authorization(ctx context.Context, dto model.AuthRequestDTO) (string, error) {
+	ctx, span := tracing.Start(ctx, "service.Auth.Authorization")
+	defer span.End()
+
+	scopes, unknown := normalizeScopes(dto.Scopes)
+	if unknown != "" {
+		s.log(ctx).Info("service.Auth.Authorization: unknown scope", zap.String("scope", unknown))
+		return "", erorrs.ErrUnknownScope
+	}
+
+	ip := audit.RequestInfoFromContext(ctx).IP
+	if err := s.checkLockout(ctx, dto.Username, ip); err != nil {
+		return "", err
+	}
+
 	user, err := s.authenticateUser(ctx, dto)
 	switch {
 	case user != (domain.User{}):
-		return s.GenerateJwtToken(user.ID)
+		// счетчик неудач сбрасывается только после второго фактора, иначе верный пароль
+		// позволял бы перебирать коды без блокировки. Вход при этом еще не состоялся,
+		// auth.login запишет VerifyMFA
+		if user.MFAEnabled {
+			recordAudit(ctx, s.audit, s.log(ctx), domain.AuditEntry{
+				ActorID: user.ID,
+				Action:  domain.AuditMFAChallenge,
+				Target:  userTarget(dto.Username),
+			})
+			return "", s.challenge(ctx, user, scopes)
+		}
+		recordAudit(ctx, s.audit, s.log(ctx), domain.AuditEntry{
+			ActorID: user.ID,
+			Action:  domain.AuditLogin,
+			Target:  userTarget(dto.Username),
+		})
+		if err := s.guard.Succeed(ctx, dto.Username); err != nil {
+			s.log(ctx).Error("service.Auth.Authorization: error resetting failures", zap.Error(err))
+		}
+		return s.issueToken(ctx, user.ID, user.TokenVersion, dto.Username, scopes)
 	case user == (domain.User{}):
 		idReg, err := s.registerUser(ctx, dto)
 		if err != nil {
 			if errors.Is(err, erorrs.ErrUserExist) {
-				s.logger.Error("service.Auth.Authorization: user exist", zap.Error(err))
+				// пользователь есть, но пароль не подошел
+				recordAudit(ctx, s.audit, s.log(ctx), domain.AuditEntry{
+					Action: domain.AuditLoginFailed,
+					Target: userTarget(dto.Username),
+				})
+				s.registerFailure(ctx, dto.Username, ip)
+				s.log(ctx).Error("service.Auth.Authorization: user exist", zap.Error(err))
 				return "", erorrs.ErrUserExist
 			}
-			s.logger.Error("service.Auth.Authorization: authorization error", zap.Error(err))
+			s.log(ctx).Error("service.Auth.Authorization: authorization error", zap.Error(err))
 			return "", err
 		}
-		return s.GenerateJwtToken(idReg)
+		recordAudit(ctx, s.audit, s.log(ctx), domain.AuditEntry{
+			ActorID: idReg,
+			Action:  domain.AuditRegister,
+			Target:  userTarget(dto.Username),
+		})
+		return s.issueToken(ctx, idReg, 0, dto.Username, scopes)
 	default:
-		s.logger.Error("service.Auth.Authorization: authorization error", zap.Error(err))
+		s.log(ctx).Error("service.Auth.Authorization: authorization error", zap.Error(err))
 		return "", err
 	}
 
 }
 
+// checkLockout отклоняет попытку входа, пока имя пользователя или IP заблокированы.
+// Если хранилище блокировок недоступно, вход не блокируется
+func (s *AuthService) checkLockout(ctx context.Context, username string, ip string) error {
+	retryAfter, err := s.guard.Check(ctx, username, ip)
+	if err != nil {
+		s.log(ctx).Error("service.Auth.checkLockout: error checking lockout", zap.Error(err))
+		return nil
+	}
+
+	if retryAfter > 0 {
+		s.log(ctx).Info("service.Auth.checkLockout: attempt rejected", zap.Duration("retryAfter", retryAfter))
+		return &ratelimit.RetryAfterError{RetryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+// registerFailure учитывает неверный пароль и фиксирует блокировку, если она наступила
+func (s *AuthService) registerFailure(ctx context.Context, username string, ip string) {
+	retryAfter, err := s.guard.Fail(ctx, username, ip)
+	if err != nil {
+		s.log(ctx).Error("service.Auth.registerFailure: error counting failure", zap.Error(err))
+		return
+	}
+
+	if retryAfter > 0 {
+		metrics.AuthLockout()
+		recordAudit(ctx, s.audit, s.log(ctx), domain.AuditEntry{
+			Action:  domain.AuditLockout,
+			Target:  userTarget(username),
+			Details: map[string]string{"retryAfter": retryAfter.String()},
+		})
+	}
+}
+
 func (s *AuthService) authenticateUser(ctx context.Context, dto model.AuthRequestDTO) (domain.User, error) {
+	ctx, span := tracing.Start(ctx, "service.Auth.authenticateUser")
+	defer span.End()
+
 	username := dto.Username
 	passwordHash := utils.GeneratePasswordHash(dto.Password)
 
 	user, err := s.repo.GetUser(ctx, username, passwordHash)
 	if err != nil {
-		s.logger.Error("service.Auth.authenticateUser: authenticate error", zap.Error(err))
+		s.log(ctx).Error("service.Auth.authenticateUser: authenticate error", zap.Error(err))
 		return domain.User{}, err
 	}
 	if user == (domain.User{}) {
-		s.logger.Info("service.Auth.authenticateUser: user not found")
+		s.log(ctx).Info("service.Auth.authenticateUser: user not found")
 		return user, nil
 	}
 
-	s.logger.Info("user authenticated successfully")
+	s.log(ctx).Info("user authenticated successfully")
 
 	return user, nil
 }
 
 func (s *AuthService) registerUser(ctx context.Context, dto model.AuthRequestDTO) (int, error) {
+	ctx, span := tracing.Start(ctx, "service.Auth.registerUser")
+	defer span.End()
+
+	if strings.HasPrefix(dto.Username, domain.SystemUsernamePrefix) {
+		s.log(ctx).Info("service.Auth.registerUser: reserved username")
+		return 0, erorrs.ErrReservedName
+	}
+
+	if err := s.checkNewPassword(ctx, dto.Username, dto.Password); err != nil {
+		return 0, err
+	}
+
 	user := utils.AuthRequestToUser(dto)
 
 	user.PasswordHash = utils.GeneratePasswordHash(user.PasswordHash)
 
-	id, err := s.repo.CreateUser(ctx, user)
+	grant := domain.CoinOperation{
+		Type:      domain.OperationWelcome,
+		Currency:  domain.DefaultCurrency,
+		Amount:    s.welcomeGrant,
+		Reason:    "welcome grant",
+		ExpiresAt: s.expiry.ExpiresAt(time.Now()),
+	}
+
+	id, err := s.repo.CreateUser(ctx, user, grant)
 	if err != nil {
 		if errors.Is(err, erorrs.ErrUserExist) {
-			s.logger.Error("service.Auth.registerUser: email already exist", zap.Error(err))
+			s.log(ctx).Error("service.Auth.registerUser: email already exist", zap.Error(err))
 			return 0, erorrs.ErrUserExist
 		}
-		s.logger.Error("service.Auth.registerUser: error register", zap.Error(err))
+		s.log(ctx).Error("service.Auth.registerUser: error register", zap.Error(err))
 		return 0, err
 	}
 
-	s.logger.Info("user created successfully")
+	metrics.UserRegistered()
+	s.log(ctx).Info("user created successfully")
 	return id, nil
 }
 
-func (s *AuthService) GenerateJwtToken(userId int) (string, error) {
+// checkNewPassword проверяет пароль нового пользователя на соответствие политике. Слабый пароль
+// к уже занятому имени - это попытка входа с неверным паролем, а не регистрация, поэтому
+// для существующего пользователя возвращается ErrUserExist
+func (s *AuthService) checkNewPassword(ctx context.Context, username string, password string) error {
+	if s.password.Allows(password) {
+		return nil
+	}
+
+	exists, err := s.repo.UserExists(ctx, username)
+	if err != nil {
+		s.log(ctx).Error("service.Auth.checkNewPassword: error checking user", zap.Error(err))
+		return err
+	}
+	if exists {
+		return erorrs.ErrUserExist
+	}
+
+	s.log(ctx).Info("service.Auth.checkNewPassword: weak password")
+	return erorrs.ErrWeakPassword
+}
+
+func (s *AuthService) issueToken(ctx context.Context, userID int, version int, username string, scopes []string) (string, error) {
+	token, err := s.GenerateJwtToken(userID, version, scopes)
+	if err != nil {
+		s.log(ctx).Error("service.Auth.issueToken: error signing token", zap.Error(err))
+		return "", err
+	}
+
+	recordAudit(ctx, s.audit, s.log(ctx), domain.AuditEntry{
+		ActorID: userID,
+		Action:  domain.AuditTokenIssued,
+		Target:  userTarget(username),
+		Details: map[string]string{"ttl": tokenTTL.String(), "scopes": strings.Join(scopes, ",")},
+	})
+
+	return token, nil
+}
+
+func (s *AuthService) GenerateJwtToken(userId int, version int, scopes []string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &tokenClaims{
-		jwt.StandardClaims{
+		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: time.Now().Add(tokenTTL).Unix(),
 			IssuedAt:  time.Now().Unix(),
-		}, userId,
+		},
+		UserId:  userId,
+		Version: version,
+		Scopes:  scopes,
 	})
 
-	return token.SignedString([]byte("qrkjk#4#%35FSFJlja#4353KSFjH"))
+	return token.SignedString([]byte(signingKey))
 }
@@ -4,10 +4,12 @@
 package service
 
 import (
+	"avito-shop/internal/audit"
 	"avito-shop/internal/domain"
 	"avito-shop/internal/erorrs"
 	mocks2 "avito-shop/internal/logger/mocks"
 	"avito-shop/internal/model"
+	"avito-shop/internal/ratelimit"
 	"avito-shop/internal/service/mocks"
 	"avito-shop/internal/utils"
 	"context"
@@ -15,22 +17,28 @@ import (
 	"github.com/golang-jwt/jwt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"testing"
 	"time"
 )
 
+const welcomeGrant = 1000
+
 func TestAuthService_Authorization(t *testing.T) {
 	mockRepo := mocks.NewRepoAuthInterface(t)
+	mockAudit := mocks.NewAuditRecorderInterface(t)
+	mockGuard := mocks.NewLoginGuardInterface(t)
 	mockLogger := mocks2.NewLogger(t)
-	authService := NewAuthService(mockRepo, mockLogger)
+	authService := NewAuthService(mockRepo, mockAudit, mockGuard, mockLogger, welcomeGrant, domain.ExpiryPolicy{}, domain.PasswordPolicy{})
 
 	tests := []struct {
-		name          string
-		dto           model.AuthRequestDTO
-		mockRepo      func()
-		mockLogger    func()
-		expectToken   bool
-		expectedError error
+		name            string
+		dto             model.AuthRequestDTO
+		mockRepo        func()
+		mockLogger      func()
+		expectToken     bool
+		expectedError   error
+		expectedActions []string
 	}{
 		{
 			name: "successful authentication",
@@ -38,12 +46,14 @@ func TestAuthService_Authorization(t *testing.T) {
 			mockRepo: func() {
 				mockRepo.On("GetUser", mock.Anything, "user", utils.GeneratePasswordHash("pass")).
 					Return(domain.User{ID: 1}, nil)
+				mockGuard.On("Succeed", mock.Anything, "user").Return(nil).Once()
 			},
 			mockLogger: func() {
 				mockLogger.On("Info", mock.Anything, mock.Anything).Once()
 			},
-			expectToken:   true,
-			expectedError: nil,
+			expectToken:     true,
+			expectedError:   nil,
+			expectedActions: []string{domain.AuditLogin, domain.AuditTokenIssued},
 		},
 		{
 			name: "User not found, successful registration",
@@ -54,13 +64,19 @@ func TestAuthService_Authorization(t *testing.T) {
 				mockRepo.On("CreateUser", mock.Anything, mock.MatchedBy(func(u domain.User) bool {
 					return u.Username == "new_user" &&
 						u.PasswordHash == utils.GeneratePasswordHash("password")
-				})).Return(2, nil)
+				}), domain.CoinOperation{
+					Type:     domain.OperationWelcome,
+					Currency: domain.DefaultCurrency,
+					Amount:   welcomeGrant,
+					Reason:   "welcome grant",
+				}).Return(2, nil)
 			},
 			mockLogger: func() {
 				mockLogger.On("Info", mock.Anything)
 			},
-			expectToken:   true,
-			expectedError: nil,
+			expectToken:     true,
+			expectedError:   nil,
+			expectedActions: []string{domain.AuditRegister, domain.AuditTokenIssued},
 		},
 		{
 			name: "user exists during registration",
@@ -68,15 +84,17 @@ func TestAuthService_Authorization(t *testing.T) {
 			mockRepo: func() {
 				mockRepo.On("GetUser", mock.Anything, "exists", mock.Anything).
 					Return(domain.User{}, nil)
-				mockRepo.On("CreateUser", mock.Anything, mock.Anything).
+				mockRepo.On("CreateUser", mock.Anything, mock.Anything, mock.Anything).
 					Return(0, erorrs.ErrUserExist)
+				mockGuard.On("Fail", mock.Anything, "exists", "").Return(time.Duration(0), nil).Once()
 			},
 			mockLogger: func() { // Добавляем ожидание вызова Info
 				mockLogger.On("Info", mock.Anything, mock.Anything).Once()
 				mockLogger.On("Error", mock.Anything, mock.Anything).Twice()
 			},
-			expectToken:   false,
-			expectedError: erorrs.ErrUserExist,
+			expectToken:     false,
+			expectedError:   erorrs.ErrUserExist,
+			expectedActions: []string{domain.AuditLoginFailed},
 		},
 	}
 
@@ -84,9 +102,19 @@ func TestAuthService_Authorization(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo.ExpectedCalls = nil
 			mockLogger.ExpectedCalls = nil
+			mockAudit.ExpectedCalls = nil
+			mockGuard.ExpectedCalls = nil
+			mockGuard.On("Check", mock.Anything, tt.dto.Username, "").Return(time.Duration(0), nil).Once()
 			tt.mockRepo()
 			tt.mockLogger()
 
+			var actions []string
+			mockAudit.On("Record", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				entry := args.Get(1).(domain.AuditEntry)
+				assert.Equal(t, "user:"+tt.dto.Username, entry.Target)
+				actions = append(actions, entry.Action)
+			}).Return(nil)
+
 			token, err := authService.Authorization(context.Background(), tt.dto)
 
 			if tt.expectedError != nil {
@@ -102,16 +130,64 @@ func TestAuthService_Authorization(t *testing.T) {
 				assert.Empty(t, token)
 			}
 
+			assert.Equal(t, tt.expectedActions, actions)
 			mockRepo.AssertExpectations(t)
 			mockLogger.AssertExpectations(t)
 		})
 	}
 }
 
+func TestAuthService_Authorization_Lockout(t *testing.T) {
+	ctx := audit.WithRequestInfo(context.Background(), audit.RequestInfo{IP: "10.0.0.1"})
+	dto := model.AuthRequestDTO{Username: "victim", Password: "guess"}
+
+	t.Run("locked attempt does not reach the database", func(t *testing.T) {
+		mockGuard := mocks.NewLoginGuardInterface(t)
+		mockLogger := mocks2.NewLogger(t)
+		mockGuard.On("Check", mock.Anything, "victim", "10.0.0.1").Return(time.Minute, nil).Once()
+		mockLogger.On("Info", mock.Anything, mock.Anything).Once()
+
+		authService := NewAuthService(mocks.NewRepoAuthInterface(t), nil, mockGuard, mockLogger, welcomeGrant, domain.ExpiryPolicy{}, domain.PasswordPolicy{})
+		_, err := authService.Authorization(ctx, dto)
+
+		var retryErr *ratelimit.RetryAfterError
+		require.True(t, errors.As(err, &retryErr))
+		assert.Equal(t, time.Minute, retryErr.RetryAfter)
+		assert.ErrorIs(t, err, erorrs.ErrTooManyAttempts)
+	})
+
+	t.Run("failure that triggers lockout is audited", func(t *testing.T) {
+		mockRepo := mocks.NewRepoAuthInterface(t)
+		mockAudit := mocks.NewAuditRecorderInterface(t)
+		mockGuard := mocks.NewLoginGuardInterface(t)
+		mockLogger := mocks2.NewLogger(t)
+		mockLogger.On("Info", mock.Anything, mock.Anything).Maybe()
+		mockLogger.On("Error", mock.Anything, mock.Anything).Maybe()
+
+		mockGuard.On("Check", mock.Anything, "victim", "10.0.0.1").Return(time.Duration(0), nil).Once()
+		mockRepo.On("GetUser", mock.Anything, "victim", mock.Anything).Return(domain.User{}, nil).Once()
+		mockRepo.On("CreateUser", mock.Anything, mock.Anything, mock.Anything).Return(0, erorrs.ErrUserExist).Once()
+		mockGuard.On("Fail", mock.Anything, "victim", "10.0.0.1").Return(time.Minute, nil).Once()
+		mockAudit.On("Record", mock.Anything, mock.MatchedBy(func(entry domain.AuditEntry) bool {
+			return entry.Action == domain.AuditLoginFailed
+		})).Return(nil).Once()
+		mockAudit.On("Record", mock.Anything, domain.AuditEntry{
+			Action:  domain.AuditLockout,
+			Target:  "user:victim",
+			Details: map[string]string{"retryAfter": "1m0s"},
+		}).Return(nil).Once()
+
+		authService := NewAuthService(mockRepo, mockAudit, mockGuard, mockLogger, welcomeGrant, domain.ExpiryPolicy{}, domain.PasswordPolicy{})
+		_, err := authService.Authorization(ctx, dto)
+
+		assert.ErrorIs(t, err, erorrs.ErrUserExist)
+	})
+}
+
 func TestAuthService_AuthenticateUser(t *testing.T) {
 	mockRepo := mocks.NewRepoAuthInterface(t)
 	mockLogger := mocks2.NewLogger(t)
-	authService := NewAuthService(mockRepo, mockLogger)
+	authService := NewAuthService(mockRepo, nil, nil, mockLogger, welcomeGrant, domain.ExpiryPolicy{}, domain.PasswordPolicy{})
 
 	tests := []struct {
 		name          string
@@ -167,7 +243,7 @@ func TestAuthService_AuthenticateUser(t *testing.T) {
 func TestAuthService_RegisterUser(t *testing.T) {
 	mockRepo := mocks.NewRepoAuthInterface(t)
 	mockLogger := mocks2.NewLogger(t)
-	authService := NewAuthService(mockRepo, mockLogger)
+	authService := NewAuthService(mockRepo, nil, nil, mockLogger, welcomeGrant, domain.ExpiryPolicy{}, domain.PasswordPolicy{})
 
 	tests := []struct {
 		name          string
@@ -181,7 +257,9 @@ func TestAuthService_RegisterUser(t *testing.T) {
 			name: "successful registration",
 			dto:  model.AuthRequestDTO{Username: "new", Password: "pass"},
 			mockRepo: func() {
-				mockRepo.On("CreateUser", mock.Anything, utils.AuthRequestToUser(model.AuthRequestDTO{Username: "new", Password: utils.GeneratePasswordHash("pass")})).
+				mockRepo.On("CreateUser", mock.Anything, utils.AuthRequestToUser(model.AuthRequestDTO{Username: "new", Password: utils.GeneratePasswordHash("pass")}), mock.MatchedBy(func(grant domain.CoinOperation) bool {
+					return grant.Type == domain.OperationWelcome && grant.Amount == welcomeGrant
+				})).
 					Return(1, nil)
 			},
 			mockLogger: func() {
@@ -194,7 +272,7 @@ func TestAuthService_RegisterUser(t *testing.T) {
 			name: "user already exists",
 			dto:  model.AuthRequestDTO{Username: "exists", Password: "pass"},
 			mockRepo: func() {
-				mockRepo.On("CreateUser", mock.Anything, mock.Anything).
+				mockRepo.On("CreateUser", mock.Anything, mock.Anything, mock.Anything).
 					Return(0, erorrs.ErrUserExist)
 			},
 			mockLogger: func() {
@@ -203,6 +281,16 @@ func TestAuthService_RegisterUser(t *testing.T) {
 			expectedID:    0,
 			expectedError: erorrs.ErrUserExist,
 		},
+		{
+			name:     "reserved username",
+			dto:      model.AuthRequestDTO{Username: domain.TreasuryUsername, Password: "pass"},
+			mockRepo: func() {},
+			mockLogger: func() {
+				mockLogger.On("Info", "service.Auth.registerUser: reserved username")
+			},
+			expectedID:    0,
+			expectedError: erorrs.ErrReservedName,
+		},
 	}
 
 	for _, tt := range tests {
@@ -221,16 +309,18 @@ func TestAuthService_RegisterUser(t *testing.T) {
 }
 
 func TestAuthService_GenerateJwtToken(t *testing.T) {
-	authService := NewAuthService(nil, nil)
+	authService := NewAuthService(nil, nil, nil, nil, welcomeGrant, domain.ExpiryPolicy{}, domain.PasswordPolicy{})
 
 	tests := []struct {
 		name        string
 		userID      int
+		scopes      []string
 		checkClaims func(*testing.T, string)
 	}{
 		{
 			name:   "valid token generation",
 			userID: 123,
+			scopes: []string{domain.ScopeInfoRead},
 			checkClaims: func(t *testing.T, tokenString string) {
 				token, err := jwt.ParseWithClaims(tokenString, &tokenClaims{}, func(token *jwt.Token) (interface{}, error) {
 					return []byte("qrkjk#4#%35FSFJlja#4353KSFjH"), nil
@@ -241,6 +331,7 @@ func TestAuthService_GenerateJwtToken(t *testing.T) {
 
 				if claims, ok := token.Claims.(*tokenClaims); ok {
 					assert.Equal(t, 123, claims.UserId)
+					assert.Equal(t, []string{domain.ScopeInfoRead}, claims.Scopes)
 					assert.True(t, claims.ExpiresAt > time.Now().Unix())
 				} else {
 					t.Fatal("invalid claims type")
@@ -251,9 +342,49 @@ func TestAuthService_GenerateJwtToken(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			token, err := authService.GenerateJwtToken(tt.userID)
+			token, err := authService.GenerateJwtToken(tt.userID, 0, tt.scopes)
 			assert.NoError(t, err)
 			tt.checkClaims(t, token)
 		})
 	}
 }
+
+func TestParseToken_Scopes(t *testing.T) {
+	authService := NewAuthService(nil, nil, nil, nil, welcomeGrant, domain.ExpiryPolicy{}, domain.PasswordPolicy{})
+
+	readOnly, err := authService.GenerateJwtToken(7, 3, []string{domain.ScopeInfoRead})
+	require.NoError(t, err)
+	parsed, err := ParseToken(readOnly)
+	require.NoError(t, err)
+	assert.Equal(t, AccessToken{UserID: 7, Version: 3, Scopes: []string{domain.ScopeInfoRead}}, parsed)
+
+	// токены, выпущенные до появления прав в claims, дают все права
+	legacy, err := authService.GenerateJwtToken(7, 0, nil)
+	require.NoError(t, err)
+	parsed, err = ParseToken(legacy)
+	require.NoError(t, err)
+	assert.Equal(t, domain.Scopes, parsed.Scopes)
+}
+
+func TestNormalizeScopes(t *testing.T) {
+	scopes, unknown := normalizeScopes(nil)
+	assert.Empty(t, unknown)
+	assert.ElementsMatch(t, domain.Scopes, scopes)
+
+	scopes, unknown = normalizeScopes([]string{domain.ScopeShopBuy, domain.ScopeInfoRead, domain.ScopeShopBuy})
+	assert.Empty(t, unknown)
+	assert.Equal(t, []string{domain.ScopeInfoRead, domain.ScopeShopBuy}, scopes)
+
+	_, unknown = normalizeScopes([]string{domain.ScopeInfoRead, "coins:mint"})
+	assert.Equal(t, "coins:mint", unknown)
+}
+
+func TestAuthService_Authorization_UnknownScope(t *testing.T) {
+	mockLogger := mocks2.NewLogger(t)
+	mockLogger.On("Info", mock.Anything, mock.Anything).Once()
+	authService := NewAuthService(mocks.NewRepoAuthInterface(t), nil, mocks.NewLoginGuardInterface(t), mockLogger, welcomeGrant, domain.ExpiryPolicy{}, domain.PasswordPolicy{})
+
+	_, err := authService.Authorization(context.Background(), model.AuthRequestDTO{Username: "kiosk", Password: "pass", Scopes: []string{"coins:mint"}})
+
+	assert.ErrorIs(t, err, erorrs.ErrUnknownScope)
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"avito-shop/internal/audit"
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	"avito-shop/internal/model"
+	"avito-shop/internal/totp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+const (
+	mfaChallengeTTL   = 5 * time.Minute
+	mfaIssuer         = "avito-shop"
+	recoveryCodeCount = 10
+)
+
+// MFARequiredError - пароль верный, но для входа нужен второй фактор. Token обменивается
+// на токен доступа в VerifyMFA
+type MFARequiredError struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+func (e *MFARequiredError) Error() string {
+	return erorrs.ErrMFARequired.Error()
+}
+
+func (e *MFARequiredError) Unwrap() error {
+	return erorrs.ErrMFARequired
+}
+
+func (e *MFARequiredError) MFAChallenge() (string, time.Time) {
+	return e.Token, e.ExpiresAt
+}
+
+// challenge выпускает токен входа с правами scopes для пользователя с включенным вторым фактором
+func (s *AuthService) challenge(ctx context.Context, user domain.User, scopes []string) error {
+	expiresAt := time.Now().Add(mfaChallengeTTL)
+
+	token, err := signChallenge(user.ID, user.TokenVersion, scopes, expiresAt)
+	if err != nil {
+		s.log(ctx).Error("service.Auth.challenge: error signing challenge", zap.Error(err))
+		return err
+	}
+
+	s.log(ctx).Info("mfa challenge issued", zap.Int("userID", user.ID))
+	return &MFARequiredError{Token: token, ExpiresAt: expiresAt}
+}
+
+// EnrollMFA выдает новый секрет второго фактора. До подтверждения кодом вход работает по паролю,
+// повторный вызов заменяет секрет
+func (s *AuthService) EnrollMFA(ctx context.Context, userID int) (model.MFAEnrollmentDTO, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		s.log(ctx).Error("service.Auth.EnrollMFA: error getting user", zap.Error(err))
+		return model.MFAEnrollmentDTO{}, err
+	}
+	if user.MFAEnabled {
+		return model.MFAEnrollmentDTO{}, erorrs.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		s.log(ctx).Error("service.Auth.EnrollMFA: error generating secret", zap.Error(err))
+		return model.MFAEnrollmentDTO{}, err
+	}
+
+	if err := s.repo.SaveMFASecret(ctx, userID, secret); err != nil {
+		if errors.Is(err, erorrs.ErrMFAAlreadyEnabled) {
+			return model.MFAEnrollmentDTO{}, err
+		}
+		s.log(ctx).Error("service.Auth.EnrollMFA: error saving secret", zap.Error(err))
+		return model.MFAEnrollmentDTO{}, err
+	}
+
+	return model.MFAEnrollmentDTO{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(mfaIssuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmMFA включает второй фактор по коду из приложения и возвращает коды восстановления
+func (s *AuthService) ConfirmMFA(ctx context.Context, userID int, dto model.MFAConfirmRequestDTO) (model.MFARecoveryCodesDTO, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		s.log(ctx).Error("service.Auth.ConfirmMFA: error getting user", zap.Error(err))
+		return model.MFARecoveryCodesDTO{}, err
+	}
+
+	mfa, err := s.repo.GetMFA(ctx, userID)
+	if err != nil {
+		if errors.Is(err, erorrs.ErrMFANotEnrolled) {
+			return model.MFARecoveryCodesDTO{}, err
+		}
+		s.log(ctx).Error("service.Auth.ConfirmMFA: error getting mfa", zap.Error(err))
+		return model.MFARecoveryCodesDTO{}, err
+	}
+	if mfa.Enabled() {
+		return model.MFARecoveryCodesDTO{}, erorrs.ErrMFAAlreadyEnabled
+	}
+
+	now := time.Now()
+	step, ok := totp.Verify(mfa.Secret, dto.Code, now)
+	if !ok {
+		s.log(ctx).Info("service.Auth.ConfirmMFA: invalid code")
+		return model.MFARecoveryCodesDTO{}, erorrs.ErrMFACodeInvalid
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		s.log(ctx).Error("service.Auth.ConfirmMFA: error generating recovery codes", zap.Error(err))
+		return model.MFARecoveryCodesDTO{}, err
+	}
+
+	if err := s.repo.EnableMFA(ctx, userID, step, hashes, now); err != nil {
+		s.log(ctx).Error("service.Auth.ConfirmMFA: error enabling mfa", zap.Error(err))
+		return model.MFARecoveryCodesDTO{}, err
+	}
+
+	recordAudit(ctx, s.audit, s.log(ctx), domain.AuditEntry{
+		ActorID: userID,
+		Action:  domain.AuditMFAEnable,
+		Target:  userTarget(user.Username),
+	})
+	s.log(ctx).Info("mfa enabled", zap.Int("userID", userID))
+
+	return model.MFARecoveryCodesDTO{RecoveryCodes: codes}, nil
+}
+
+// VerifyMFA обменивает токен входа и код второго фактора на токен доступа. Подходит код из
+// приложения или неиспользованный код восстановления. Неверный код учитывается в блокировке
+// так же, как неверный пароль
+func (s *AuthService) VerifyMFA(ctx context.Context, dto model.MFAVerifyRequestDTO) (string, error) {
+	challenge, err := parseChallenge(dto.MFAToken)
+	if err != nil {
+		s.log(ctx).Info("service.Auth.VerifyMFA: invalid challenge", zap.Error(err))
+		return "", erorrs.ErrMFAChallengeInvalid
+	}
+
+	user, err := s.repo.GetUserByID(ctx, challenge.UserID)
+	if err != nil {
+		if errors.Is(err, erorrs.ErrNotFound) {
+			return "", erorrs.ErrMFAChallengeInvalid
+		}
+		s.log(ctx).Error("service.Auth.VerifyMFA: error getting user", zap.Error(err))
+		return "", err
+	}
+	// пароль сменили после входа по паролю
+	if user.TokenVersion != challenge.Version {
+		s.log(ctx).Info("service.Auth.VerifyMFA: revoked challenge", zap.Int("userID", user.ID))
+		return "", erorrs.ErrMFAChallengeInvalid
+	}
+
+	ip := audit.RequestInfoFromContext(ctx).IP
+	if err := s.checkLockout(ctx, user.Username, ip); err != nil {
+		return "", err
+	}
+
+	method, err := s.checkSecondFactor(ctx, user.ID, dto.Code)
+	if err != nil {
+		if errors.Is(err, erorrs.ErrMFACodeInvalid) {
+			recordAudit(ctx, s.audit, s.log(ctx), domain.AuditEntry{
+				ActorID: user.ID,
+				Action:  domain.AuditLoginFailed,
+				Target:  userTarget(user.Username),
+			})
+			s.registerFailure(ctx, user.Username, ip)
+			s.log(ctx).Info("service.Auth.VerifyMFA: invalid code")
+		}
+		return "", err
+	}
+
+	if err := s.guard.Succeed(ctx, user.Username); err != nil {
+		s.log(ctx).Error("service.Auth.VerifyMFA: error resetting failures", zap.Error(err))
+	}
+	recordAudit(ctx, s.audit, s.log(ctx), domain.AuditEntry{
+		ActorID: user.ID,
+		Action:  domain.AuditLogin,
+		Target:  userTarget(user.Username),
+		Details: map[string]string{"method": method},
+	})
+
+	return s.issueToken(ctx, user.ID, user.TokenVersion, user.Username, challenge.Scopes)
+}
+
+// checkSecondFactor проверяет код и гасит его: шаг TOTP нельзя принять дважды, код
+// восстановления - использовать повторно. Возвращает способ подтверждения
+func (s *AuthService) checkSecondFactor(ctx context.Context, userID int, code string) (string, error) {
+	mfa, err := s.repo.GetMFA(ctx, userID)
+	if err != nil {
+		if errors.Is(err, erorrs.ErrMFANotEnrolled) {
+			return "", erorrs.ErrMFAChallengeInvalid
+		}
+		s.log(ctx).Error("service.Auth.checkSecondFactor: error getting mfa", zap.Error(err))
+		return "", err
+	}
+	if !mfa.Enabled() {
+		return "", erorrs.ErrMFAChallengeInvalid
+	}
+
+	now := time.Now()
+	if step, ok := totp.Verify(mfa.Secret, code, now); ok {
+		used, err := s.repo.UseTOTPStep(ctx, userID, step)
+		if err != nil {
+			s.log(ctx).Error("service.Auth.checkSecondFactor: error using totp step", zap.Error(err))
+			return "", err
+		}
+		if !used {
+			s.log(ctx).Info("service.Auth.checkSecondFactor: totp code replayed", zap.Int("userID", userID))
+			return "", erorrs.ErrMFACodeInvalid
+		}
+		return "totp", nil
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, userID, hashSecret(normalizeRecoveryCode(code)), now)
+	if err != nil {
+		s.log(ctx).Error("service.Auth.checkSecondFactor: error using recovery code", zap.Error(err))
+		return "", err
+	}
+	if !used {
+		return "", erorrs.ErrMFACodeInvalid
+	}
+
+	s.log(ctx).Info("recovery code used", zap.Int("userID", userID))
+	return "recovery", nil
+}
+
+// generateRecoveryCodes возвращает коды восстановления в виде для пользователя и их хеши для бд
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	buf := make([]byte, 8)
+	for range recoveryCodeCount {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		code := hex.EncodeToString(buf)
+		codes = append(codes, code[:4]+"-"+code[4:8]+"-"+code[8:12]+"-"+code[12:])
+		hashes = append(hashes, hashSecret(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode допускает код без дефиса, в верхнем регистре и с пробелами по краям
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
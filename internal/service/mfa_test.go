@@ -0,0 +1,283 @@
+//go:build unit
+// +build unit
+
+package service
+
+import (
+	"avito-shop/internal/audit"
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	mocks2 "avito-shop/internal/logger/mocks"
+	"avito-shop/internal/model"
+	"avito-shop/internal/service/mocks"
+	"avito-shop/internal/totp"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testMFASecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func currentTOTP(t *testing.T) string {
+	code, err := totp.Code(testMFASecret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	return code
+}
+
+func TestAuthService_Authorization_MFARequired(t *testing.T) {
+	ctx := audit.WithRequestInfo(context.Background(), audit.RequestInfo{IP: "10.0.0.1"})
+
+	mockRepo := mocks.NewRepoAuthInterface(t)
+	mockGuard := mocks.NewLoginGuardInterface(t)
+	mockAudit := mocks.NewAuditRecorderInterface(t)
+	mockLogger := mocks2.NewLogger(t)
+	mockLogger.On("Info", mock.Anything, mock.Anything).Maybe()
+
+	mockGuard.On("Check", mock.Anything, "alice", "10.0.0.1").Return(time.Duration(0), nil)
+	mockRepo.On("GetUser", mock.Anything, "alice", mock.Anything).
+		Return(domain.User{ID: 1, Username: "alice", TokenVersion: 2, MFAEnabled: true}, nil)
+	mockAudit.On("Record", mock.Anything, domain.AuditEntry{ActorID: 1, Action: domain.AuditMFAChallenge, Target: "user:alice"}).Return(nil)
+
+	authService := NewAuthService(mockRepo, mockAudit, mockGuard, mockLogger, welcomeGrant, domain.ExpiryPolicy{}, testPasswordPolicy)
+	token, err := authService.Authorization(ctx, model.AuthRequestDTO{Username: "alice", Password: "pass", Scopes: []string{domain.ScopeInfoRead}})
+
+	assert.Empty(t, token)
+	assert.ErrorIs(t, err, erorrs.ErrMFARequired)
+	var required *MFARequiredError
+	require.True(t, errors.As(err, &required))
+
+	// токен входа не дает доступа, пока не обменян на токен доступа
+	_, err = ParseToken(required.Token)
+	assert.Error(t, err)
+
+	challenge, err := parseChallenge(required.Token)
+	require.NoError(t, err)
+	assert.Equal(t, AccessToken{UserID: 1, Version: 2, Scopes: []string{domain.ScopeInfoRead}}, challenge)
+
+	// счетчик неудач сбрасывается только после второго фактора
+	mockGuard.AssertNotCalled(t, "Succeed", mock.Anything, mock.Anything)
+}
+
+func TestAuthService_EnrollMFA(t *testing.T) {
+	mockRepo := mocks.NewRepoAuthInterface(t)
+	mockRepo.On("GetUserByID", mock.Anything, 1).Return(domain.User{ID: 1, Username: "alice"}, nil)
+	mockRepo.On("GetUserByID", mock.Anything, 2).Return(domain.User{ID: 2, Username: "bob", MFAEnabled: true}, nil)
+	mockRepo.On("SaveMFASecret", mock.Anything, 1, mock.Anything).Return(nil)
+
+	authService := NewAuthService(mockRepo, nil, nil, nil, welcomeGrant, domain.ExpiryPolicy{}, testPasswordPolicy)
+
+	enrollment, err := authService.EnrollMFA(context.Background(), 1)
+	require.NoError(t, err)
+	assert.NotEmpty(t, enrollment.Secret)
+	assert.Equal(t, totp.URI(mfaIssuer, "alice", enrollment.Secret), enrollment.OTPAuthURI)
+	mockRepo.AssertCalled(t, "SaveMFASecret", mock.Anything, 1, enrollment.Secret)
+
+	_, err = authService.EnrollMFA(context.Background(), 2)
+	assert.ErrorIs(t, err, erorrs.ErrMFAAlreadyEnabled)
+}
+
+func TestAuthService_ConfirmMFA(t *testing.T) {
+	tests := []struct {
+		name          string
+		mfa           domain.MFA
+		mfaErr        error
+		code          func(t *testing.T) string
+		expectedError error
+	}{
+		{name: "confirmed", mfa: domain.MFA{UserID: 1, Secret: testMFASecret}, code: currentTOTP},
+		{
+			name:          "wrong code",
+			mfa:           domain.MFA{UserID: 1, Secret: testMFASecret},
+			code:          func(*testing.T) string { return "000000" },
+			expectedError: erorrs.ErrMFACodeInvalid,
+		},
+		{
+			name:          "not enrolled",
+			mfaErr:        erorrs.ErrMFANotEnrolled,
+			code:          currentTOTP,
+			expectedError: erorrs.ErrMFANotEnrolled,
+		},
+		{
+			name:          "already enabled",
+			mfa:           domain.MFA{UserID: 1, Secret: testMFASecret, ConfirmedAt: &time.Time{}},
+			code:          currentTOTP,
+			expectedError: erorrs.ErrMFAAlreadyEnabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewRepoAuthInterface(t)
+			mockAudit := mocks.NewAuditRecorderInterface(t)
+			mockLogger := mocks2.NewLogger(t)
+			mockLogger.On("Info", mock.Anything, mock.Anything).Maybe()
+
+			mockRepo.On("GetUserByID", mock.Anything, 1).Return(domain.User{ID: 1, Username: "alice"}, nil)
+			mockRepo.On("GetMFA", mock.Anything, 1).Return(tt.mfa, tt.mfaErr)
+			if tt.expectedError == nil {
+				mockRepo.On("EnableMFA", mock.Anything, 1, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				mockAudit.On("Record", mock.Anything, domain.AuditEntry{ActorID: 1, Action: domain.AuditMFAEnable, Target: "user:alice"}).Return(nil)
+			}
+
+			authService := NewAuthService(mockRepo, mockAudit, nil, mockLogger, welcomeGrant, domain.ExpiryPolicy{}, testPasswordPolicy)
+			codes, err := authService.ConfirmMFA(context.Background(), 1, model.MFAConfirmRequestDTO{Code: tt.code(t)})
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				mockRepo.AssertNotCalled(t, "EnableMFA", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, codes.RecoveryCodes, recoveryCodeCount)
+
+			// в бд уходят только хеши, и по ним находится код, введенный пользователем
+			hashes := mockRepo.Calls[len(mockRepo.Calls)-1].Arguments.Get(3).([]string)
+			require.Len(t, hashes, recoveryCodeCount)
+			for i, code := range codes.RecoveryCodes {
+				assert.Equal(t, hashes[i], hashSecret(normalizeRecoveryCode(strings.ToUpper(code))))
+				assert.NotContains(t, hashes, code)
+			}
+		})
+	}
+}
+
+func TestAuthService_VerifyMFA(t *testing.T) {
+	ctx := audit.WithRequestInfo(context.Background(), audit.RequestInfo{IP: "10.0.0.1"})
+	user := domain.User{ID: 1, Username: "alice", TokenVersion: 2, MFAEnabled: true}
+	enabled := domain.MFA{UserID: 1, Secret: testMFASecret, ConfirmedAt: &time.Time{}}
+
+	challenge, err := signChallenge(1, 2, []string{domain.ScopeInfoRead}, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	stale, err := signChallenge(1, 1, []string{domain.ScopeInfoRead}, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	expired, err := signChallenge(1, 2, []string{domain.ScopeInfoRead}, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	access, err := NewAuthService(nil, nil, nil, nil, welcomeGrant, domain.ExpiryPolicy{}, testPasswordPolicy).GenerateJwtToken(1, 2, nil)
+	require.NoError(t, err)
+
+	succeeded := func(method string) func(*mocks.RepoAuthInterface, *mocks.LoginGuardInterface, *mocks.AuditRecorderInterface) {
+		return func(_ *mocks.RepoAuthInterface, guard *mocks.LoginGuardInterface, recorder *mocks.AuditRecorderInterface) {
+			guard.On("Succeed", mock.Anything, "alice").Return(nil)
+			recorder.On("Record", mock.Anything, domain.AuditEntry{
+				ActorID: 1,
+				Action:  domain.AuditLogin,
+				Target:  "user:alice",
+				Details: map[string]string{"method": method},
+			}).Return(nil)
+			recorder.On("Record", mock.Anything, mock.MatchedBy(func(entry domain.AuditEntry) bool {
+				return entry.Action == domain.AuditTokenIssued
+			})).Return(nil)
+		}
+	}
+	failed := func(_ *mocks.RepoAuthInterface, guard *mocks.LoginGuardInterface, recorder *mocks.AuditRecorderInterface) {
+		guard.On("Fail", mock.Anything, "alice", "10.0.0.1").Return(time.Duration(0), nil)
+		recorder.On("Record", mock.Anything, domain.AuditEntry{ActorID: 1, Action: domain.AuditLoginFailed, Target: "user:alice"}).Return(nil)
+	}
+
+	tests := []struct {
+		name          string
+		token         string
+		code          func(t *testing.T) string
+		setup         func(*mocks.RepoAuthInterface, *mocks.LoginGuardInterface, *mocks.AuditRecorderInterface)
+		expectedError error
+	}{
+		{
+			name:  "totp",
+			token: challenge,
+			code:  currentTOTP,
+			setup: func(repo *mocks.RepoAuthInterface, guard *mocks.LoginGuardInterface, recorder *mocks.AuditRecorderInterface) {
+				repo.On("GetMFA", mock.Anything, 1).Return(enabled, nil)
+				repo.On("UseTOTPStep", mock.Anything, 1, mock.Anything).Return(true, nil)
+				succeeded("totp")(repo, guard, recorder)
+			},
+		},
+		{
+			name:  "totp replayed",
+			token: challenge,
+			code:  currentTOTP,
+			setup: func(repo *mocks.RepoAuthInterface, guard *mocks.LoginGuardInterface, recorder *mocks.AuditRecorderInterface) {
+				repo.On("GetMFA", mock.Anything, 1).Return(enabled, nil)
+				repo.On("UseTOTPStep", mock.Anything, 1, mock.Anything).Return(false, nil)
+				failed(repo, guard, recorder)
+			},
+			expectedError: erorrs.ErrMFACodeInvalid,
+		},
+		{
+			name:  "recovery code",
+			token: challenge,
+			code:  func(*testing.T) string { return " ABCD-ef01-2345-6789 " },
+			setup: func(repo *mocks.RepoAuthInterface, guard *mocks.LoginGuardInterface, recorder *mocks.AuditRecorderInterface) {
+				repo.On("GetMFA", mock.Anything, 1).Return(enabled, nil)
+				repo.On("UseRecoveryCode", mock.Anything, 1, hashSecret("abcdef0123456789"), mock.Anything).Return(true, nil)
+				succeeded("recovery")(repo, guard, recorder)
+			},
+		},
+		{
+			name:  "wrong code",
+			token: challenge,
+			code:  func(*testing.T) string { return "guess" },
+			setup: func(repo *mocks.RepoAuthInterface, guard *mocks.LoginGuardInterface, recorder *mocks.AuditRecorderInterface) {
+				repo.On("GetMFA", mock.Anything, 1).Return(enabled, nil)
+				repo.On("UseRecoveryCode", mock.Anything, 1, hashSecret("guess"), mock.Anything).Return(false, nil)
+				failed(repo, guard, recorder)
+			},
+			expectedError: erorrs.ErrMFACodeInvalid,
+		},
+		{
+			// пароль сменили между шагами входа
+			name:          "stale challenge",
+			token:         stale,
+			code:          currentTOTP,
+			setup:         func(*mocks.RepoAuthInterface, *mocks.LoginGuardInterface, *mocks.AuditRecorderInterface) {},
+			expectedError: erorrs.ErrMFAChallengeInvalid,
+		},
+		{
+			name:          "expired challenge",
+			token:         expired,
+			code:          currentTOTP,
+			setup:         func(*mocks.RepoAuthInterface, *mocks.LoginGuardInterface, *mocks.AuditRecorderInterface) {},
+			expectedError: erorrs.ErrMFAChallengeInvalid,
+		},
+		{
+			name:          "access token instead of challenge",
+			token:         access,
+			code:          currentTOTP,
+			setup:         func(*mocks.RepoAuthInterface, *mocks.LoginGuardInterface, *mocks.AuditRecorderInterface) {},
+			expectedError: erorrs.ErrMFAChallengeInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewRepoAuthInterface(t)
+			mockGuard := mocks.NewLoginGuardInterface(t)
+			mockAudit := mocks.NewAuditRecorderInterface(t)
+			mockLogger := mocks2.NewLogger(t)
+			mockLogger.On("Info", mock.Anything, mock.Anything).Maybe()
+
+			mockRepo.On("GetUserByID", mock.Anything, 1).Return(user, nil).Maybe()
+			mockGuard.On("Check", mock.Anything, "alice", "10.0.0.1").Return(time.Duration(0), nil).Maybe()
+			tt.setup(mockRepo, mockGuard, mockAudit)
+
+			authService := NewAuthService(mockRepo, mockAudit, mockGuard, mockLogger, welcomeGrant, domain.ExpiryPolicy{}, testPasswordPolicy)
+			token, err := authService.VerifyMFA(ctx, model.MFAVerifyRequestDTO{MFAToken: tt.token, Code: tt.code(t)})
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Empty(t, token)
+				return
+			}
+
+			require.NoError(t, err)
+			parsed, err := ParseToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, AccessToken{UserID: 1, Version: 2, Scopes: []string{domain.ScopeInfoRead}}, parsed)
+		})
+	}
+}
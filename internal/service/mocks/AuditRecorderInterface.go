@@ -0,0 +1,47 @@
+// Code generated by mockery v2.52.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	domain "avito-shop/internal/domain"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AuditRecorderInterface is an autogenerated mock type for the AuditRecorderInterface type
+type AuditRecorderInterface struct {
+	mock.Mock
+}
+
+// Record provides a mock function with given fields: ctx, entry
+func (_m *AuditRecorderInterface) Record(ctx context.Context, entry domain.AuditEntry) error {
+	ret := _m.Called(ctx, entry)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Record")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.AuditEntry) error); ok {
+		r0 = rf(ctx, entry)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewAuditRecorderInterface creates a new instance of AuditRecorderInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAuditRecorderInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AuditRecorderInterface {
+	mock := &AuditRecorderInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
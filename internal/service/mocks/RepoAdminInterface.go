@@ -0,0 +1,169 @@
+// Code generated by mockery v2.52.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	domain "avito-shop/internal/domain"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// RepoAdminInterface is an autogenerated mock type for the RepoAdminInterface type
+type RepoAdminInterface struct {
+	mock.Mock
+}
+
+// AddGroupMember provides a mock function with given fields: ctx, group, userID
+func (_m *RepoAdminInterface) AddGroupMember(ctx context.Context, group string, userID int) error {
+	ret := _m.Called(ctx, group, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddGroupMember")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) error); ok {
+		r0 = rf(ctx, group, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ApplyCoinOperation provides a mock function with given fields: ctx, userIDs, op
+func (_m *RepoAdminInterface) ApplyCoinOperation(ctx context.Context, userIDs []int, op domain.CoinOperation) error {
+	ret := _m.Called(ctx, userIDs, op)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ApplyCoinOperation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int, domain.CoinOperation) error); ok {
+		r0 = rf(ctx, userIDs, op)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetGroupMembers provides a mock function with given fields: ctx, group
+func (_m *RepoAdminInterface) GetGroupMembers(ctx context.Context, group string) ([]int, error) {
+	ret := _m.Called(ctx, group)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGroupMembers")
+	}
+
+	var r0 []int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]int, error)); ok {
+		return rf(ctx, group)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []int); ok {
+		r0 = rf(ctx, group)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, group)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserByName provides a mock function with given fields: ctx, username
+func (_m *RepoAdminInterface) GetUserByName(ctx context.Context, username string) (int, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByName")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserRole provides a mock function with given fields: ctx, userID
+func (_m *RepoAdminInterface) GetUserRole(ctx context.Context, userID int) (string, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserRole")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (string, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) string); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveGroupMember provides a mock function with given fields: ctx, group, userID
+func (_m *RepoAdminInterface) RemoveGroupMember(ctx context.Context, group string, userID int) error {
+	ret := _m.Called(ctx, group, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveGroupMember")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) error); ok {
+		r0 = rf(ctx, group, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewRepoAdminInterface creates a new instance of RepoAdminInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRepoAdminInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *RepoAdminInterface {
+	mock := &RepoAdminInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
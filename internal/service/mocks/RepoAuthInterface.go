@@ -7,6 +7,8 @@ import (
 	context "context"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // RepoAuthInterface is an autogenerated mock type for the RepoAuthInterface type
@@ -14,9 +16,37 @@ type RepoAuthInterface struct {
 	mock.Mock
 }
 
-// CreateUser provides a mock function with given fields: ctx, user
-func (_m *RepoAuthInterface) CreateUser(ctx context.Context, user domain.User) (int, error) {
-	ret := _m.Called(ctx, user)
+// CreateResetToken provides a mock function with given fields: ctx, token
+func (_m *RepoAuthInterface) CreateResetToken(ctx context.Context, token domain.PasswordResetToken) (domain.PasswordResetToken, error) {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateResetToken")
+	}
+
+	var r0 domain.PasswordResetToken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.PasswordResetToken) (domain.PasswordResetToken, error)); ok {
+		return rf(ctx, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.PasswordResetToken) domain.PasswordResetToken); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Get(0).(domain.PasswordResetToken)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.PasswordResetToken) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateUser provides a mock function with given fields: ctx, user, grant
+func (_m *RepoAuthInterface) CreateUser(ctx context.Context, user domain.User, grant domain.CoinOperation) (int, error) {
+	ret := _m.Called(ctx, user, grant)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateUser")
@@ -24,17 +54,91 @@ func (_m *RepoAuthInterface) CreateUser(ctx context.Context, user domain.User) (
 
 	var r0 int
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, domain.User) (int, error)); ok {
-		return rf(ctx, user)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.User, domain.CoinOperation) (int, error)); ok {
+		return rf(ctx, user, grant)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.User, domain.CoinOperation) int); ok {
+		r0 = rf(ctx, user, grant)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.User, domain.CoinOperation) error); ok {
+		r1 = rf(ctx, user, grant)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EnableMFA provides a mock function with given fields: ctx, userID, step, recoveryHashes, at
+func (_m *RepoAuthInterface) EnableMFA(ctx context.Context, userID int, step int64, recoveryHashes []string, at time.Time) error {
+	ret := _m.Called(ctx, userID, step, recoveryHashes, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnableMFA")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int64, []string, time.Time) error); ok {
+		r0 = rf(ctx, userID, step, recoveryHashes, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetMFA provides a mock function with given fields: ctx, userID
+func (_m *RepoAuthInterface) GetMFA(ctx context.Context, userID int) (domain.MFA, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMFA")
+	}
+
+	var r0 domain.MFA
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (domain.MFA, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) domain.MFA); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(domain.MFA)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTokenVersion provides a mock function with given fields: ctx, userID
+func (_m *RepoAuthInterface) GetTokenVersion(ctx context.Context, userID int) (int, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTokenVersion")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (int, error)); ok {
+		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, domain.User) int); ok {
-		r0 = rf(ctx, user)
+	if rf, ok := ret.Get(0).(func(context.Context, int) int); ok {
+		r0 = rf(ctx, userID)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, domain.User) error); ok {
-		r1 = rf(ctx, user)
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -70,6 +174,192 @@ func (_m *RepoAuthInterface) GetUser(ctx context.Context, username string, passw
 	return r0, r1
 }
 
+// GetUserByID provides a mock function with given fields: ctx, userID
+func (_m *RepoAuthInterface) GetUserByID(ctx context.Context, userID int) (domain.User, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByID")
+	}
+
+	var r0 domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (domain.User, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) domain.User); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(domain.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResetPassword provides a mock function with given fields: ctx, tokenHash, passwordHash, at
+func (_m *RepoAuthInterface) ResetPassword(ctx context.Context, tokenHash string, passwordHash string, at time.Time) (domain.User, error) {
+	ret := _m.Called(ctx, tokenHash, passwordHash, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetPassword")
+	}
+
+	var r0 domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) (domain.User, error)); ok {
+		return rf(ctx, tokenHash, passwordHash, at)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) domain.User); ok {
+		r0 = rf(ctx, tokenHash, passwordHash, at)
+	} else {
+		r0 = ret.Get(0).(domain.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Time) error); ok {
+		r1 = rf(ctx, tokenHash, passwordHash, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveMFASecret provides a mock function with given fields: ctx, userID, secret
+func (_m *RepoAuthInterface) SaveMFASecret(ctx context.Context, userID int, secret string) error {
+	ret := _m.Called(ctx, userID, secret)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveMFASecret")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(ctx, userID, secret)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePassword provides a mock function with given fields: ctx, userID, passwordHash
+func (_m *RepoAuthInterface) UpdatePassword(ctx context.Context, userID int, passwordHash string) (int, error) {
+	ret := _m.Called(ctx, userID, passwordHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePassword")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) (int, error)); ok {
+		return rf(ctx, userID, passwordHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) int); ok {
+		r0 = rf(ctx, userID, passwordHash)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string) error); ok {
+		r1 = rf(ctx, userID, passwordHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UseRecoveryCode provides a mock function with given fields: ctx, userID, hash, at
+func (_m *RepoAuthInterface) UseRecoveryCode(ctx context.Context, userID int, hash string, at time.Time) (bool, error) {
+	ret := _m.Called(ctx, userID, hash, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UseRecoveryCode")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, time.Time) (bool, error)); ok {
+		return rf(ctx, userID, hash, at)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, time.Time) bool); ok {
+		r0 = rf(ctx, userID, hash, at)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string, time.Time) error); ok {
+		r1 = rf(ctx, userID, hash, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UseTOTPStep provides a mock function with given fields: ctx, userID, step
+func (_m *RepoAuthInterface) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	ret := _m.Called(ctx, userID, step)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UseTOTPStep")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int64) (bool, error)); ok {
+		return rf(ctx, userID, step)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int64) bool); ok {
+		r0 = rf(ctx, userID, step)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int64) error); ok {
+		r1 = rf(ctx, userID, step)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UserExists provides a mock function with given fields: ctx, username
+func (_m *RepoAuthInterface) UserExists(ctx context.Context, username string) (bool, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UserExists")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewRepoAuthInterface creates a new instance of RepoAuthInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewRepoAuthInterface(t interface {
@@ -9,6 +9,8 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	model "avito-shop/internal/model"
+
+	time "time"
 )
 
 // RepoUserInterface is an autogenerated mock type for the RepoUserInterface type
@@ -34,6 +36,80 @@ func (_m *RepoUserInterface) BuyItem(ctx context.Context, user domain.User, item
 	return r0
 }
 
+// CheckCurrency provides a mock function with given fields: ctx, currency
+func (_m *RepoUserInterface) CheckCurrency(ctx context.Context, currency string) error {
+	ret := _m.Called(ctx, currency)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckCurrency")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, currency)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExpireCoins provides a mock function with given fields: ctx, now
+func (_m *RepoUserInterface) ExpireCoins(ctx context.Context, now time.Time) (int, error) {
+	ret := _m.Called(ctx, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExpireCoins")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int, error)); ok {
+		return rf(ctx, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int); ok {
+		r0 = rf(ctx, now)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBalance provides a mock function with given fields: ctx, userID, currency
+func (_m *RepoUserInterface) GetBalance(ctx context.Context, userID int, currency string) (domain.Money, error) {
+	ret := _m.Called(ctx, userID, currency)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBalance")
+	}
+
+	var r0 domain.Money
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) (domain.Money, error)); ok {
+		return rf(ctx, userID, currency)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) domain.Money); ok {
+		r0 = rf(ctx, userID, currency)
+	} else {
+		r0 = ret.Get(0).(domain.Money)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string) error); ok {
+		r1 = rf(ctx, userID, currency)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetCoinHistory provides a mock function with given fields: ctx, userID, currentUsername
 func (_m *RepoUserInterface) GetCoinHistory(ctx context.Context, userID int, currentUsername string) (model.CoinHistoryDTO, error) {
 	ret := _m.Called(ctx, userID, currentUsername)
@@ -62,6 +138,36 @@ func (_m *RepoUserInterface) GetCoinHistory(ctx context.Context, userID int, cur
 	return r0, r1
 }
 
+// GetExpiringCoins provides a mock function with given fields: ctx, userID, before
+func (_m *RepoUserInterface) GetExpiringCoins(ctx context.Context, userID int, before time.Time) ([]model.ExpiringCoinsDTO, error) {
+	ret := _m.Called(ctx, userID, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetExpiringCoins")
+	}
+
+	var r0 []model.ExpiringCoinsDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Time) ([]model.ExpiringCoinsDTO, error)); ok {
+		return rf(ctx, userID, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Time) []model.ExpiringCoinsDTO); ok {
+		r0 = rf(ctx, userID, before)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.ExpiringCoinsDTO)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, time.Time) error); ok {
+		r1 = rf(ctx, userID, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetItem provides a mock function with given fields: ctx, itemName
 func (_m *RepoUserInterface) GetItem(ctx context.Context, itemName string) (domain.Item, error) {
 	ret := _m.Called(ctx, itemName)
@@ -176,17 +282,47 @@ func (_m *RepoUserInterface) GetUserByName(ctx context.Context, toUser string) (
 	return r0, r1
 }
 
-// SendCoins provides a mock function with given fields: ctx, fromUserID, toUserID, amount
-func (_m *RepoUserInterface) SendCoins(ctx context.Context, fromUserID int, toUserID int, amount int) error {
-	ret := _m.Called(ctx, fromUserID, toUserID, amount)
+// GetWallets provides a mock function with given fields: ctx, userID
+func (_m *RepoUserInterface) GetWallets(ctx context.Context, userID int) ([]model.WalletDTO, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWallets")
+	}
+
+	var r0 []model.WalletDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]model.WalletDTO, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []model.WalletDTO); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.WalletDTO)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SendCoins provides a mock function with given fields: ctx, transfer
+func (_m *RepoUserInterface) SendCoins(ctx context.Context, transfer domain.Transfer) error {
+	ret := _m.Called(ctx, transfer)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SendCoins")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) error); ok {
-		r0 = rf(ctx, fromUserID, toUserID, amount)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Transfer) error); ok {
+		r0 = rf(ctx, transfer)
 	} else {
 		r0 = ret.Error(0)
 	}
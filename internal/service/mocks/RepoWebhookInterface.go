@@ -0,0 +1,233 @@
+// Code generated by mockery v2.52.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	domain "avito-shop/internal/domain"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	model "avito-shop/internal/model"
+
+	time "time"
+)
+
+// RepoWebhookInterface is an autogenerated mock type for the RepoWebhookInterface type
+type RepoWebhookInterface struct {
+	mock.Mock
+}
+
+// ClaimDueDeliveries provides a mock function with given fields: ctx, now, lease, limit
+func (_m *RepoWebhookInterface) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error) {
+	ret := _m.Called(ctx, now, lease, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimDueDeliveries")
+	}
+
+	var r0 []domain.WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Duration, int) ([]domain.WebhookDelivery, error)); ok {
+		return rf(ctx, now, lease, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Duration, int) []domain.WebhookDelivery); ok {
+		r0 = rf(ctx, now, lease, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.WebhookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, time.Duration, int) error); ok {
+		r1 = rf(ctx, now, lease, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateSubscription provides a mock function with given fields: ctx, sub
+func (_m *RepoWebhookInterface) CreateSubscription(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	ret := _m.Called(ctx, sub)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateSubscription")
+	}
+
+	var r0 domain.WebhookSubscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.WebhookSubscription) (domain.WebhookSubscription, error)); ok {
+		return rf(ctx, sub)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.WebhookSubscription) domain.WebhookSubscription); ok {
+		r0 = rf(ctx, sub)
+	} else {
+		r0 = ret.Get(0).(domain.WebhookSubscription)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.WebhookSubscription) error); ok {
+		r1 = rf(ctx, sub)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteSubscription provides a mock function with given fields: ctx, id
+func (_m *RepoWebhookInterface) DeleteSubscription(ctx context.Context, id int) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteSubscription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnqueueDeliveries provides a mock function with given fields: ctx, eventType, payload
+func (_m *RepoWebhookInterface) EnqueueDeliveries(ctx context.Context, eventType string, payload []byte) (int, error) {
+	ret := _m.Called(ctx, eventType, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnqueueDeliveries")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) (int, error)); ok {
+		return rf(ctx, eventType, payload)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) int); ok {
+		r0 = rf(ctx, eventType, payload)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []byte) error); ok {
+		r1 = rf(ctx, eventType, payload)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeliveries provides a mock function with given fields: ctx, subscriptionID, limit
+func (_m *RepoWebhookInterface) GetDeliveries(ctx context.Context, subscriptionID int, limit int) ([]model.WebhookDeliveryDTO, error) {
+	ret := _m.Called(ctx, subscriptionID, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDeliveries")
+	}
+
+	var r0 []model.WebhookDeliveryDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) ([]model.WebhookDeliveryDTO, error)); ok {
+		return rf(ctx, subscriptionID, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) []model.WebhookDeliveryDTO); ok {
+		r0 = rf(ctx, subscriptionID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.WebhookDeliveryDTO)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, subscriptionID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListSubscriptions provides a mock function with given fields: ctx
+func (_m *RepoWebhookInterface) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListSubscriptions")
+	}
+
+	var r0 []domain.WebhookSubscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]domain.WebhookSubscription, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.WebhookSubscription); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.WebhookSubscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordAttempt provides a mock function with given fields: ctx, attempt, status, nextAttemptAt
+func (_m *RepoWebhookInterface) RecordAttempt(ctx context.Context, attempt domain.WebhookAttempt, status string, nextAttemptAt time.Time) error {
+	ret := _m.Called(ctx, attempt, status, nextAttemptAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordAttempt")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.WebhookAttempt, string, time.Time) error); ok {
+		r0 = rf(ctx, attempt, status, nextAttemptAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RequeueDelivery provides a mock function with given fields: ctx, subscriptionID, deliveryID
+func (_m *RepoWebhookInterface) RequeueDelivery(ctx context.Context, subscriptionID int, deliveryID int64) error {
+	ret := _m.Called(ctx, subscriptionID, deliveryID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequeueDelivery")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int64) error); ok {
+		r0 = rf(ctx, subscriptionID, deliveryID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewRepoWebhookInterface creates a new instance of RepoWebhookInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRepoWebhookInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *RepoWebhookInterface {
+	mock := &RepoWebhookInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,57 @@
+// Code generated by mockery v2.52.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	domain "avito-shop/internal/domain"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// WebhookSenderInterface is an autogenerated mock type for the WebhookSenderInterface type
+type WebhookSenderInterface struct {
+	mock.Mock
+}
+
+// Send provides a mock function with given fields: ctx, delivery
+func (_m *WebhookSenderInterface) Send(ctx context.Context, delivery domain.WebhookDelivery) (int, error) {
+	ret := _m.Called(ctx, delivery)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Send")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.WebhookDelivery) (int, error)); ok {
+		return rf(ctx, delivery)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.WebhookDelivery) int); ok {
+		r0 = rf(ctx, delivery)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.WebhookDelivery) error); ok {
+		r1 = rf(ctx, delivery)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewWebhookSenderInterface creates a new instance of WebhookSenderInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewWebhookSenderInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *WebhookSenderInterface {
+	mock := &WebhookSenderInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"avito-shop/internal/audit"
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	"avito-shop/internal/model"
+	"avito-shop/internal/utils"
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+)
+
+// CheckSession отклоняет токен, выпущенный до последней смены пароля пользователя
+func (s *AuthService) CheckSession(ctx context.Context, userID int, version int) error {
+	current, err := s.repo.GetTokenVersion(ctx, userID)
+	if err != nil {
+		if errors.Is(err, erorrs.ErrNotFound) {
+			return erorrs.ErrSessionRevoked
+		}
+		s.log(ctx).Error("service.Auth.CheckSession: error getting token version", zap.Error(err))
+		return err
+	}
+
+	if version != current {
+		s.log(ctx).Info("service.Auth.CheckSession: revoked token", zap.Int("userID", userID))
+		return erorrs.ErrSessionRevoked
+	}
+
+	return nil
+}
+
+// ChangePassword меняет пароль по текущему паролю и возвращает новый токен с правами scopes.
+// Остальные токены пользователя перестают действовать. Неверный текущий пароль учитывается
+// в блокировке так же, как неудачный вход
+func (s *AuthService) ChangePassword(ctx context.Context, userID int, scopes []string, dto model.PasswordChangeRequestDTO) (string, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		s.log(ctx).Error("service.Auth.ChangePassword: error getting user", zap.Error(err))
+		return "", err
+	}
+
+	ip := audit.RequestInfoFromContext(ctx).IP
+	if err := s.checkLockout(ctx, user.Username, ip); err != nil {
+		return "", err
+	}
+
+	if utils.GeneratePasswordHash(dto.CurrentPassword) != user.PasswordHash {
+		recordAudit(ctx, s.audit, s.log(ctx), domain.AuditEntry{
+			ActorID: userID,
+			Action:  domain.AuditLoginFailed,
+			Target:  userTarget(user.Username),
+		})
+		s.registerFailure(ctx, user.Username, ip)
+		s.log(ctx).Info("service.Auth.ChangePassword: wrong current password")
+		return "", erorrs.ErrWrongPassword
+	}
+
+	if !s.password.Allows(dto.NewPassword) {
+		return "", erorrs.ErrWeakPassword
+	}
+
+	version, err := s.repo.UpdatePassword(ctx, userID, utils.GeneratePasswordHash(dto.NewPassword))
+	if err != nil {
+		s.log(ctx).Error("service.Auth.ChangePassword: error updating password", zap.Error(err))
+		return "", err
+	}
+
+	if err := s.guard.Succeed(ctx, user.Username); err != nil {
+		s.log(ctx).Error("service.Auth.ChangePassword: error resetting failures", zap.Error(err))
+	}
+	recordAudit(ctx, s.audit, s.log(ctx), domain.AuditEntry{
+		ActorID: userID,
+		Action:  domain.AuditPasswordChange,
+		Target:  userTarget(user.Username),
+	})
+	s.log(ctx).Info("password changed", zap.Int("userID", userID))
+
+	return s.issueToken(ctx, userID, version, user.Username, scopes)
+}
+
+// IssueResetToken выпускает одноразовый токен сброса пароля пользователя username. Токен
+// возвращается один раз, администратор передает его пользователю сам
+func (s *AuthService) IssueResetToken(ctx context.Context, actorID int, username string) (model.PasswordResetTokenDTO, error) {
+	secret, err := generateSecret()
+	if err != nil {
+		s.log(ctx).Error("service.Auth.IssueResetToken: error generating token", zap.Error(err))
+		return model.PasswordResetTokenDTO{}, err
+	}
+
+	token, err := s.repo.CreateResetToken(ctx, domain.PasswordResetToken{
+		Username:  username,
+		Hash:      hashSecret(secret),
+		CreatedBy: actorID,
+		ExpiresAt: time.Now().Add(s.password.ResetTTL),
+	})
+	if err != nil {
+		s.log(ctx).Error("service.Auth.IssueResetToken: error saving token", zap.Error(err))
+		return model.PasswordResetTokenDTO{}, err
+	}
+
+	recordAudit(ctx, s.audit, s.log(ctx), domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditPasswordResetIssue,
+		Target:  userTarget(username),
+		Details: map[string]string{"tokenID": strconv.Itoa(token.ID), "expiresAt": token.ExpiresAt.UTC().Format(time.RFC3339)},
+	})
+	s.log(ctx).Info("password reset token issued", zap.Int("userID", token.UserID), zap.Int("actorID", actorID))
+
+	return model.PasswordResetTokenDTO{Token: secret, ExpiresAt: token.ExpiresAt}, nil
+}
+
+// ResetPassword задает новый пароль по токену сброса. Токен гасится, выданные токены доступа отзываются
+func (s *AuthService) ResetPassword(ctx context.Context, dto model.PasswordResetRequestDTO) error {
+	if !s.password.Allows(dto.NewPassword) {
+		return erorrs.ErrWeakPassword
+	}
+
+	user, err := s.repo.ResetPassword(ctx, hashSecret(dto.Token), utils.GeneratePasswordHash(dto.NewPassword), time.Now())
+	if err != nil {
+		if errors.Is(err, erorrs.ErrResetTokenInvalid) {
+			s.log(ctx).Info("service.Auth.ResetPassword: invalid token")
+			return err
+		}
+		s.log(ctx).Error("service.Auth.ResetPassword: error resetting password", zap.Error(err))
+		return err
+	}
+
+	if err := s.guard.Succeed(ctx, user.Username); err != nil {
+		s.log(ctx).Error("service.Auth.ResetPassword: error resetting failures", zap.Error(err))
+	}
+	recordAudit(ctx, s.audit, s.log(ctx), domain.AuditEntry{
+		ActorID: user.ID,
+		Action:  domain.AuditPasswordReset,
+		Target:  userTarget(user.Username),
+	})
+	s.log(ctx).Info("password reset", zap.Int("userID", user.ID))
+
+	return nil
+}
@@ -0,0 +1,204 @@
+//go:build unit
+// +build unit
+
+package service
+
+import (
+	"avito-shop/internal/audit"
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	mocks2 "avito-shop/internal/logger/mocks"
+	"avito-shop/internal/model"
+	"avito-shop/internal/service/mocks"
+	"avito-shop/internal/utils"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+var testPasswordPolicy = domain.PasswordPolicy{MinLength: 8, RequireLetter: true, RequireDigit: true, ResetTTL: time.Hour}
+
+func TestAuthService_Authorization_WeakPassword(t *testing.T) {
+	ctx := audit.WithRequestInfo(context.Background(), audit.RequestInfo{IP: "10.0.0.1"})
+
+	tests := []struct {
+		name          string
+		exists        bool
+		expectedError error
+	}{
+		{name: "new user", exists: false, expectedError: erorrs.ErrWeakPassword},
+		// слабый пароль к занятому имени - просто неверный пароль
+		{name: "existing user", exists: true, expectedError: erorrs.ErrUserExist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewRepoAuthInterface(t)
+			mockGuard := mocks.NewLoginGuardInterface(t)
+			mockAudit := mocks.NewAuditRecorderInterface(t)
+			mockLogger := mocks2.NewLogger(t)
+			mockLogger.On("Info", mock.Anything, mock.Anything).Maybe()
+			mockLogger.On("Error", mock.Anything, mock.Anything).Maybe()
+
+			mockGuard.On("Check", mock.Anything, "newbie", "10.0.0.1").Return(time.Duration(0), nil)
+			mockRepo.On("GetUser", mock.Anything, "newbie", mock.Anything).Return(domain.User{}, nil)
+			mockRepo.On("UserExists", mock.Anything, "newbie").Return(tt.exists, nil)
+			if tt.exists {
+				mockGuard.On("Fail", mock.Anything, "newbie", "10.0.0.1").Return(time.Duration(0), nil)
+				mockAudit.On("Record", mock.Anything, mock.Anything).Return(nil)
+			}
+
+			authService := NewAuthService(mockRepo, mockAudit, mockGuard, mockLogger, welcomeGrant, domain.ExpiryPolicy{}, testPasswordPolicy)
+			_, err := authService.Authorization(ctx, model.AuthRequestDTO{Username: "newbie", Password: "pass"})
+
+			assert.ErrorIs(t, err, tt.expectedError)
+			mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestAuthService_CheckSession(t *testing.T) {
+	mockRepo := mocks.NewRepoAuthInterface(t)
+	mockLogger := mocks2.NewLogger(t)
+	mockLogger.On("Info", mock.Anything, mock.Anything).Maybe()
+	mockRepo.On("GetTokenVersion", mock.Anything, 1).Return(2, nil)
+	mockRepo.On("GetTokenVersion", mock.Anything, 9).Return(0, erorrs.ErrNotFound)
+	authService := NewAuthService(mockRepo, nil, nil, mockLogger, welcomeGrant, domain.ExpiryPolicy{}, testPasswordPolicy)
+
+	assert.NoError(t, authService.CheckSession(context.Background(), 1, 2))
+	assert.ErrorIs(t, authService.CheckSession(context.Background(), 1, 1), erorrs.ErrSessionRevoked)
+	assert.ErrorIs(t, authService.CheckSession(context.Background(), 9, 0), erorrs.ErrSessionRevoked)
+}
+
+func TestAuthService_ChangePassword(t *testing.T) {
+	ctx := audit.WithRequestInfo(context.Background(), audit.RequestInfo{IP: "10.0.0.1"})
+	user := domain.User{ID: 1, Username: "alice", PasswordHash: utils.GeneratePasswordHash("oldpass1"), TokenVersion: 2}
+
+	tests := []struct {
+		name          string
+		dto           model.PasswordChangeRequestDTO
+		setup         func(*mocks.RepoAuthInterface, *mocks.LoginGuardInterface, *mocks.AuditRecorderInterface)
+		expectedError error
+	}{
+		{
+			name: "changed",
+			dto:  model.PasswordChangeRequestDTO{CurrentPassword: "oldpass1", NewPassword: "newpass2"},
+			setup: func(repo *mocks.RepoAuthInterface, guard *mocks.LoginGuardInterface, recorder *mocks.AuditRecorderInterface) {
+				repo.On("UpdatePassword", mock.Anything, 1, utils.GeneratePasswordHash("newpass2")).Return(3, nil)
+				guard.On("Succeed", mock.Anything, "alice").Return(nil)
+				recorder.On("Record", mock.Anything, domain.AuditEntry{
+					ActorID: 1,
+					Action:  domain.AuditPasswordChange,
+					Target:  "user:alice",
+				}).Return(nil)
+				recorder.On("Record", mock.Anything, mock.MatchedBy(func(entry domain.AuditEntry) bool {
+					return entry.Action == domain.AuditTokenIssued
+				})).Return(nil)
+			},
+		},
+		{
+			name: "wrong current password",
+			dto:  model.PasswordChangeRequestDTO{CurrentPassword: "guess", NewPassword: "newpass2"},
+			setup: func(repo *mocks.RepoAuthInterface, guard *mocks.LoginGuardInterface, recorder *mocks.AuditRecorderInterface) {
+				guard.On("Fail", mock.Anything, "alice", "10.0.0.1").Return(time.Duration(0), nil)
+				recorder.On("Record", mock.Anything, domain.AuditEntry{
+					ActorID: 1,
+					Action:  domain.AuditLoginFailed,
+					Target:  "user:alice",
+				}).Return(nil)
+			},
+			expectedError: erorrs.ErrWrongPassword,
+		},
+		{
+			name:          "weak new password",
+			dto:           model.PasswordChangeRequestDTO{CurrentPassword: "oldpass1", NewPassword: "short"},
+			setup:         func(*mocks.RepoAuthInterface, *mocks.LoginGuardInterface, *mocks.AuditRecorderInterface) {},
+			expectedError: erorrs.ErrWeakPassword,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewRepoAuthInterface(t)
+			mockGuard := mocks.NewLoginGuardInterface(t)
+			mockAudit := mocks.NewAuditRecorderInterface(t)
+			mockLogger := mocks2.NewLogger(t)
+			mockLogger.On("Info", mock.Anything, mock.Anything).Maybe()
+
+			mockRepo.On("GetUserByID", mock.Anything, 1).Return(user, nil)
+			mockGuard.On("Check", mock.Anything, "alice", "10.0.0.1").Return(time.Duration(0), nil)
+			tt.setup(mockRepo, mockGuard, mockAudit)
+
+			authService := NewAuthService(mockRepo, mockAudit, mockGuard, mockLogger, welcomeGrant, domain.ExpiryPolicy{}, testPasswordPolicy)
+			token, err := authService.ChangePassword(ctx, 1, []string{domain.ScopeAccount}, tt.dto)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+
+			require.NoError(t, err)
+			parsed, err := ParseToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, AccessToken{UserID: 1, Version: 3, Scopes: []string{domain.ScopeAccount}}, parsed)
+		})
+	}
+}
+
+func TestAuthService_PasswordReset(t *testing.T) {
+	mockRepo := mocks.NewRepoAuthInterface(t)
+	mockGuard := mocks.NewLoginGuardInterface(t)
+	mockAudit := mocks.NewAuditRecorderInterface(t)
+	mockLogger := mocks2.NewLogger(t)
+	mockLogger.On("Info", mock.Anything, mock.Anything, mock.Anything).Maybe()
+	authService := NewAuthService(mockRepo, mockAudit, mockGuard, mockLogger, welcomeGrant, domain.ExpiryPolicy{}, testPasswordPolicy)
+
+	var saved domain.PasswordResetToken
+	mockRepo.On("CreateResetToken", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(domain.PasswordResetToken) }).
+		Return(func(_ context.Context, token domain.PasswordResetToken) domain.PasswordResetToken {
+			token.ID = 4
+			token.UserID = 2
+			return token
+		}, nil)
+	mockAudit.On("Record", mock.Anything, mock.MatchedBy(func(entry domain.AuditEntry) bool {
+		return entry.ActorID == 1 && entry.Action == domain.AuditPasswordResetIssue && entry.Target == "user:bob"
+	})).Return(nil)
+
+	issued, err := authService.IssueResetToken(context.Background(), 1, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, hashSecret(issued.Token), saved.Hash)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), issued.ExpiresAt, time.Minute)
+
+	t.Run("weak password is rejected before the token is spent", func(t *testing.T) {
+		err := authService.ResetPassword(context.Background(), model.PasswordResetRequestDTO{Token: issued.Token, NewPassword: "short"})
+		assert.ErrorIs(t, err, erorrs.ErrWeakPassword)
+		mockRepo.AssertNotCalled(t, "ResetPassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		mockRepo.On("ResetPassword", mock.Anything, hashSecret("stale"), mock.Anything, mock.Anything).
+			Return(domain.User{}, erorrs.ErrResetTokenInvalid).Once()
+
+		err := authService.ResetPassword(context.Background(), model.PasswordResetRequestDTO{Token: "stale", NewPassword: "newpass2"})
+		assert.ErrorIs(t, err, erorrs.ErrResetTokenInvalid)
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		mockRepo.On("ResetPassword", mock.Anything, saved.Hash, utils.GeneratePasswordHash("newpass2"), mock.Anything).
+			Return(domain.User{ID: 2, Username: "bob", TokenVersion: 1}, nil).Once()
+		mockGuard.On("Succeed", mock.Anything, "bob").Return(nil).Once()
+		mockAudit.On("Record", mock.Anything, domain.AuditEntry{
+			ActorID: 2,
+			Action:  domain.AuditPasswordReset,
+			Target:  "user:bob",
+		}).Return(nil).Once()
+
+		err := authService.ResetPassword(context.Background(), model.PasswordResetRequestDTO{Token: issued.Token, NewPassword: "newpass2"})
+		assert.NoError(t, err)
+	})
+}
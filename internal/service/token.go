@@ -1,34 +1,121 @@
 package service
 
 import (
+	"avito-shop/internal/domain"
 	"avito-shop/internal/erorrs"
+	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt"
+	"slices"
+	"time"
 )
 
+const signingKey = "qrkjk#4#%35FSFJlja#4353KSFjH"
+
+// purposeMFA помечает токен входа, который еще нужно обменять на токен доступа по коду второго фактора
+const purposeMFA = "mfa"
+
+// Purpose пуст у токенов доступа: токен с любым другим назначением как токен доступа не принимается
 type tokenClaims struct {
 	jwt.StandardClaims
-	UserId int
+	UserId  int
+	Version int      `json:"ver,omitempty"`
+	Scopes  []string `json:"scopes,omitempty"`
+	Purpose string   `json:"purpose,omitempty"`
+}
+
+// AccessToken - содержимое проверенного JWT. Version сверяется с версией токенов пользователя,
+// чтобы смена пароля отзывала ранее выданные токены
+type AccessToken struct {
+	UserID  int
+	Version int
+	Scopes  []string
+}
+
+// ParseToken проверяет подпись и срок токена. Токены, выпущенные до появления прав
+// в claims, дают все права, пока не истекут
+func ParseToken(accessToken string) (AccessToken, error) {
+	claims, err := parseClaims(accessToken)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	if claims.Purpose != "" {
+		return AccessToken{}, errors.New("not an access token")
+	}
+
+	parsed := AccessToken{UserID: claims.UserId, Version: claims.Version, Scopes: claims.Scopes}
+	if parsed.Scopes == nil {
+		parsed.Scopes = domain.Scopes
+	}
+
+	return parsed, nil
 }
 
-func ParseToken(accessToken string) (int, error) {
-	token, err := jwt.ParseWithClaims(accessToken,
+// signChallenge выпускает токен входа, ожидающего второй фактор. Он несет права будущего
+// токена доступа, но сам доступа не дает
+func signChallenge(userID int, version int, scopes []string, expiresAt time.Time) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &tokenClaims{
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: expiresAt.Unix(),
+			IssuedAt:  time.Now().Unix(),
+		},
+		UserId:  userID,
+		Version: version,
+		Scopes:  scopes,
+		Purpose: purposeMFA,
+	})
+
+	return token.SignedString([]byte(signingKey))
+}
+
+func parseChallenge(challenge string) (AccessToken, error) {
+	claims, err := parseClaims(challenge)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	if claims.Purpose != purposeMFA {
+		return AccessToken{}, errors.New("not an mfa challenge")
+	}
+
+	return AccessToken{UserID: claims.UserId, Version: claims.Version, Scopes: claims.Scopes}, nil
+}
+
+func parseClaims(signed string) (*tokenClaims, error) {
+	token, err := jwt.ParseWithClaims(signed,
 		&tokenClaims{},
 		func(token *jwt.Token) (interface{}, error) {
 			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 				return nil, erorrs.ErrSigningMethod
 			}
 
-			return []byte("qrkjk#4#%35FSFJlja#4353KSFjH"), nil
+			return []byte(signingKey), nil
 		})
 	if err != nil {
-		return 0, fmt.Errorf("error parse token %s", err)
+		return nil, fmt.Errorf("error parse token %s", err)
 	}
 
 	claims, ok := token.Claims.(*tokenClaims)
 	if !ok {
-		return 0, fmt.Errorf("invalid type of token claims %s", err)
+		return nil, fmt.Errorf("invalid type of token claims %s", err)
+	}
+
+	return claims, nil
+}
+
+// normalizeScopes проверяет запрошенные права и приводит их к отсортированному списку без повторов.
+// Пустой список означает все права. Возвращает неизвестное право, если оно встретилось
+func normalizeScopes(requested []string) ([]string, string) {
+	if len(requested) == 0 {
+		return slices.Clone(domain.Scopes), ""
+	}
+
+	for _, scope := range requested {
+		if !slices.Contains(domain.Scopes, scope) {
+			return nil, scope
+		}
 	}
 
-	return claims.UserId, nil
+	scopes := slices.Clone(requested)
+	slices.Sort(scopes)
+	return slices.Compact(scopes), ""
 }
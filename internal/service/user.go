@@ -4,155 +4,288 @@ import (
 	"avito-shop/internal/domain"
 	"avito-shop/internal/erorrs"
 	"avito-shop/internal/logger"
+	"avito-shop/internal/metrics"
 	"avito-shop/internal/model"
+	"avito-shop/internal/tracing"
 	"context"
 	"database/sql"
 	"errors"
 	"go.uber.org/zap"
+	"time"
 )
 
 //go:generate go run github.com/vektra/mockery/v2@latest --name=RepoUserInterface
 type RepoUserInterface interface {
 	BuyItem(ctx context.Context, user domain.User, item domain.Item) error
-	SendCoins(ctx context.Context, fromUserID int, toUserID int, amount int) error
+	SendCoins(ctx context.Context, transfer domain.Transfer) error
 	GetItem(ctx context.Context, itemName string) (domain.Item, error)
 	GetPurchasedItems(ctx context.Context, userID int) ([]model.ItemDTO, error)
 	GetUser(ctx context.Context, userID int) (domain.User, error)
 	GetUserByName(ctx context.Context, toUser string) (int, error)
 	GetCoinHistory(ctx context.Context, userID int, currentUsername string) (model.CoinHistoryDTO, error)
+	GetWallets(ctx context.Context, userID int) ([]model.WalletDTO, error)
+	GetBalance(ctx context.Context, userID int, currency string) (domain.Money, error)
+	GetExpiringCoins(ctx context.Context, userID int, before time.Time) ([]model.ExpiringCoinsDTO, error)
+	ExpireCoins(ctx context.Context, now time.Time) (int, error)
+	CheckCurrency(ctx context.Context, currency string) error
 }
 
 type UserService struct {
 	repo   RepoUserInterface
+	audit  AuditRecorderInterface
 	logger logger.Logger
+	expiry domain.ExpiryPolicy
+	fees   domain.FeeRule
 }
 
-func NewUserService(repo RepoUserInterface, logger logger.Logger) *UserService {
+func NewUserService(repo RepoUserInterface, audit AuditRecorderInterface, logger logger.Logger, expiry domain.ExpiryPolicy, fees domain.FeeRule) *UserService {
 	return &UserService{
 		repo:   repo,
+		audit:  audit,
 		logger: logger,
+		expiry: expiry,
+		fees:   fees,
 	}
 }
 
-func (s *UserService) SendCoinToUser(ctx context.Context, fromUserID int, toUser string, amount int) error {
+// log возвращает логгер запроса из ctx, а если его нет - логгер сервиса
+func (s *UserService) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, s.logger)
+}
+
+func (s *UserService) SendCoinToUser(ctx context.Context, fromUserID int, toUser string, amount domain.Money, currency string) error {
+	ctx, span := tracing.Start(ctx, "service.User.SendCoinToUser")
+	defer span.End()
+
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+
 	toUserID, err := s.repo.GetUserByName(ctx, toUser)
 	if err != nil {
-		s.logger.Error("service.User.SendCoinToUser: error getting user", zap.Error(err))
+		s.log(ctx).Error("service.User.SendCoinToUser: error getting user", zap.Error(err))
 		return err
 	}
 
 	if fromUserID == toUserID {
-		s.logger.Error("service.User.SendCoinToUser: ", zap.Error(err))
+		s.log(ctx).Error("service.User.SendCoinToUser: ", zap.Error(err))
 		return erorrs.ErrSelfTransfer
 	}
 
-	if err := s.repo.SendCoins(ctx, fromUserID, toUserID, amount); err != nil {
+	transfer := domain.Transfer{
+		FromUserID: fromUserID,
+		ToUserID:   toUserID,
+		Amount:     amount,
+		Fee:        s.fees.Calculate(currency, amount),
+		Currency:   currency,
+	}
+
+	if err := s.repo.SendCoins(ctx, transfer); err != nil {
 		switch {
+		case errors.Is(err, erorrs.ErrCurrencyNotFound):
+			s.log(ctx).Error("service.User.SendCoinToUser: currency not found", zap.Error(err))
+			return erorrs.ErrCurrencyNotFound
 		case errors.Is(err, erorrs.ErrNotFound):
-			s.logger.Error("service.User.SendCoinToUser: user not found", zap.Error(err))
+			s.log(ctx).Error("service.User.SendCoinToUser: user not found", zap.Error(err))
 			return erorrs.ErrNotFound
 		case errors.Is(err, erorrs.ErrInsufficientFunds):
-			s.logger.Error("service.User.SendCoinToUser: money not enough", zap.Error(err))
+			s.log(ctx).Error("service.User.SendCoinToUser: money not enough", zap.Error(err))
+			metrics.InsufficientFunds(metrics.OperationSendCoin)
 			return erorrs.ErrInsufficientFunds
 		default:
-			s.logger.Error("service.User.SendCoinToUser: error sending coin", zap.Error(err))
+			s.log(ctx).Error("service.User.SendCoinToUser: error sending coin", zap.Error(err))
 			return err
 		}
 	}
 
-	s.logger.Info("money sent successfully", zap.Int("fromUserID", fromUserID), zap.Int("toUserID", toUserID), zap.Int("amount", amount))
+	metrics.CoinsTransferred(currency, amount)
+	recordAudit(ctx, s.audit, s.log(ctx), domain.AuditEntry{
+		ActorID: fromUserID,
+		Action:  domain.AuditTransfer,
+		Target:  userTarget(toUser),
+		Details: map[string]string{
+			"amount":   amount.String(),
+			"fee":      transfer.Fee.String(),
+			"currency": currency,
+		},
+	})
+	s.log(ctx).Info("money sent successfully", zap.Int("fromUserID", fromUserID), zap.Int("toUserID", toUserID), zap.Stringer("amount", amount), zap.Stringer("fee", transfer.Fee), zap.String("currency", currency))
 
 	return nil
 }
 
+// QuoteTransfer рассчитывает комиссию и итоговую сумму списания для перевода без его выполнения.
+// Для несуществующей валюты возвращает ErrCurrencyNotFound, как и сам перевод
+func (s *UserService) QuoteTransfer(ctx context.Context, amount domain.Money, currency string) (model.TransferQuoteDTO, error) {
+	ctx, span := tracing.Start(ctx, "service.User.QuoteTransfer")
+	defer span.End()
+
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+
+	if err := s.repo.CheckCurrency(ctx, currency); err != nil {
+		if !errors.Is(err, erorrs.ErrCurrencyNotFound) {
+			s.log(ctx).Error("service.User.QuoteTransfer: error checking currency", zap.Error(err))
+		}
+		return model.TransferQuoteDTO{}, err
+	}
+
+	fee := s.fees.Calculate(currency, amount)
+
+	return model.TransferQuoteDTO{
+		Amount:   amount,
+		Fee:      fee,
+		Total:    amount + fee,
+		Currency: currency,
+	}, nil
+}
+
 func (s *UserService) BuyItem(ctx context.Context, userID int, input model.BuyItemRequestDTO) error {
+	ctx, span := tracing.Start(ctx, "service.User.BuyItem")
+	defer span.End()
+
 	item, err := s.repo.GetItem(ctx, input.Item)
 	if err != nil {
 		if errors.Is(err, erorrs.ErrItemNotFound) {
-			s.logger.Error("service.User.BuyItem: item not found", zap.Error(err))
+			s.log(ctx).Error("service.User.BuyItem: item not found", zap.Error(err))
 			return erorrs.ErrItemNotFound
 		}
-		s.logger.Error("service.User.BuyItem: error getting item", zap.Error(err))
+		s.log(ctx).Error("service.User.BuyItem: error getting item", zap.Error(err))
 		return err
 	}
 
 	user, err := s.repo.GetUser(ctx, userID)
 	if err != nil {
 		if errors.Is(err, erorrs.ErrNotFound) {
-			s.logger.Error("service.User.BuyItem: user not found", zap.Error(err))
+			s.log(ctx).Error("service.User.BuyItem: user not found", zap.Error(err))
 			return erorrs.ErrNotFound
 		}
-		s.logger.Error("service.User.BuyItem: error getting user", zap.Error(err))
+		s.log(ctx).Error("service.User.BuyItem: error getting user", zap.Error(err))
+		return err
+	}
+
+	balance, err := s.repo.GetBalance(ctx, user.ID, item.Currency)
+	if err != nil {
+		s.log(ctx).Error("service.User.BuyItem: error getting balance", zap.Error(err))
 		return err
 	}
 
-	if user.Coins < item.Price {
-		s.logger.Error("service.User.ByItem: insufficient funds", zap.Error(err))
+	if balance < item.Price {
+		s.log(ctx).Error("service.User.ByItem: insufficient funds", zap.Error(err))
+		metrics.InsufficientFunds(metrics.OperationBuyItem)
 		return erorrs.ErrInsufficientFunds
 	}
 
 	err = s.repo.BuyItem(ctx, user, item)
 	if err != nil {
 		if errors.Is(err, erorrs.ErrNotFound) {
-			s.logger.Error("service.User.ByItem: rows not found", zap.Error(err))
+			s.log(ctx).Error("service.User.ByItem: rows not found", zap.Error(err))
 			return erorrs.ErrNotFound
 		}
-		s.logger.Error("service.User.ByItem: error buy", zap.Error(err))
+		if errors.Is(err, erorrs.ErrInsufficientFunds) {
+			s.log(ctx).Error("service.User.ByItem: insufficient funds", zap.Error(err))
+			metrics.InsufficientFunds(metrics.OperationBuyItem)
+			return erorrs.ErrInsufficientFunds
+		}
+		s.log(ctx).Error("service.User.ByItem: error buy", zap.Error(err))
 		return err
 	}
 
-	s.logger.Info("item bought successfully", zap.Int("userID", userID))
+	metrics.ItemBought(item.Name)
+	s.log(ctx).Info("item bought successfully", zap.Int("userID", userID))
 	return nil
 }
 
 func (s *UserService) GetUserInfo(ctx context.Context, userID int) (model.InfoResponseDTO, error) {
+	ctx, span := tracing.Start(ctx, "service.User.GetUserInfo")
+	defer span.End()
+
 	items, err := s.getUserItems(ctx, userID)
 	if err != nil {
 		if errors.Is(err, erorrs.ErrItemNotFound) {
-			s.logger.Error("service.User.GetUserInfo: items is null", zap.Error(err))
+			s.log(ctx).Error("service.User.GetUserInfo: items is null", zap.Error(err))
 			items = []model.ItemDTO{}
 		} else {
-			s.logger.Error("service.User.GetUserInfo: error getting info", zap.Error(err))
+			s.log(ctx).Error("service.User.GetUserInfo: error getting info", zap.Error(err))
 			return model.InfoResponseDTO{}, err
 		}
 	}
 
 	balance, userName, err := s.getUserNameBalance(ctx, userID)
 	if err != nil {
-		s.logger.Error("service.User.GetUserInfo: error getting user name and balance", zap.Error(err))
+		s.log(ctx).Error("service.User.GetUserInfo: error getting user name and balance", zap.Error(err))
+		return model.InfoResponseDTO{}, err
+	}
+
+	wallets, err := s.repo.GetWallets(ctx, userID)
+	if err != nil {
+		s.log(ctx).Error("service.User.GetUserInfo: error get wallets", zap.Error(err))
 		return model.InfoResponseDTO{}, err
 	}
 
 	coinHistory, err := s.repo.GetCoinHistory(ctx, userID, userName)
 	if err != nil {
-		s.logger.Error("service.User.GetUserInfo: error get coin history", zap.Error(err))
+		s.log(ctx).Error("service.User.GetUserInfo: error get coin history", zap.Error(err))
+		return model.InfoResponseDTO{}, err
+	}
+
+	expiringSoon, err := s.repo.GetExpiringCoins(ctx, userID, time.Now().Add(s.expiry.SoonWindow))
+	if err != nil {
+		s.log(ctx).Error("service.User.GetUserInfo: error get expiring coins", zap.Error(err))
 		return model.InfoResponseDTO{}, err
 	}
 
 	return model.InfoResponseDTO{
-		Coins:       balance,
-		Inventory:   items,
-		CoinHistory: coinHistory,
+		Coins:        balance,
+		Inventory:    items,
+		Wallets:      wallets,
+		CoinHistory:  coinHistory,
+		ExpiringSoon: expiringSoon,
 	}, nil
 }
 
+// ExpireCoins списывает монеты, срок жизни которых истек к моменту now
+func (s *UserService) ExpireCoins(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := tracing.Start(ctx, "service.User.ExpireCoins")
+	defer span.End()
+
+	affected, err := s.repo.ExpireCoins(ctx, now)
+	if err != nil {
+		s.log(ctx).Error("service.User.ExpireCoins: error expire coins", zap.Error(err))
+		return 0, err
+	}
+
+	if affected > 0 {
+		s.log(ctx).Info("coins expired", zap.Int("users", affected))
+	}
+
+	return affected, nil
+}
+
 func (s *UserService) getUserItems(ctx context.Context, userID int) ([]model.ItemDTO, error) {
+	ctx, span := tracing.Start(ctx, "service.User.getUserItems")
+	defer span.End()
+
 	items, err := s.repo.GetPurchasedItems(ctx, userID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			s.logger.Error("service.User.getUserItems: no items", zap.Error(err))
+			s.log(ctx).Error("service.User.getUserItems: no items", zap.Error(err))
 			return []model.ItemDTO{}, erorrs.ErrItemNotFound
 		}
-		s.logger.Error("service.User.getUserItems: error get user items", zap.Error(err))
+		s.log(ctx).Error("service.User.getUserItems: error get user items", zap.Error(err))
 		return nil, err
 	}
 	return items, nil
 }
 
-func (s *UserService) getUserNameBalance(ctx context.Context, userID int) (int, string, error) {
+func (s *UserService) getUserNameBalance(ctx context.Context, userID int) (domain.Money, string, error) {
+	ctx, span := tracing.Start(ctx, "service.User.getUserNameBalance")
+	defer span.End()
+
 	user, err := s.repo.GetUser(ctx, userID)
 	if err != nil {
-		s.logger.Error("service.User.getUserNameBalance: error", zap.Error(err))
+		s.log(ctx).Error("service.User.getUserNameBalance: error", zap.Error(err))
 		return 0, "", err
 	}
 
@@ -13,19 +13,22 @@ import (
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestUserService_SendCoinToUser(t *testing.T) {
 	mockRepo := mocks.NewRepoUserInterface(t)
+	mockAudit := mocks.NewAuditRecorderInterface(t)
 	mockLogger := mocks2.NewLogger(t)
-	userService := NewUserService(mockRepo, mockLogger)
+	userService := NewUserService(mockRepo, mockAudit, mockLogger, domain.ExpiryPolicy{}, domain.FeeRule{})
 
 	tests := []struct {
 		name          string
 		fromUserID    int
 		toUser        string
-		amount        int
+		amount        domain.Money
 		mockRepo      func()
 		mockLogger    func()
 		expectedError error
@@ -34,13 +37,21 @@ func TestUserService_SendCoinToUser(t *testing.T) {
 			name:       "success",
 			fromUserID: 1,
 			toUser:     "user2",
-			amount:     500,
+			amount:     domain.NewMoney(500),
 			mockRepo: func() {
 				mockRepo.On("GetUserByName", mock.Anything, "user2").Return(2, nil)
-				mockRepo.On("SendCoins", mock.Anything, 1, 2, 500).Return(nil)
+				mockRepo.On("SendCoins", mock.Anything, domain.Transfer{
+					FromUserID: 1, ToUserID: 2, Amount: domain.NewMoney(500), Currency: domain.DefaultCurrency,
+				}).Return(nil)
+				mockAudit.On("Record", mock.Anything, domain.AuditEntry{
+					ActorID: 1,
+					Action:  domain.AuditTransfer,
+					Target:  "user:user2",
+					Details: map[string]string{"amount": "500.00", "fee": "0.00", "currency": domain.DefaultCurrency},
+				}).Return(nil).Once()
 			},
 			mockLogger: func() {
-				mockLogger.On("Info", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				mockLogger.On("Info", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 			},
 			expectedError: nil,
 		},
@@ -48,7 +59,7 @@ func TestUserService_SendCoinToUser(t *testing.T) {
 			name:       "fromUser toUser ID is same",
 			fromUserID: 1,
 			toUser:     "user1",
-			amount:     5,
+			amount:     domain.NewMoney(5),
 			mockRepo: func() {
 				mockRepo.On("GetUserByName", mock.Anything, "user1").Return(1, nil)
 			},
@@ -61,10 +72,12 @@ func TestUserService_SendCoinToUser(t *testing.T) {
 			name:       "not enough money",
 			fromUserID: 1,
 			toUser:     "user2",
-			amount:     2000,
+			amount:     domain.NewMoney(2000),
 			mockRepo: func() {
 				mockRepo.On("GetUserByName", mock.Anything, "user2").Return(2, nil)
-				mockRepo.On("SendCoins", mock.Anything, 1, 2, 2000).Return(erorrs.ErrInsufficientFunds)
+				mockRepo.On("SendCoins", mock.Anything, domain.Transfer{
+					FromUserID: 1, ToUserID: 2, Amount: domain.NewMoney(2000), Currency: domain.DefaultCurrency,
+				}).Return(erorrs.ErrInsufficientFunds)
 			},
 			mockLogger: func() {
 				mockLogger.On("Error", mock.Anything, mock.Anything)
@@ -78,7 +91,7 @@ func TestUserService_SendCoinToUser(t *testing.T) {
 			tt.mockRepo()
 			tt.mockLogger()
 
-			err := userService.SendCoinToUser(context.Background(), tt.fromUserID, tt.toUser, tt.amount)
+			err := userService.SendCoinToUser(context.Background(), tt.fromUserID, tt.toUser, tt.amount, "")
 
 			assert.Equal(t, tt.expectedError, err)
 			mockRepo.AssertExpectations(t)
@@ -87,10 +100,61 @@ func TestUserService_SendCoinToUser(t *testing.T) {
 	}
 }
 
+func TestUserService_SendCoinToUser_WithFee(t *testing.T) {
+	mockRepo := mocks.NewRepoUserInterface(t)
+	mockLogger := mocks2.NewLogger(t)
+	mockAudit := mocks.NewAuditRecorderInterface(t)
+	fees := domain.FeeRule{BasisPoints: 100, Currencies: []string{domain.DefaultCurrency}}
+	userService := NewUserService(mockRepo, mockAudit, mockLogger, domain.ExpiryPolicy{}, fees)
+
+	mockRepo.On("GetUserByName", mock.Anything, "user2").Return(2, nil)
+	mockRepo.On("SendCoins", mock.Anything, domain.Transfer{
+		FromUserID: 1, ToUserID: 2, Amount: domain.NewMoney(500), Fee: domain.NewMoney(5), Currency: domain.DefaultCurrency,
+	}).Return(nil)
+	mockLogger.On("Info", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockAudit.On("Record", mock.Anything, mock.MatchedBy(func(entry domain.AuditEntry) bool {
+		return entry.Details["fee"] == "5.00"
+	})).Return(nil).Once()
+
+	err := userService.SendCoinToUser(context.Background(), 1, "user2", domain.NewMoney(500), "")
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_QuoteTransfer(t *testing.T) {
+	fees := domain.FeeRule{Flat: domain.NewMoney(1), Currencies: []string{domain.DefaultCurrency}}
+	mockRepo := mocks.NewRepoUserInterface(t)
+	mockRepo.On("CheckCurrency", mock.Anything, domain.DefaultCurrency).Return(nil)
+	mockRepo.On("CheckCurrency", mock.Anything, "karma").Return(nil)
+	mockRepo.On("CheckCurrency", mock.Anything, "doge").Return(erorrs.ErrCurrencyNotFound)
+	userService := NewUserService(mockRepo, nil, mocks2.NewLogger(t), domain.ExpiryPolicy{}, fees)
+
+	quote, err := userService.QuoteTransfer(context.Background(), domain.NewMoney(10), "")
+	require.NoError(t, err)
+	assert.Equal(t, model.TransferQuoteDTO{
+		Amount:   domain.NewMoney(10),
+		Fee:      domain.NewMoney(1),
+		Total:    domain.NewMoney(11),
+		Currency: domain.DefaultCurrency,
+	}, quote)
+
+	quote, err = userService.QuoteTransfer(context.Background(), domain.NewMoney(10), "karma")
+	require.NoError(t, err)
+	assert.Equal(t, model.TransferQuoteDTO{
+		Amount:   domain.NewMoney(10),
+		Total:    domain.NewMoney(10),
+		Currency: "karma",
+	}, quote)
+
+	_, err = userService.QuoteTransfer(context.Background(), domain.NewMoney(10), "doge")
+	assert.ErrorIs(t, err, erorrs.ErrCurrencyNotFound)
+}
+
 func TestUserService_BuyItem(t *testing.T) {
 	mockRepo := mocks.NewRepoUserInterface(t)
 	mockLogger := mocks2.NewLogger(t)
-	userService := NewUserService(mockRepo, mockLogger)
+	userService := NewUserService(mockRepo, nil, mockLogger, domain.ExpiryPolicy{}, domain.FeeRule{})
 
 	tests := []struct {
 		name          string
@@ -109,6 +173,7 @@ func TestUserService_BuyItem(t *testing.T) {
 			mockRepo: func() {
 				mockRepo.On("GetItem", mock.Anything, "cup").Return(domain.Item{ID: 1, Name: "cup", Price: 20}, nil)
 				mockRepo.On("GetUser", mock.Anything, 1).Return(domain.User{ID: 1, Username: "user1", PasswordHash: "", Coins: 1000}, nil)
+				mockRepo.On("GetBalance", mock.Anything, 1, mock.Anything).Return(domain.Money(1000), nil)
 				mockRepo.On("BuyItem", mock.Anything, domain.User{ID: 1, Username: "user1", Coins: 1000}, domain.Item{ID: 1, Name: "cup", Price: 20}).Return(nil)
 			},
 			mockLogger: func() {
@@ -148,6 +213,7 @@ func TestUserService_BuyItem(t *testing.T) {
 			mockRepo: func() {
 				mockRepo.On("GetItem", mock.Anything, "cup").Return(domain.Item{ID: 1, Name: "cup", Price: 20}, nil)
 				mockRepo.On("GetUser", mock.Anything, 2).Return(domain.User{ID: 2, Username: "user2", Coins: 2}, nil)
+				mockRepo.On("GetBalance", mock.Anything, 2, mock.Anything).Return(domain.Money(2), nil)
 			},
 			mockLogger: func() {
 				mockLogger.On("Error", mock.Anything, mock.Anything)
@@ -161,6 +227,7 @@ func TestUserService_BuyItem(t *testing.T) {
 			mockRepo: func() {
 				mockRepo.On("GetItem", mock.Anything, "cup").Return(domain.Item{ID: 3, Name: "cup", Price: 20}, nil)
 				mockRepo.On("GetUser", mock.Anything, 3).Return(domain.User{ID: 3, Username: "user3", Coins: 1000}, nil)
+				mockRepo.On("GetBalance", mock.Anything, 3, mock.Anything).Return(domain.Money(1000), nil)
 				mockRepo.On("BuyItem", mock.Anything, domain.User{ID: 3, Username: "user3", Coins: 1000}, domain.Item{ID: 1, Name: "cup", Price: 20}).Return(erorrs.ErrNotFound)
 			},
 			mockLogger: func() {
@@ -187,7 +254,8 @@ func TestUserService_BuyItem(t *testing.T) {
 func TestUserService_GetUserInfo(t *testing.T) {
 	mockRepo := mocks.NewRepoUserInterface(t)
 	mockLogger := mocks2.NewLogger(t)
-	service := NewUserService(mockRepo, mockLogger)
+	service := NewUserService(mockRepo, nil, mockLogger, domain.ExpiryPolicy{}, domain.FeeRule{})
+	expiresAt := time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)
 
 	tests := []struct {
 		name           string
@@ -207,9 +275,18 @@ func TestUserService_GetUserInfo(t *testing.T) {
 					nil)
 				m.On("GetUser", mock.Anything, 1).Return(domain.User{ID: 1, Username: "user1", Coins: 980},
 					nil)
+				m.On("GetWallets", mock.Anything, 1).Return([]model.WalletDTO{
+					{Currency: domain.DefaultCurrency, Balance: 980},
+					{Currency: "karma", Balance: 300},
+				},
+					nil)
 				m.On("GetCoinHistory", mock.Anything, 1, "user1").Return(model.CoinHistoryDTO{
-					[]model.TransactionHistoryDTO{},
-					[]model.TransactionHistoryDTO{},
+					Received: []model.TransactionHistoryDTO{},
+					Sent:     []model.TransactionHistoryDTO{},
+				},
+					nil)
+				m.On("GetExpiringCoins", mock.Anything, 1, mock.Anything).Return([]model.ExpiringCoinsDTO{
+					{Amount: 500, ExpiresAt: expiresAt},
 				},
 					nil)
 			},
@@ -221,10 +298,17 @@ func TestUserService_GetUserInfo(t *testing.T) {
 				Inventory: []model.ItemDTO{
 					{Type: "cup", Quantity: 1},
 				},
+				Wallets: []model.WalletDTO{
+					{Currency: domain.DefaultCurrency, Balance: 980},
+					{Currency: "karma", Balance: 300},
+				},
 				CoinHistory: model.CoinHistoryDTO{
 					Received: []model.TransactionHistoryDTO{},
 					Sent:     []model.TransactionHistoryDTO{},
 				},
+				ExpiringSoon: []model.ExpiringCoinsDTO{
+					{Amount: 500, ExpiresAt: expiresAt},
+				},
 			},
 			expectedError: nil,
 		},
@@ -234,7 +318,9 @@ func TestUserService_GetUserInfo(t *testing.T) {
 			mockRepo: func(m *mocks.RepoUserInterface) {
 				m.On("GetPurchasedItems", mock.Anything, 2).Return(nil, erorrs.ErrItemNotFound)
 				m.On("GetUser", mock.Anything, 2).Return(domain.User{}, nil)
+				m.On("GetWallets", mock.Anything, 2).Return(nil, nil)
 				m.On("GetCoinHistory", mock.Anything, 2, "").Return(model.CoinHistoryDTO{}, nil)
+				m.On("GetExpiringCoins", mock.Anything, 2, mock.Anything).Return(nil, nil)
 			},
 			mockLogger: func(l *mocks2.Logger) {
 				l.On("Error", mock.Anything, mock.Anything).Maybe()
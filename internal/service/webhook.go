@@ -0,0 +1,284 @@
+package service
+
+import (
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	"avito-shop/internal/events"
+	"avito-shop/internal/logger"
+	"avito-shop/internal/metrics"
+	"avito-shop/internal/model"
+	"avito-shop/internal/tracing"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"go.uber.org/zap"
+	"slices"
+	"strings"
+	"time"
+)
+
+// deliveryLease - на сколько откладывается взятая в работу доставка. Должно хватать
+// на отправку всей пачки, иначе другая реплика отправит доставку повторно
+const deliveryLease = 5 * time.Minute
+
+// deliveriesLimit - сколько последних доставок показывать в журнале подписки
+const deliveriesLimit = 100
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=RepoWebhookInterface
+type RepoWebhookInterface interface {
+	CreateSubscription(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int) error
+	EnqueueDeliveries(ctx context.Context, eventType string, payload []byte) (int, error)
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, attempt domain.WebhookAttempt, status string, nextAttemptAt time.Time) error
+	RequeueDelivery(ctx context.Context, subscriptionID int, deliveryID int64) error
+	GetDeliveries(ctx context.Context, subscriptionID int, limit int) ([]model.WebhookDeliveryDTO, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=WebhookSenderInterface
+type WebhookSenderInterface interface {
+	Send(ctx context.Context, delivery domain.WebhookDelivery) (int, error)
+}
+
+type WebhookService struct {
+	repo      RepoWebhookInterface
+	sender    WebhookSenderInterface
+	audit     AuditRecorderInterface
+	logger    logger.Logger
+	retry     domain.RetryPolicy
+	batchSize int
+}
+
+func NewWebhookService(repo RepoWebhookInterface, sender WebhookSenderInterface, audit AuditRecorderInterface, logger logger.Logger, retry domain.RetryPolicy, batchSize int) *WebhookService {
+	return &WebhookService{
+		repo:      repo,
+		sender:    sender,
+		audit:     audit,
+		logger:    logger,
+		retry:     retry,
+		batchSize: batchSize,
+	}
+}
+
+// log возвращает логгер запроса из ctx, а если его нет - логгер сервиса
+func (s *WebhookService) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, s.logger)
+}
+
+// CreateSubscription создает подписку. Если секрет не передан, он генерируется и возвращается
+// один раз в ответе
+func (s *WebhookService) CreateSubscription(ctx context.Context, actorID int, dto model.WebhookSubscriptionRequestDTO) (model.WebhookSubscriptionDTO, error) {
+	for _, eventType := range dto.EventTypes {
+		if !slices.Contains(events.Types, eventType) {
+			s.log(ctx).Info("service.Webhook.CreateSubscription: unknown event type", zap.String("type", eventType))
+			return model.WebhookSubscriptionDTO{}, erorrs.ErrUnknownEventType
+		}
+	}
+
+	secret := dto.Secret
+	if secret == "" {
+		var err error
+		if secret, err = generateSecret(); err != nil {
+			s.log(ctx).Error("service.Webhook.CreateSubscription: error generating secret", zap.Error(err))
+			return model.WebhookSubscriptionDTO{}, err
+		}
+	}
+
+	eventTypes := slices.Clone(dto.EventTypes)
+	slices.Sort(eventTypes)
+
+	sub, err := s.repo.CreateSubscription(ctx, domain.WebhookSubscription{
+		URL:        dto.URL,
+		Secret:     secret,
+		EventTypes: slices.Compact(eventTypes),
+		CreatedBy:  actorID,
+	})
+	if err != nil {
+		s.log(ctx).Error("service.Webhook.CreateSubscription: error saving subscription", zap.Error(err))
+		return model.WebhookSubscriptionDTO{}, err
+	}
+
+	recordAudit(ctx, s.audit, s.log(ctx), domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditWebhookCreate,
+		Target:  webhookTarget(sub.ID),
+		Details: map[string]string{"url": sub.URL, "eventTypes": strings.Join(sub.EventTypes, ",")},
+	})
+	s.log(ctx).Info("webhook subscription created", zap.Int("subscriptionID", sub.ID), zap.Int("actorID", actorID))
+
+	result := subscriptionToDTO(sub)
+	result.Secret = sub.Secret
+	return result, nil
+}
+
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]model.WebhookSubscriptionDTO, error) {
+	subs, err := s.repo.ListSubscriptions(ctx)
+	if err != nil {
+		s.log(ctx).Error("service.Webhook.ListSubscriptions: error getting subscriptions", zap.Error(err))
+		return nil, err
+	}
+
+	result := make([]model.WebhookSubscriptionDTO, 0, len(subs))
+	for _, sub := range subs {
+		result = append(result, subscriptionToDTO(sub))
+	}
+
+	return result, nil
+}
+
+func (s *WebhookService) DeleteSubscription(ctx context.Context, actorID int, id int) error {
+	if err := s.repo.DeleteSubscription(ctx, id); err != nil {
+		s.log(ctx).Error("service.Webhook.DeleteSubscription: error deleting subscription", zap.Error(err))
+		return err
+	}
+
+	recordAudit(ctx, s.audit, s.log(ctx), domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditWebhookDelete,
+		Target:  webhookTarget(id),
+	})
+	s.log(ctx).Info("webhook subscription deleted", zap.Int("subscriptionID", id))
+	return nil
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, subscriptionID int) ([]model.WebhookDeliveryDTO, error) {
+	deliveries, err := s.repo.GetDeliveries(ctx, subscriptionID, deliveriesLimit)
+	if err != nil {
+		s.log(ctx).Error("service.Webhook.GetDeliveries: error getting deliveries", zap.Error(err))
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// RetryDelivery возвращает доставку из dead в очередь
+func (s *WebhookService) RetryDelivery(ctx context.Context, subscriptionID int, deliveryID int64) error {
+	if err := s.repo.RequeueDelivery(ctx, subscriptionID, deliveryID); err != nil {
+		s.log(ctx).Error("service.Webhook.RetryDelivery: error requeue delivery", zap.Error(err))
+		return err
+	}
+
+	s.log(ctx).Info("webhook delivery requeued", zap.Int64("deliveryID", deliveryID))
+	return nil
+}
+
+// Publish ставит событие в очередь доставки всем подписчикам этого типа событий
+func (s *WebhookService) Publish(ctx context.Context, event events.Event) error {
+	ctx, span := tracing.Start(ctx, "service.Webhook.Publish")
+	defer span.End()
+
+	payload, err := json.Marshal(model.WebhookEventDTO{
+		ID:         event.ID,
+		Type:       event.Type,
+		UserID:     event.UserID,
+		Data:       event.Data,
+		OccurredAt: event.OccurredAt.UTC(),
+	})
+	if err != nil {
+		s.log(ctx).Error("service.Webhook.Publish: error marshal payload", zap.Error(err))
+		return err
+	}
+
+	if _, err := s.repo.EnqueueDeliveries(ctx, event.Type, payload); err != nil {
+		s.log(ctx).Error("service.Webhook.Publish: error enqueue deliveries", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// DeliverDue отправляет доставки, время которых подошло к моменту now, и возвращает число успешных
+func (s *WebhookService) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := tracing.Start(ctx, "service.Webhook.DeliverDue")
+	defer span.End()
+
+	deliveries, err := s.repo.ClaimDueDeliveries(ctx, now, deliveryLease, s.batchSize)
+	if err != nil {
+		s.log(ctx).Error("service.Webhook.DeliverDue: error claim deliveries", zap.Error(err))
+		return 0, err
+	}
+
+	delivered := 0
+	for _, delivery := range deliveries {
+		ok, err := s.deliver(ctx, delivery)
+		if err != nil {
+			return delivered, err
+		}
+		if ok {
+			delivered++
+		}
+	}
+
+	return delivered, nil
+}
+
+// deliver выполняет одну попытку и решает, что делать дальше: отметить доставленной,
+// запланировать повтор или отправить в dead
+func (s *WebhookService) deliver(ctx context.Context, delivery domain.WebhookDelivery) (bool, error) {
+	start := time.Now()
+	statusCode, sendErr := s.sender.Send(ctx, delivery)
+
+	// При остановке попытка не засчитывается: доставка вернется в очередь по истечении lease
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	attempt := domain.WebhookAttempt{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts + 1,
+		StatusCode: statusCode,
+		Duration:   time.Since(start),
+	}
+
+	status, next, result := domain.WebhookDelivered, time.Now(), metrics.WebhookDelivered
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+
+		if s.retry.Exhausted(attempt.Attempt) {
+			status, result = domain.WebhookDead, metrics.WebhookDead
+			s.log(ctx).Error("webhook delivery moved to dead letters",
+				zap.Int64("deliveryID", delivery.ID),
+				zap.Int("subscriptionID", delivery.SubscriptionID),
+				zap.Int("attempts", attempt.Attempt),
+				zap.Error(sendErr),
+			)
+		} else {
+			status, result = domain.WebhookPending, metrics.WebhookRetry
+			next = next.Add(s.retry.Delay(attempt.Attempt))
+			s.log(ctx).Info("webhook delivery failed, will retry",
+				zap.Int64("deliveryID", delivery.ID),
+				zap.Int("attempt", attempt.Attempt),
+				zap.Time("nextAttemptAt", next),
+				zap.Error(sendErr),
+			)
+		}
+	}
+
+	if err := s.repo.RecordAttempt(ctx, attempt, status, next); err != nil {
+		s.log(ctx).Error("service.Webhook.deliver: error record attempt", zap.Int64("deliveryID", delivery.ID), zap.Error(err))
+		return false, err
+	}
+
+	metrics.WebhookAttempt(result)
+	return sendErr == nil, nil
+}
+
+func subscriptionToDTO(sub domain.WebhookSubscription) model.WebhookSubscriptionDTO {
+	return model.WebhookSubscriptionDTO{
+		ID:         sub.ID,
+		URL:        sub.URL,
+		EventTypes: sub.EventTypes,
+		CreatedAt:  sub.CreatedAt,
+	}
+}
+
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,158 @@
+//go:build unit
+// +build unit
+
+package service
+
+import (
+	"avito-shop/internal/domain"
+	"avito-shop/internal/erorrs"
+	"avito-shop/internal/events"
+	mocks2 "avito-shop/internal/logger/mocks"
+	"avito-shop/internal/model"
+	"avito-shop/internal/service/mocks"
+	"avito-shop/internal/webhook"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var testRetry = domain.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour}
+
+func TestWebhookService_DeliverDue(t *testing.T) {
+	tests := []struct {
+		name           string
+		attempts       int
+		sendStatus     int
+		sendErr        error
+		expectedStatus string
+		expectedDelay  time.Duration
+		delivered      int
+	}{
+		{name: "delivered", sendStatus: http.StatusOK, expectedStatus: domain.WebhookDelivered, delivered: 1},
+		{name: "retry with backoff", attempts: 1, sendStatus: http.StatusBadGateway, sendErr: errors.New("unexpected status 502"), expectedStatus: domain.WebhookPending, expectedDelay: 2 * time.Minute},
+		{name: "dead after last attempt", attempts: 2, sendErr: errors.New("connection refused"), expectedStatus: domain.WebhookDead},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewRepoWebhookInterface(t)
+			mockSender := mocks.NewWebhookSenderInterface(t)
+			mockLogger := mocks2.NewLogger(t)
+			mockLogger.On("Info", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+			mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+			s := NewWebhookService(mockRepo, mockSender, nil, mockLogger, testRetry, 10)
+
+			now := time.Now()
+			delivery := domain.WebhookDelivery{ID: 5, SubscriptionID: 1, URL: "http://hook", Attempts: tt.attempts}
+
+			mockRepo.On("ClaimDueDeliveries", mock.Anything, now, deliveryLease, 10).Return([]domain.WebhookDelivery{delivery}, nil)
+			mockSender.On("Send", mock.Anything, delivery).Return(tt.sendStatus, tt.sendErr)
+			mockRepo.On("RecordAttempt", mock.Anything, mock.MatchedBy(func(a domain.WebhookAttempt) bool {
+				return a.DeliveryID == 5 && a.Attempt == tt.attempts+1 && a.StatusCode == tt.sendStatus && (a.Error != "") == (tt.sendErr != nil)
+			}), tt.expectedStatus, mock.MatchedBy(func(next time.Time) bool {
+				delay := next.Sub(now)
+				return delay >= tt.expectedDelay && delay < tt.expectedDelay+time.Second
+			})).Return(nil)
+
+			delivered, err := s.DeliverDue(context.Background(), now)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.delivered, delivered)
+		})
+	}
+}
+
+func TestWebhookService_DeliverDue_Receiver(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	var body []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		received <- r
+	}))
+	defer receiver.Close()
+
+	mockRepo := mocks.NewRepoWebhookInterface(t)
+	s := NewWebhookService(mockRepo, webhook.NewSender(time.Second), nil, mocks2.NewLogger(t), testRetry, 10)
+
+	delivery := domain.WebhookDelivery{
+		ID:        9,
+		URL:       receiver.URL,
+		Secret:    "s3cret",
+		EventType: events.TypeCoinsReceived,
+		Payload:   []byte(`{"type":"coins.received","userId":2}`),
+	}
+	mockRepo.On("ClaimDueDeliveries", mock.Anything, mock.Anything, deliveryLease, 10).Return([]domain.WebhookDelivery{delivery}, nil)
+	mockRepo.On("RecordAttempt", mock.Anything, mock.MatchedBy(func(a domain.WebhookAttempt) bool {
+		return a.DeliveryID == 9 && a.Attempt == 1 && a.StatusCode == http.StatusOK
+	}), domain.WebhookDelivered, mock.Anything).Return(nil)
+
+	delivered, err := s.DeliverDue(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+
+	req := <-received
+	timestamp, err := strconv.ParseInt(req.Header.Get(webhook.TimestampHeader), 10, 64)
+	require.NoError(t, err)
+	assert.True(t, webhook.Verify("s3cret", timestamp, body, req.Header.Get(webhook.SignatureHeader)))
+	assert.Equal(t, events.TypeCoinsReceived, req.Header.Get(webhook.EventHeader))
+	assert.JSONEq(t, string(delivery.Payload), string(body))
+}
+
+func TestWebhookService_CreateSubscription(t *testing.T) {
+	mockRepo := mocks.NewRepoWebhookInterface(t)
+	mockLogger := mocks2.NewLogger(t)
+	mockLogger.On("Info", mock.Anything, mock.Anything, mock.Anything).Maybe()
+	mockAudit := mocks.NewAuditRecorderInterface(t)
+	s := NewWebhookService(mockRepo, mocks.NewWebhookSenderInterface(t), mockAudit, mockLogger, testRetry, 10)
+
+	_, err := s.CreateSubscription(context.Background(), 1, model.WebhookSubscriptionRequestDTO{
+		URL:        "https://hr.example.com/hook",
+		EventTypes: []string{"user.deleted"},
+	})
+	assert.ErrorIs(t, err, erorrs.ErrUnknownEventType)
+
+	mockRepo.On("CreateSubscription", mock.Anything, mock.MatchedBy(func(sub domain.WebhookSubscription) bool {
+		return len(sub.Secret) == 64 && sub.CreatedBy == 1 &&
+			assert.ObjectsAreEqual([]string{events.TypeCoinsReceived, events.TypePurchaseCompleted}, sub.EventTypes)
+	})).Return(func(_ context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+		sub.ID = 3
+		return sub, nil
+	})
+	mockAudit.On("Record", mock.Anything, domain.AuditEntry{
+		ActorID: 1,
+		Action:  domain.AuditWebhookCreate,
+		Target:  "webhook:3",
+		Details: map[string]string{
+			"url":        "https://hr.example.com/hook",
+			"eventTypes": events.TypeCoinsReceived + "," + events.TypePurchaseCompleted,
+		},
+	}).Return(nil).Once()
+
+	sub, err := s.CreateSubscription(context.Background(), 1, model.WebhookSubscriptionRequestDTO{
+		URL:        "https://hr.example.com/hook",
+		EventTypes: []string{events.TypePurchaseCompleted, events.TypeCoinsReceived, events.TypeCoinsReceived},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, sub.ID)
+	assert.Len(t, sub.Secret, 64)
+}
+
+func TestWebhookService_Publish(t *testing.T) {
+	mockRepo := mocks.NewRepoWebhookInterface(t)
+	s := NewWebhookService(mockRepo, mocks.NewWebhookSenderInterface(t), nil, mocks2.NewLogger(t), testRetry, 10)
+
+	mockRepo.On("EnqueueDeliveries", mock.Anything, events.TypePurchaseCompleted, mock.MatchedBy(func(payload []byte) bool {
+		return assert.Contains(t, string(payload), `"type":"purchase.completed","userId":4,"data":{"item":"cup"}`)
+	})).Return(1, nil)
+
+	err := s.Publish(context.Background(), events.Event{Type: events.TypePurchaseCompleted, UserID: 4, Data: []byte(`{"item":"cup"}`)})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,90 @@
+//go:build unit
+// +build unit
+
+package webhook
+
+import (
+	"avito-shop/internal/domain"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSender_Send(t *testing.T) {
+	var (
+		gotBody    []byte
+		gotHeaders http.Header
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotHeaders = r.Header.Clone()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	sender := NewSender(time.Second)
+	sender.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	delivery := domain.WebhookDelivery{
+		ID:        42,
+		URL:       receiver.URL,
+		Secret:    "secret",
+		EventType: "coins.received",
+		Payload:   []byte(`{"type":"coins.received"}`),
+	}
+
+	status, err := sender.Send(context.Background(), delivery)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, status)
+
+	assert.Equal(t, delivery.Payload, gotBody)
+	assert.Equal(t, "coins.received", gotHeaders.Get(EventHeader))
+	assert.Equal(t, "42", gotHeaders.Get(DeliveryHeader))
+
+	timestamp, err := strconv.ParseInt(gotHeaders.Get(TimestampHeader), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1700000000), timestamp)
+	assert.True(t, Verify("secret", timestamp, gotBody, gotHeaders.Get(SignatureHeader)))
+	assert.False(t, Verify("other", timestamp, gotBody, gotHeaders.Get(SignatureHeader)))
+	assert.False(t, Verify("secret", timestamp+1, gotBody, gotHeaders.Get(SignatureHeader)))
+}
+
+func TestSender_SendFailures(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/redirect":
+			http.Redirect(w, r, "/elsewhere", http.StatusFound)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer receiver.Close()
+
+	sender := NewSender(50 * time.Millisecond)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{name: "server error", path: "/error", expectedStatus: http.StatusInternalServerError},
+		{name: "redirect is not followed", path: "/redirect", expectedStatus: http.StatusFound},
+		{name: "timeout", path: "/slow", expectedStatus: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := sender.Send(context.Background(), domain.WebhookDelivery{URL: receiver.URL + tt.path, Payload: []byte(`{}`)})
+			assert.Error(t, err)
+			assert.Equal(t, tt.expectedStatus, status)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"avito-shop/internal/logger"
+	"context"
+	"go.uber.org/zap"
+	"time"
+)
+
+type CoinExpirer interface {
+	ExpireCoins(ctx context.Context, now time.Time) (int, error)
+}
+
+// ExpiryWorker периодически списывает монеты с истекшим сроком жизни
+type ExpiryWorker struct {
+	expirer  CoinExpirer
+	logger   logger.Logger
+	interval time.Duration
+}
+
+func NewExpiryWorker(expirer CoinExpirer, logger logger.Logger, interval time.Duration) *ExpiryWorker {
+	return &ExpiryWorker{
+		expirer:  expirer,
+		logger:   logger,
+		interval: interval,
+	}
+}
+
+// Run выполняет списание сразу при запуске и затем с интервалом interval до отмены ctx
+func (w *ExpiryWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.expire(ctx)
+
+		select {
+		case <-ctx.Done():
+			w.logger.Info("Expiry worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *ExpiryWorker) expire(ctx context.Context) {
+	if _, err := w.expirer.ExpireCoins(ctx, time.Now()); err != nil && ctx.Err() == nil {
+		w.logger.Error("worker.Expiry: error expire coins", zap.Error(err))
+	}
+}
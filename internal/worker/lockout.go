@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"avito-shop/internal/logger"
+	"context"
+	"go.uber.org/zap"
+	"time"
+)
+
+type LockoutPurger interface {
+	Purge(ctx context.Context) (int, error)
+}
+
+// LockoutWorker периодически удаляет устаревшие счетчики неудачных входов
+type LockoutWorker struct {
+	purger   LockoutPurger
+	logger   logger.Logger
+	interval time.Duration
+}
+
+func NewLockoutWorker(purger LockoutPurger, logger logger.Logger, interval time.Duration) *LockoutWorker {
+	return &LockoutWorker{
+		purger:   purger,
+		logger:   logger,
+		interval: interval,
+	}
+}
+
+// Run выполняет очистку сразу при запуске и затем с интервалом interval до отмены ctx
+func (w *LockoutWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			w.logger.Info("Lockout worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *LockoutWorker) purge(ctx context.Context) {
+	if _, err := w.purger.Purge(ctx); err != nil && ctx.Err() == nil {
+		w.logger.Error("worker.Lockout: error purge lockouts", zap.Error(err))
+	}
+}
@@ -0,0 +1,106 @@
+package worker
+
+import (
+	"avito-shop/internal/events"
+	"avito-shop/internal/logger"
+	"context"
+	"go.uber.org/zap"
+	"time"
+)
+
+type OutboxProcessor interface {
+	ProcessBatch(ctx context.Context, limit int, handle func(ctx context.Context, batch []events.Event) int) (int, error)
+}
+
+// OutboxRelay переносит события из outbox в публикаторы по возрастанию id. Событие отмечается
+// опубликованным, только когда его приняли все публикаторы, иначе оно и все следующие повторяются
+// в следующем цикле: доставка как минимум один раз и с сохранением порядка.
+//
+// Отказ любого публикатора останавливает очередь для всех пользователей, пока он не восстановится,
+// - это цена порядка. Повтор получают только публикаторы, еще не принявшие событие, поэтому
+// работающие публикаторы не получают дублей, пока релей не перезапущен
+type OutboxRelay struct {
+	outbox     OutboxProcessor
+	publishers events.Publishers
+	logger     logger.Logger
+	interval   time.Duration
+	batchSize  int
+
+	// stalledID - событие, на котором остановилась прошлая публикация, accepted - какие
+	// публикаторы его уже приняли
+	stalledID int64
+	accepted  []bool
+}
+
+func NewOutboxRelay(outbox OutboxProcessor, publishers events.Publishers, logger logger.Logger, interval time.Duration, batchSize int) *OutboxRelay {
+	return &OutboxRelay{
+		outbox:     outbox,
+		publishers: publishers,
+		logger:     logger,
+		interval:   interval,
+		batchSize:  batchSize,
+		accepted:   make([]bool, len(publishers)),
+	}
+}
+
+// Run публикует события сразу при запуске и затем с интервалом interval до отмены ctx
+func (w *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.relay(ctx)
+
+		select {
+		case <-ctx.Done():
+			w.logger.Info("Outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// relay разбирает очередь пачками, пока она не опустеет или публикация не прервется
+func (w *OutboxRelay) relay(ctx context.Context) {
+	for ctx.Err() == nil {
+		published, err := w.outbox.ProcessBatch(ctx, w.batchSize, w.publish)
+		if err != nil {
+			if ctx.Err() == nil {
+				w.logger.Error("worker.Outbox: error process batch", zap.Error(err))
+			}
+			return
+		}
+
+		if published < w.batchSize {
+			return
+		}
+	}
+}
+
+func (w *OutboxRelay) publish(ctx context.Context, batch []events.Event) int {
+	for i, event := range batch {
+		if event.ID != w.stalledID {
+			w.stalledID = event.ID
+			clear(w.accepted)
+		}
+
+		failed := false
+		for j, publisher := range w.publishers {
+			if w.accepted[j] {
+				continue
+			}
+			if err := publisher.Publish(ctx, event); err != nil {
+				w.logger.Error("worker.Outbox: error publish event", zap.Int64("eventID", event.ID), zap.String("type", event.Type), zap.Error(err))
+				failed = true
+				continue
+			}
+			w.accepted[j] = true
+		}
+
+		if failed {
+			return i
+		}
+	}
+
+	return len(batch)
+}
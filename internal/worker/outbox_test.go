@@ -0,0 +1,114 @@
+//go:build unit
+// +build unit
+
+package worker
+
+import (
+	"avito-shop/internal/events"
+	"avito-shop/internal/logger/mocks"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+// memoryOutbox отдает события пачками и запоминает, какие из них отмечены опубликованными
+type memoryOutbox struct {
+	pending   []events.Event
+	published []int64
+}
+
+func (o *memoryOutbox) ProcessBatch(ctx context.Context, limit int, handle func(context.Context, []events.Event) int) (int, error) {
+	batch := o.pending[:min(limit, len(o.pending))]
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	n := handle(ctx, batch)
+	for _, event := range batch[:n] {
+		o.published = append(o.published, event.ID)
+	}
+	o.pending = o.pending[n:]
+
+	return n, nil
+}
+
+type failingPublisher struct {
+	failOn int64
+	got    []int64
+}
+
+func (p *failingPublisher) Publish(_ context.Context, event events.Event) error {
+	if event.ID == p.failOn {
+		return errors.New("broker unavailable")
+	}
+	p.got = append(p.got, event.ID)
+	return nil
+}
+
+func TestOutboxRelay_PublishesInOrder(t *testing.T) {
+	outbox := &memoryOutbox{}
+	for id := int64(1); id <= 5; id++ {
+		outbox.pending = append(outbox.pending, events.Event{ID: id, Type: events.TypeBalanceChanged})
+	}
+
+	producer := events.NewMemoryProducer()
+	relay := NewOutboxRelay(outbox, events.Publishers{events.NewKafkaPublisher(producer, "shop-events")}, mocks.NewLogger(t), time.Second, 2)
+
+	relay.relay(context.Background())
+
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, outbox.published)
+	assert.Len(t, producer.Messages(), 5)
+}
+
+func TestOutboxRelay_StopsOnFailure(t *testing.T) {
+	outbox := &memoryOutbox{}
+	for id := int64(1); id <= 4; id++ {
+		outbox.pending = append(outbox.pending, events.Event{ID: id})
+	}
+
+	mockLogger := mocks.NewLogger(t)
+	mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Once()
+
+	publisher := &failingPublisher{failOn: 3}
+	relay := NewOutboxRelay(outbox, events.Publishers{publisher}, mockLogger, time.Second, 10)
+
+	relay.relay(context.Background())
+
+	// событие 3 и следующие за ним остаются в outbox и будут повторены по порядку
+	assert.Equal(t, []int64{1, 2}, outbox.published)
+	assert.Equal(t, []int64{1, 2}, publisher.got)
+	assert.Len(t, outbox.pending, 2)
+
+	publisher.failOn = 0
+	relay.relay(context.Background())
+	assert.Equal(t, []int64{1, 2, 3, 4}, outbox.published)
+}
+
+func TestOutboxRelay_RetriesOnlyFailedPublisher(t *testing.T) {
+	outbox := &memoryOutbox{}
+	for id := int64(1); id <= 3; id++ {
+		outbox.pending = append(outbox.pending, events.Event{ID: id})
+	}
+
+	mockLogger := mocks.NewLogger(t)
+	mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Twice()
+
+	healthy := &failingPublisher{}
+	broken := &failingPublisher{failOn: 2}
+	relay := NewOutboxRelay(outbox, events.Publishers{healthy, broken}, mockLogger, time.Second, 10)
+
+	relay.relay(context.Background())
+	relay.relay(context.Background())
+	assert.Equal(t, []int64{1}, outbox.published)
+
+	broken.failOn = 0
+	relay.relay(context.Background())
+
+	// исправный публикатор получил событие 2 один раз, хотя оно повторялось
+	assert.Equal(t, []int64{1, 2, 3}, healthy.got)
+	assert.Equal(t, []int64{1, 2, 3}, broken.got)
+	assert.Equal(t, []int64{1, 2, 3}, outbox.published)
+}
@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"avito-shop/internal/logger"
+	"context"
+	"go.uber.org/zap"
+	"time"
+)
+
+type WebhookDeliverer interface {
+	DeliverDue(ctx context.Context, now time.Time) (int, error)
+}
+
+// WebhookWorker периодически отправляет вебхуки из очереди доставки
+type WebhookWorker struct {
+	deliverer WebhookDeliverer
+	logger    logger.Logger
+	interval  time.Duration
+}
+
+func NewWebhookWorker(deliverer WebhookDeliverer, logger logger.Logger, interval time.Duration) *WebhookWorker {
+	return &WebhookWorker{
+		deliverer: deliverer,
+		logger:    logger,
+		interval:  interval,
+	}
+}
+
+// Run отправляет доставки сразу при запуске и затем с интервалом interval до отмены ctx
+func (w *WebhookWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.deliver(ctx)
+
+		select {
+		case <-ctx.Done():
+			w.logger.Info("Webhook worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *WebhookWorker) deliver(ctx context.Context) {
+	if _, err := w.deliverer.DeliverDue(ctx, time.Now()); err != nil && ctx.Err() == nil {
+		w.logger.Error("worker.Webhook: error deliver webhooks", zap.Error(err))
+	}
+}
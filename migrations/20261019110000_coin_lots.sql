@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS coin_lots (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    amount INTEGER NOT NULL,
+    remaining INTEGER NOT NULL,
+    granted_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ,
+    CHECK (amount > 0),
+    CHECK (remaining >= 0 AND remaining <= amount)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_coin_lots_user_id ON coin_lots(user_id) WHERE remaining > 0;
+CREATE INDEX IF NOT EXISTS idx_coin_lots_expires_at ON coin_lots(expires_at) WHERE remaining > 0;
+
+-- Накопленные до введения партий балансы считаются бессрочными
+INSERT INTO coin_lots (user_id, amount, remaining)
+SELECT id, balance, balance FROM users WHERE balance > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS coin_lots;
+-- +goose StatementEnd
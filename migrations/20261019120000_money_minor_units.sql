@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Суммы хранятся в минимальных единицах (сотых долях монеты)
+ALTER TABLE users ALTER COLUMN balance TYPE BIGINT USING balance * 100;
+ALTER TABLE items ALTER COLUMN price TYPE BIGINT USING price * 100;
+ALTER TABLE transfers ALTER COLUMN amount TYPE BIGINT USING amount * 100;
+ALTER TABLE coin_operations ALTER COLUMN amount TYPE BIGINT USING amount * 100;
+ALTER TABLE coin_lots ALTER COLUMN amount TYPE BIGINT USING amount * 100;
+ALTER TABLE coin_lots ALTER COLUMN remaining TYPE BIGINT USING remaining * 100;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE coin_lots ALTER COLUMN remaining TYPE INTEGER USING remaining / 100;
+ALTER TABLE coin_lots ALTER COLUMN amount TYPE INTEGER USING amount / 100;
+ALTER TABLE coin_operations ALTER COLUMN amount TYPE INTEGER USING amount / 100;
+ALTER TABLE transfers ALTER COLUMN amount TYPE INTEGER USING amount / 100;
+ALTER TABLE items ALTER COLUMN price TYPE INTEGER USING price / 100;
+ALTER TABLE users ALTER COLUMN balance TYPE INTEGER USING balance / 100;
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS currencies (
+    code VARCHAR(32) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL
+    );
+
+INSERT INTO currencies (code, name) VALUES
+                                        ('coin', 'Монеты магазина'),
+                                        ('karma', 'Карма');
+
+CREATE TABLE IF NOT EXISTS wallets (
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    currency VARCHAR(32) REFERENCES currencies(code),
+    balance BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, currency),
+    CHECK (balance >= 0)
+    );
+
+INSERT INTO wallets (user_id, currency, balance)
+SELECT id, 'coin', balance FROM users;
+
+ALTER TABLE users DROP COLUMN balance;
+
+ALTER TABLE items ADD COLUMN currency VARCHAR(32) NOT NULL DEFAULT 'coin' REFERENCES currencies(code);
+ALTER TABLE transfers ADD COLUMN currency VARCHAR(32) NOT NULL DEFAULT 'coin' REFERENCES currencies(code);
+ALTER TABLE coin_operations ADD COLUMN currency VARCHAR(32) NOT NULL DEFAULT 'coin' REFERENCES currencies(code);
+ALTER TABLE coin_lots ADD COLUMN currency VARCHAR(32) NOT NULL DEFAULT 'coin' REFERENCES currencies(code);
+
+DROP INDEX IF EXISTS idx_coin_lots_user_id;
+CREATE INDEX IF NOT EXISTS idx_coin_lots_user_currency ON coin_lots(user_id, currency) WHERE remaining > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_coin_lots_user_currency;
+CREATE INDEX IF NOT EXISTS idx_coin_lots_user_id ON coin_lots(user_id) WHERE remaining > 0;
+
+ALTER TABLE coin_lots DROP COLUMN currency;
+ALTER TABLE coin_operations DROP COLUMN currency;
+ALTER TABLE transfers DROP COLUMN currency;
+ALTER TABLE items DROP COLUMN currency;
+
+ALTER TABLE users ADD COLUMN balance BIGINT NOT NULL DEFAULT 0 CHECK (balance >= 0);
+UPDATE users u SET balance = w.balance FROM wallets w WHERE w.user_id = u.id AND w.currency = 'coin';
+
+DROP TABLE IF EXISTS wallets;
+DROP TABLE IF EXISTS currencies;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS fee BIGINT NOT NULL DEFAULT 0 CHECK (fee >= 0);
+
+-- Имя казначейства мог занять обычный пользователь, зарегистрированный до появления комиссий.
+-- Делать его счетом казначейства нельзя: миграция останавливается, имя нужно освободить вручную
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM users WHERE username = '__treasury__' AND role <> 'system') THEN
+        RAISE EXCEPTION 'username __treasury__ is taken by a non-system user';
+    END IF;
+END $$;
+
+-- Системный счет казначейства, на который зачисляются комиссии. Войти под ним нельзя:
+-- хэш пароля не совпадает ни с одним результатом GeneratePasswordHash
+INSERT INTO users (username, password_hash, role)
+VALUES ('__treasury__', '!', 'system')
+ON CONFLICT (username) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM users WHERE username = '__treasury__' AND role = 'system';
+ALTER TABLE transfers DROP COLUMN IF EXISTS fee;
+-- +goose StatementEnd
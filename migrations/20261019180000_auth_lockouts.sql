@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Счетчики неудачных входов и блокировки по имени пользователя (user:...) и IP (ip:...),
+-- общие для всех реплик
+CREATE TABLE IF NOT EXISTS auth_lockouts (
+    key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    window_start TIMESTAMPTZ,
+    lockouts INTEGER NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE INDEX IF NOT EXISTS idx_auth_lockouts_updated_at ON auth_lockouts(updated_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS auth_lockouts;
+-- +goose StatementEnd
@@ -4,18 +4,25 @@
 package integrations
 
 import (
+	"avito-shop/internal/audit"
 	"avito-shop/internal/domain"
 	"avito-shop/internal/erorrs"
+	"avito-shop/internal/events"
 	"avito-shop/internal/logger"
+	"avito-shop/internal/model"
+	"avito-shop/internal/ratelimit"
 	"avito-shop/internal/repository"
 	"avito-shop/internal/service"
+	"avito-shop/internal/totp"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/pressly/goose"
 	"github.com/stretchr/testify/suite"
 	"log"
 	"testing"
+	"time"
 )
 
 type IntegrationTestSuite struct {
@@ -69,7 +76,20 @@ func (s *IntegrationTestSuite) TearDownTest() {
             users, 
             items, 
             purchases,
-    		transfers
+    		transfers,
+            coin_operations,
+            user_groups,
+            user_group_members,
+            coin_lots,
+            wallets,
+            webhook_subscriptions,
+            outbox,
+            audit_log,
+            auth_lockouts,
+            api_keys,
+            password_reset_tokens,
+            user_mfa,
+            mfa_recovery_codes
         RESTART IDENTITY CASCADE`)
 	if err != nil {
 		s.FailNow("Failed to clean tables", err.Error())
@@ -82,21 +102,33 @@ func (s *IntegrationTestSuite) SetupTest() {
 }
 
 func (s *IntegrationTestSuite) saveTestUser(user domain.User) int {
-	query := `INSERT INTO users (username, password_hash, balance) 
-              VALUES ($1, $2, $3) 
+	query := `INSERT INTO users (username, password_hash, role) 
+              VALUES ($1, $2, COALESCE(NULLIF($3, ''), 'user')) 
               RETURNING id`
 
 	var id int
 	err := s.db.QueryRow(query,
 		user.Username,
 		user.PasswordHash,
-		1000,
+		user.Role,
 	).Scan(&id)
 
 	if err != nil {
 		s.Fail(err.Error())
 	}
 
+	_, err = s.db.Exec(`INSERT INTO wallets (user_id, currency, balance) VALUES ($1, $2, $3)`,
+		id, domain.DefaultCurrency, domain.NewMoney(1000))
+	if err != nil {
+		s.Fail(err.Error())
+	}
+
+	_, err = s.db.Exec(`INSERT INTO coin_lots (user_id, currency, amount, remaining) VALUES ($1, $2, $3, $3)`,
+		id, domain.DefaultCurrency, domain.NewMoney(1000))
+	if err != nil {
+		s.Fail(err.Error())
+	}
+
 	return id
 }
 
@@ -108,7 +140,7 @@ func (s *IntegrationTestSuite) saveTestItem(itemName string) int {
 	var id int
 	err := s.db.QueryRow(query,
 		itemName,
-		20,
+		domain.NewMoney(20),
 	).Scan(&id)
 
 	if err != nil {
@@ -122,7 +154,7 @@ func (s *IntegrationTestSuite) TestGetUser() {
 	existUser := domain.User{
 		Username:     "testUser1",
 		PasswordHash: "testUser1password",
-		Coins:        1000,
+		Coins:        domain.NewMoney(1000),
 	}
 
 	id := s.saveTestUser(existUser)
@@ -146,7 +178,7 @@ func (s *IntegrationTestSuite) TestGetNotFoundUser() {
 func (s *IntegrationTestSuite) TestGetItem() {
 	existItem := domain.Item{
 		Name:  "cup",
-		Price: 20,
+		Price: domain.NewMoney(20),
 	}
 
 	id := s.saveTestItem(existItem.Name)
@@ -184,10 +216,10 @@ func (s *IntegrationTestSuite) TestBuyItem_Success() {
 	err = s.repo.BuyItem(context.Background(), userFromDB, itemFromDB)
 	s.Require().NoError(err)
 
-	var balance int
-	err = s.db.QueryRow("SELECT balance FROM users WHERE id = $1", userID).Scan(&balance)
+	var balance domain.Money
+	err = s.db.QueryRow("SELECT balance FROM wallets WHERE user_id = $1 AND currency = $2", userID, domain.DefaultCurrency).Scan(&balance)
 	s.Require().NoError(err)
-	s.Require().Equal(1000-itemFromDB.Price, balance)
+	s.Require().Equal(domain.NewMoney(1000)-itemFromDB.Price, balance)
 
 	items, err := s.repo.GetPurchasedItems(context.Background(), userID)
 	s.Require().NoError(err)
@@ -230,6 +262,572 @@ func (s *IntegrationTestSuite) TestSendCoinToUser_InsufficientFunds() {
 		PasswordHash: "rich_pass",
 	})
 
-	err := s.repo.SendCoins(context.Background(), fromUserID, toUserID, 1500)
+	err := s.repo.SendCoins(context.Background(), domain.Transfer{
+		FromUserID: fromUserID,
+		ToUserID:   toUserID,
+		Amount:     domain.NewMoney(1500),
+		Currency:   domain.DefaultCurrency,
+	})
+	s.Require().ErrorIs(err, erorrs.ErrInsufficientFunds)
+}
+
+func (s *IntegrationTestSuite) TestSendCoins_MovesLots() {
+	fromUserID := s.saveTestUser(domain.User{
+		Username:     "sender",
+		PasswordHash: "sender_pass",
+	})
+
+	toUserID := s.saveTestUser(domain.User{
+		Username:     "receiver",
+		PasswordHash: "receiver_pass",
+	})
+
+	err := s.repo.SendCoins(context.Background(), domain.Transfer{
+		FromUserID: fromUserID,
+		ToUserID:   toUserID,
+		Amount:     domain.NewMoney(300),
+		Currency:   domain.DefaultCurrency,
+	})
+	s.Require().NoError(err)
+
+	var fromLots, toLots domain.Money
+	err = s.db.QueryRow("SELECT COALESCE(SUM(remaining), 0) FROM coin_lots WHERE user_id = $1", fromUserID).Scan(&fromLots)
+	s.Require().NoError(err)
+	err = s.db.QueryRow("SELECT COALESCE(SUM(remaining), 0) FROM coin_lots WHERE user_id = $1", toUserID).Scan(&toLots)
+	s.Require().NoError(err)
+
+	s.Require().Equal(domain.NewMoney(700), fromLots)
+	s.Require().Equal(domain.NewMoney(1300), toLots)
+}
+
+func (s *IntegrationTestSuite) TestSendCoins_SpendsEarliestExpiringLotsFirst() {
+	fromUserID := s.saveTestUser(domain.User{Username: "sender", PasswordHash: "sender_pass"})
+	toUserID := s.saveTestUser(domain.User{Username: "receiver", PasswordHash: "receiver_pass"})
+
+	_, err := s.db.Exec(`UPDATE coin_lots SET expires_at = now() + interval '1 day' WHERE user_id = $1`, fromUserID)
+	s.Require().NoError(err)
+	_, err = s.db.Exec(`UPDATE coin_lots SET expires_at = now() + interval '30 days' WHERE user_id = $1`, toUserID)
+	s.Require().NoError(err)
+
+	transfer := domain.Transfer{FromUserID: fromUserID, ToUserID: toUserID, Amount: domain.NewMoney(300), Currency: domain.DefaultCurrency}
+	s.Require().NoError(s.repo.SendCoins(context.Background(), transfer))
+
+	// полученная партия начислена позже собственной, но сгорает раньше и тратится первой
+	transfer = domain.Transfer{FromUserID: toUserID, ToUserID: fromUserID, Amount: domain.NewMoney(300), Currency: domain.DefaultCurrency}
+	s.Require().NoError(s.repo.SendCoins(context.Background(), transfer))
+
+	var longLived domain.Money
+	err = s.db.QueryRow(
+		`SELECT COALESCE(SUM(remaining), 0) FROM coin_lots WHERE user_id = $1 AND expires_at > now() + interval '7 days'`,
+		toUserID,
+	).Scan(&longLived)
+	s.Require().NoError(err)
+	s.Require().Equal(domain.NewMoney(1000), longLived)
+}
+
+func (s *IntegrationTestSuite) TestSendCoins_IgnoresUnsweptExpiredLots() {
+	fromUserID := s.saveTestUser(domain.User{Username: "sender", PasswordHash: "sender_pass"})
+	toUserID := s.saveTestUser(domain.User{Username: "receiver", PasswordHash: "receiver_pass"})
+
+	// срок истек, но задание сгорания еще не запускалось
+	_, err := s.db.Exec(`UPDATE coin_lots SET expires_at = now() - interval '1 minute' WHERE user_id = $1`, fromUserID)
+	s.Require().NoError(err)
+
+	user, err := s.repo.GetUser(context.Background(), fromUserID)
+	s.Require().NoError(err)
+	s.Require().Equal(domain.Money(0), user.Coins)
+
+	wallets, err := s.repo.GetWallets(context.Background(), fromUserID)
+	s.Require().NoError(err)
+	s.Require().Len(wallets, 1)
+	s.Require().Equal(domain.Money(0), wallets[0].Balance)
+
+	err = s.repo.SendCoins(context.Background(), domain.Transfer{
+		FromUserID: fromUserID,
+		ToUserID:   toUserID,
+		Amount:     domain.NewMoney(100),
+		Currency:   domain.DefaultCurrency,
+	})
+	s.Require().ErrorIs(err, erorrs.ErrInsufficientFunds)
+}
+
+func (s *IntegrationTestSuite) TestExpireCoins() {
+	userID := s.saveTestUser(domain.User{
+		Username:     "expiring",
+		PasswordHash: "expiring_pass",
+	})
+
+	_, err := s.db.Exec(`UPDATE coin_lots SET expires_at = now() - interval '1 day' WHERE user_id = $1`, userID)
+	s.Require().NoError(err)
+
+	affected, err := s.repo.ExpireCoins(context.Background(), time.Now())
+	s.Require().NoError(err)
+	s.Require().Equal(1, affected)
+
+	user, err := s.repo.GetUser(context.Background(), userID)
+	s.Require().NoError(err)
+	s.Require().Equal(domain.Money(0), user.Coins)
+
+	history, err := s.repo.GetCoinHistory(context.Background(), userID, user.Username)
+	s.Require().NoError(err)
+	s.Require().Len(history.Operations, 1)
+	s.Require().Equal(domain.OperationExpire, history.Operations[0].Type)
+	s.Require().Equal(domain.NewMoney(-1000), history.Operations[0].Amount)
+
+	batch := s.publishOutbox()
+	s.Require().Len(batch, 1)
+	s.Require().Equal(events.TypeBalanceChanged, batch[0].Type)
+	s.Require().Equal(userID, batch[0].UserID)
+	s.Require().JSONEq(`{"currency":"coin","delta":"-1000.00"}`, string(batch[0].Data))
+}
+
+func (s *IntegrationTestSuite) TestMintCoins_WritesOutbox() {
+	firstID := s.saveTestUser(domain.User{Username: "first", PasswordHash: "hash"})
+	secondID := s.saveTestUser(domain.User{Username: "second", PasswordHash: "hash"})
+	adminID := s.saveTestUser(domain.User{Username: "admin", PasswordHash: "hash"})
+
+	admin := repository.NewAdminRepo(s.db, logger.NewLogger())
+	err := admin.ApplyCoinOperation(context.Background(), []int{firstID, secondID}, domain.CoinOperation{
+		Type:     domain.OperationMint,
+		Currency: domain.DefaultCurrency,
+		Amount:   domain.NewMoney(50),
+		Reason:   "bonus",
+		ActorID:  adminID,
+	})
+	s.Require().NoError(err)
+
+	batch := s.publishOutbox()
+	s.Require().Len(batch, 2)
+	for i, userID := range []int{firstID, secondID} {
+		s.Require().Equal(events.TypeBalanceChanged, batch[i].Type)
+		s.Require().Equal(userID, batch[i].UserID)
+		s.Require().JSONEq(`{"currency":"coin","delta":"50.00"}`, string(batch[i].Data))
+	}
+}
+
+// publishOutbox забирает из outbox все неопубликованные события
+func (s *IntegrationTestSuite) publishOutbox() []events.Event {
+	var batch []events.Event
+	_, err := repository.NewOutboxRepo(s.db, logger.NewLogger()).ProcessBatch(context.Background(), 100, func(_ context.Context, b []events.Event) int {
+		batch = b
+		return len(b)
+	})
+	s.Require().NoError(err)
+
+	return batch
+}
+
+func (s *IntegrationTestSuite) TestSendCoins_UnknownCurrency() {
+	fromUserID := s.saveTestUser(domain.User{
+		Username:     "sender",
+		PasswordHash: "sender_pass",
+	})
+
+	toUserID := s.saveTestUser(domain.User{
+		Username:     "receiver",
+		PasswordHash: "receiver_pass",
+	})
+
+	err := s.repo.SendCoins(context.Background(), domain.Transfer{
+		FromUserID: fromUserID,
+		ToUserID:   toUserID,
+		Amount:     domain.NewMoney(1),
+		Currency:   "doubloon",
+	})
+	s.Require().ErrorIs(err, erorrs.ErrCurrencyNotFound)
+}
+
+func (s *IntegrationTestSuite) TestSendCoins_SeparateWallets() {
+	fromUserID := s.saveTestUser(domain.User{
+		Username:     "sender",
+		PasswordHash: "sender_pass",
+	})
+
+	toUserID := s.saveTestUser(domain.User{
+		Username:     "receiver",
+		PasswordHash: "receiver_pass",
+	})
+
+	err := s.repo.SendCoins(context.Background(), domain.Transfer{
+		FromUserID: fromUserID,
+		ToUserID:   toUserID,
+		Amount:     domain.NewMoney(1),
+		Currency:   "karma",
+	})
+	s.Require().ErrorIs(err, erorrs.ErrInsufficientFunds)
+
+	wallets, err := s.repo.GetWallets(context.Background(), fromUserID)
+	s.Require().NoError(err)
+	s.Require().Len(wallets, 1)
+	s.Require().Equal(domain.DefaultCurrency, wallets[0].Currency)
+	s.Require().Equal(domain.NewMoney(1000), wallets[0].Balance)
+}
+
+func (s *IntegrationTestSuite) TestSendCoins_FeeGoesToTreasury() {
+	treasuryID := s.saveTestUser(domain.User{
+		Username:     domain.TreasuryUsername,
+		PasswordHash: "!",
+		Role:         domain.RoleSystem,
+	})
+
+	fromUserID := s.saveTestUser(domain.User{
+		Username:     "sender",
+		PasswordHash: "sender_pass",
+	})
+
+	toUserID := s.saveTestUser(domain.User{
+		Username:     "receiver",
+		PasswordHash: "receiver_pass",
+	})
+
+	err := s.repo.SendCoins(context.Background(), domain.Transfer{
+		FromUserID: fromUserID,
+		ToUserID:   toUserID,
+		Amount:     domain.NewMoney(100),
+		Fee:        domain.NewMoney(2),
+		Currency:   domain.DefaultCurrency,
+	})
+	s.Require().NoError(err)
+
+	sender, err := s.repo.GetUser(context.Background(), fromUserID)
+	s.Require().NoError(err)
+	s.Require().Equal(domain.NewMoney(898), sender.Coins)
+
+	receiver, err := s.repo.GetUser(context.Background(), toUserID)
+	s.Require().NoError(err)
+	s.Require().Equal(domain.NewMoney(1100), receiver.Coins)
+
+	treasury, err := s.repo.GetUser(context.Background(), treasuryID)
+	s.Require().NoError(err)
+	s.Require().Equal(domain.NewMoney(1002), treasury.Coins)
+
+	history, err := s.repo.GetCoinHistory(context.Background(), fromUserID, sender.Username)
+	s.Require().NoError(err)
+	s.Require().Len(history.Sent, 1)
+	s.Require().Equal(domain.NewMoney(2), history.Sent[0].Fee)
+}
+
+func (s *IntegrationTestSuite) TestSendCoins_FeeNeverGoesToRegularUser() {
+	// обычный пользователь с именем казначейства не становится счетом для комиссий
+	impostorID := s.saveTestUser(domain.User{Username: domain.TreasuryUsername, PasswordHash: "hash"})
+	fromUserID := s.saveTestUser(domain.User{Username: "sender", PasswordHash: "sender_pass"})
+	toUserID := s.saveTestUser(domain.User{Username: "receiver", PasswordHash: "receiver_pass"})
+
+	err := s.repo.SendCoins(context.Background(), domain.Transfer{
+		FromUserID: fromUserID,
+		ToUserID:   toUserID,
+		Amount:     domain.NewMoney(100),
+		Fee:        domain.NewMoney(2),
+		Currency:   domain.DefaultCurrency,
+	})
+	s.Require().Error(err)
+
+	impostor, err := s.repo.GetUser(context.Background(), impostorID)
+	s.Require().NoError(err)
+	s.Require().Equal(domain.NewMoney(1000), impostor.Coins)
+}
+
+func (s *IntegrationTestSuite) TestWebhookDeliveryLifecycle() {
+	ctx := context.Background()
+	repo := repository.NewWebhookRepo(s.db, logger.NewLogger())
+
+	sub, err := repo.CreateSubscription(ctx, domain.WebhookSubscription{
+		URL:        "http://localhost/hook",
+		Secret:     "secret",
+		EventTypes: []string{"coins.received"},
+	})
+	s.Require().NoError(err)
+
+	enqueued, err := repo.EnqueueDeliveries(ctx, "purchase.completed", []byte(`{}`))
+	s.Require().NoError(err)
+	s.Require().Equal(0, enqueued)
+
+	enqueued, err = repo.EnqueueDeliveries(ctx, "coins.received", []byte(`{"userId":1}`))
+	s.Require().NoError(err)
+	s.Require().Equal(1, enqueued)
+
+	now := time.Now()
+	claimed, err := repo.ClaimDueDeliveries(ctx, now, time.Minute, 10)
+	s.Require().NoError(err)
+	s.Require().Len(claimed, 1)
+	s.Require().Equal("secret", claimed[0].Secret)
+	s.Require().JSONEq(`{"userId":1}`, string(claimed[0].Payload))
+
+	// взятая в работу доставка не выдается повторно до истечения lease
+	again, err := repo.ClaimDueDeliveries(ctx, now, time.Minute, 10)
+	s.Require().NoError(err)
+	s.Require().Empty(again)
+
+	err = repo.RecordAttempt(ctx, domain.WebhookAttempt{
+		DeliveryID: claimed[0].ID,
+		Attempt:    1,
+		StatusCode: 500,
+		Error:      "unexpected status 500",
+	}, domain.WebhookDead, now)
+	s.Require().NoError(err)
+
+	s.Require().NoError(repo.RequeueDelivery(ctx, sub.ID, claimed[0].ID))
+	s.Require().ErrorIs(repo.RequeueDelivery(ctx, sub.ID, claimed[0].ID), erorrs.ErrDeliveryNotFound)
+
+	deliveries, err := repo.GetDeliveries(ctx, sub.ID, 10)
+	s.Require().NoError(err)
+	s.Require().Len(deliveries, 1)
+	s.Require().Equal(domain.WebhookPending, deliveries[0].Status)
+	s.Require().Len(deliveries[0].Log, 1)
+	s.Require().Equal(500, deliveries[0].Log[0].StatusCode)
+}
+
+func (s *IntegrationTestSuite) TestSendCoins_WritesOutbox() {
+	fromUserID := s.saveTestUser(domain.User{
+		Username:     "outbox_sender",
+		PasswordHash: "sender_pass",
+	})
+
+	toUserID := s.saveTestUser(domain.User{
+		Username:     "outbox_receiver",
+		PasswordHash: "receiver_pass",
+	})
+
+	err := s.repo.SendCoins(context.Background(), domain.Transfer{
+		FromUserID: fromUserID,
+		ToUserID:   toUserID,
+		Amount:     domain.NewMoney(2000),
+		Currency:   domain.DefaultCurrency,
+	})
 	s.Require().ErrorIs(err, erorrs.ErrInsufficientFunds)
+
+	err = s.repo.SendCoins(context.Background(), domain.Transfer{
+		FromUserID: fromUserID,
+		ToUserID:   toUserID,
+		Amount:     domain.NewMoney(300),
+		Currency:   domain.DefaultCurrency,
+	})
+	s.Require().NoError(err)
+
+	outbox := repository.NewOutboxRepo(s.db, logger.NewLogger())
+
+	var batch []events.Event
+	published, err := outbox.ProcessBatch(context.Background(), 10, func(_ context.Context, b []events.Event) int {
+		batch = b
+		return len(b)
+	})
+	s.Require().NoError(err)
+
+	// неудавшийся перевод событий не оставляет
+	s.Require().Equal(3, published)
+	s.Require().Equal(events.TypeBalanceChanged, batch[0].Type)
+	s.Require().Equal(fromUserID, batch[0].UserID)
+	s.Require().Equal(events.TypeCoinsReceived, batch[1].Type)
+	s.Require().Equal(toUserID, batch[1].UserID)
+	s.Require().JSONEq(`{"fromUser":"outbox_sender","amount":"300.00","currency":"coin"}`, string(batch[1].Data))
+
+	published, err = outbox.ProcessBatch(context.Background(), 10, func(_ context.Context, b []events.Event) int {
+		return len(b)
+	})
+	s.Require().NoError(err)
+	s.Require().Equal(0, published)
+}
+
+func (s *IntegrationTestSuite) TestAuditLog_ChainAndImmutability() {
+	log := logger.NewLogger()
+	auditService := service.NewAuditService(repository.NewAuditRepo(s.db, log), log)
+
+	ctx := audit.WithRequestInfo(context.Background(), audit.RequestInfo{IP: "10.0.0.1", UserAgent: "curl/8", RequestID: "req-1"})
+	for _, entry := range []domain.AuditEntry{
+		{ActorID: 1, Action: domain.AuditLogin, Target: "user:alice"},
+		{Action: domain.AuditLoginFailed, Target: "user:bob"},
+		{ActorID: 1, Action: domain.AuditTransfer, Target: "user:bob", Details: map[string]string{"amount": "10.00", "currency": "coin"}},
+	} {
+		s.Require().NoError(auditService.Record(ctx, entry))
+	}
+
+	checked, err := auditService.Verify(context.Background())
+	s.Require().NoError(err)
+	s.Require().Equal(3, checked)
+
+	entries, err := auditService.Query(context.Background(), model.AuditQueryDTO{ActorID: 1})
+	s.Require().NoError(err)
+	s.Require().Len(entries, 2)
+	s.Require().Equal(domain.AuditTransfer, entries[0].Action)
+	s.Require().Equal("10.00", entries[0].Details["amount"])
+	s.Require().Equal("req-1", entries[0].RequestID)
+
+	_, err = s.db.Exec(`UPDATE audit_log SET target = 'user:mallory' WHERE id = 2`)
+	s.Require().Error(err)
+	_, err = s.db.Exec(`DELETE FROM audit_log WHERE id = 2`)
+	s.Require().Error(err)
+
+	// правка в обход триггера видна при проверке цепочки
+	_, err = s.db.Exec(`ALTER TABLE audit_log DISABLE TRIGGER audit_log_append_only`)
+	s.Require().NoError(err)
+	_, err = s.db.Exec(`UPDATE audit_log SET details = '{"amount": "1000.00", "currency": "coin"}' WHERE id = 3`)
+	s.Require().NoError(err)
+	_, err = s.db.Exec(`ALTER TABLE audit_log ENABLE TRIGGER audit_log_append_only`)
+	s.Require().NoError(err)
+
+	checked, err = auditService.Verify(context.Background())
+	var chainErr *audit.ChainError
+	s.Require().True(errors.As(err, &chainErr))
+	s.Require().Equal(int64(3), chainErr.EntryID)
+	s.Require().Equal(0, checked)
+}
+
+func (s *IntegrationTestSuite) TestAuthLockout_SharedStore() {
+	ctx := context.Background()
+	store := repository.NewLockoutRepo(s.db, logger.NewLogger())
+	policy := ratelimit.LockoutPolicy{MaxFailures: 2, Window: time.Minute, BaseLockout: time.Minute, MaxLockout: time.Hour}
+	guard := ratelimit.NewGuard(store, policy, ratelimit.LockoutPolicy{})
+
+	retryAfter, err := guard.Fail(ctx, "alice", "10.0.0.1")
+	s.Require().NoError(err)
+	s.Require().Zero(retryAfter)
+
+	retryAfter, err = guard.Fail(ctx, "alice", "10.0.0.1")
+	s.Require().NoError(err)
+	s.Require().Equal(time.Minute, retryAfter.Round(time.Minute))
+
+	// вторая реплика видит блокировку через общее хранилище
+	other := ratelimit.NewGuard(repository.NewLockoutRepo(s.db, logger.NewLogger()), policy, ratelimit.LockoutPolicy{})
+	retryAfter, err = other.Check(ctx, "alice", "10.0.0.2")
+	s.Require().NoError(err)
+	s.Require().Positive(retryAfter)
+
+	s.Require().NoError(guard.Succeed(ctx, "alice"))
+	retryAfter, err = other.Check(ctx, "alice", "10.0.0.2")
+	s.Require().NoError(err)
+	s.Require().Zero(retryAfter)
+}
+
+func (s *IntegrationTestSuite) TestAPIKeyLifecycle() {
+	ctx := context.Background()
+	log := logger.NewLogger()
+	s.saveTestUser(domain.User{Username: "ci-bot", PasswordHash: "hash"})
+	adminID := s.saveTestUser(domain.User{Username: "admin", PasswordHash: "hash"})
+
+	keys := service.NewAPIKeyService(repository.NewAPIKeyRepo(s.db, log), service.NewAuditService(repository.NewAuditRepo(s.db, log), log), log)
+
+	created, err := keys.CreateKey(ctx, adminID, model.APIKeyRequestDTO{
+		Username: "ci-bot",
+		Name:     "merged PRs",
+		Scopes:   []string{domain.ScopeCoinsSend},
+	})
+	s.Require().NoError(err)
+
+	key, err := keys.Authenticate(ctx, created.Key)
+	s.Require().NoError(err)
+	s.Require().Equal(created.ID, key.ID)
+	s.Require().True(key.HasScope(domain.ScopeCoinsSend))
+
+	listed, err := keys.ListKeys(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(listed, 1)
+	s.Require().Empty(listed[0].Key)
+	s.Require().NotNil(listed[0].LastUsedAt)
+
+	s.Require().NoError(keys.RevokeKey(ctx, adminID, created.ID))
+	_, err = keys.Authenticate(ctx, created.Key)
+	s.Require().ErrorIs(err, erorrs.ErrAPIKeyInvalid)
+
+	_, err = keys.CreateKey(ctx, adminID, model.APIKeyRequestDTO{Username: "ghost", Name: "bot", Scopes: []string{domain.ScopeInfoRead}})
+	s.Require().ErrorIs(err, erorrs.ErrNotFound)
+}
+
+func (s *IntegrationTestSuite) TestPasswordChangeAndReset() {
+	ctx := context.Background()
+	log := logger.NewLogger()
+	adminID := s.saveTestUser(domain.User{Username: "admin", PasswordHash: "hash"})
+
+	guard := ratelimit.NewGuard(ratelimit.NewMemoryStore(), ratelimit.LockoutPolicy{}, ratelimit.LockoutPolicy{})
+	policy := domain.PasswordPolicy{MinLength: 8, RequireLetter: true, RequireDigit: true, ResetTTL: time.Hour}
+	auth := service.NewAuthService(repository.NewAuthRepo(s.db, log), service.NewAuditService(repository.NewAuditRepo(s.db, log), log),
+		guard, log, 0, domain.ExpiryPolicy{}, policy)
+
+	_, err := auth.Authorization(ctx, model.AuthRequestDTO{Username: "alice", Password: "short"})
+	s.Require().ErrorIs(err, erorrs.ErrWeakPassword)
+
+	first, err := auth.Authorization(ctx, model.AuthRequestDTO{Username: "alice", Password: "oldpass1"})
+	s.Require().NoError(err)
+	session, err := service.ParseToken(first)
+	s.Require().NoError(err)
+	s.Require().NoError(auth.CheckSession(ctx, session.UserID, session.Version))
+
+	_, err = auth.ChangePassword(ctx, session.UserID, domain.Scopes, model.PasswordChangeRequestDTO{CurrentPassword: "guess", NewPassword: "newpass2"})
+	s.Require().ErrorIs(err, erorrs.ErrWrongPassword)
+
+	second, err := auth.ChangePassword(ctx, session.UserID, domain.Scopes, model.PasswordChangeRequestDTO{CurrentPassword: "oldpass1", NewPassword: "newpass2"})
+	s.Require().NoError(err)
+	s.Require().ErrorIs(auth.CheckSession(ctx, session.UserID, session.Version), erorrs.ErrSessionRevoked)
+	renewed, err := service.ParseToken(second)
+	s.Require().NoError(err)
+	s.Require().NoError(auth.CheckSession(ctx, renewed.UserID, renewed.Version))
+
+	reset, err := auth.IssueResetToken(ctx, adminID, "alice")
+	s.Require().NoError(err)
+	s.Require().NoError(auth.ResetPassword(ctx, model.PasswordResetRequestDTO{Token: reset.Token, NewPassword: "resetpass3"}))
+	s.Require().ErrorIs(auth.CheckSession(ctx, renewed.UserID, renewed.Version), erorrs.ErrSessionRevoked)
+
+	// токен сброса одноразовый
+	err = auth.ResetPassword(ctx, model.PasswordResetRequestDTO{Token: reset.Token, NewPassword: "another4"})
+	s.Require().ErrorIs(err, erorrs.ErrResetTokenInvalid)
+
+	_, err = auth.Authorization(ctx, model.AuthRequestDTO{Username: "alice", Password: "newpass2"})
+	s.Require().ErrorIs(err, erorrs.ErrUserExist)
+	_, err = auth.Authorization(ctx, model.AuthRequestDTO{Username: "alice", Password: "resetpass3"})
+	s.Require().NoError(err)
+
+	_, err = auth.IssueResetToken(ctx, adminID, "ghost")
+	s.Require().ErrorIs(err, erorrs.ErrNotFound)
+}
+
+func (s *IntegrationTestSuite) TestMFAEnrollAndLogin() {
+	ctx := context.Background()
+	log := logger.NewLogger()
+
+	guard := ratelimit.NewGuard(ratelimit.NewMemoryStore(), ratelimit.LockoutPolicy{}, ratelimit.LockoutPolicy{})
+	policy := domain.PasswordPolicy{MinLength: 8, RequireLetter: true, RequireDigit: true, ResetTTL: time.Hour}
+	auth := service.NewAuthService(repository.NewAuthRepo(s.db, log), service.NewAuditService(repository.NewAuditRepo(s.db, log), log),
+		guard, log, 0, domain.ExpiryPolicy{}, policy)
+
+	first, err := auth.Authorization(ctx, model.AuthRequestDTO{Username: "alice", Password: "oldpass1"})
+	s.Require().NoError(err)
+	session, err := service.ParseToken(first)
+	s.Require().NoError(err)
+
+	_, err = auth.ConfirmMFA(ctx, session.UserID, model.MFAConfirmRequestDTO{Code: "123456"})
+	s.Require().ErrorIs(err, erorrs.ErrMFANotEnrolled)
+
+	enrollment, err := auth.EnrollMFA(ctx, session.UserID)
+	s.Require().NoError(err)
+
+	step := totp.Step(time.Now())
+	code, err := totp.Code(enrollment.Secret, step)
+	s.Require().NoError(err)
+	recovery, err := auth.ConfirmMFA(ctx, session.UserID, model.MFAConfirmRequestDTO{Code: code})
+	s.Require().NoError(err)
+	s.Require().NotEmpty(recovery.RecoveryCodes)
+
+	_, err = auth.EnrollMFA(ctx, session.UserID)
+	s.Require().ErrorIs(err, erorrs.ErrMFAAlreadyEnabled)
+
+	_, err = auth.Authorization(ctx, model.AuthRequestDTO{Username: "alice", Password: "oldpass1", Scopes: []string{domain.ScopeInfoRead}})
+	var required *service.MFARequiredError
+	s.Require().True(errors.As(err, &required))
+
+	// код подтверждения подключения повторно не принимается
+	_, err = auth.VerifyMFA(ctx, model.MFAVerifyRequestDTO{MFAToken: required.Token, Code: code})
+	s.Require().ErrorIs(err, erorrs.ErrMFACodeInvalid)
+
+	next, err := totp.Code(enrollment.Secret, step+1)
+	s.Require().NoError(err)
+	token, err := auth.VerifyMFA(ctx, model.MFAVerifyRequestDTO{MFAToken: required.Token, Code: next})
+	s.Require().NoError(err)
+	access, err := service.ParseToken(token)
+	s.Require().NoError(err)
+	s.Require().Equal([]string{domain.ScopeInfoRead}, access.Scopes)
+
+	// код восстановления одноразовый
+	_, err = auth.VerifyMFA(ctx, model.MFAVerifyRequestDTO{MFAToken: required.Token, Code: recovery.RecoveryCodes[0]})
+	s.Require().NoError(err)
+	_, err = auth.VerifyMFA(ctx, model.MFAVerifyRequestDTO{MFAToken: required.Token, Code: recovery.RecoveryCodes[0]})
+	s.Require().ErrorIs(err, erorrs.ErrMFACodeInvalid)
 }